@@ -0,0 +1,75 @@
+package garkov
+
+import (
+	"unicode"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// Acrostic creates a sentence for each letter of the word, beginning with a word that starts
+// with the letter, so that the first letters of the sentences spell the word. Characters
+// that are not letters are skipped. The sentence of a letter that no start prefix begins
+// with is empty.
+func (m *Markov) Acrostic(word string) []string {
+	return m.AcrosticWithOptions(word, GenOptions{})
+}
+
+// AcrosticWithOptions creates a sentence for each letter of the word, see Acrostic.
+// opts.StartWith is ignored.
+func (m *Markov) AcrosticWithOptions(word string, opts GenOptions) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	index := m.letterIndex(opts)
+
+	var sentences []string
+	for _, r := range word {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+
+		candidates := index[unicode.ToLower(r)]
+		if len(candidates) == 0 {
+			sentences = append(sentences, "")
+			continue
+		}
+		sentences = append(sentences, m.fit(func() []dictionary.Word {
+			return m.prefixWords(m.drawStartOf(candidates))
+		}, opts))
+	}
+	return sentences
+}
+
+// letterIndex returns the indices of the start prefixes the word filters accept, by the
+// lower case first letter of their first word. Quotes and other marks before the first
+// word are skipped.
+func (m *Markov) letterIndex(opts GenOptions) map[rune][]int {
+	filter := m.wordFilter(opts)
+
+	index := make(map[rune][]int)
+	for i, prefix := range m.Start {
+		if !m.allowedPrefix(prefix, filter) {
+			continue
+		}
+		if r, found := m.firstLetter(prefix); found {
+			index[r] = append(index[r], i)
+		}
+	}
+	return index
+}
+
+// firstLetter returns the lower case first letter of a prefix as it is generated, and false
+// if a digit comes first or it has no letters
+func (m *Markov) firstLetter(prefix []int) (rune, bool) {
+	for _, idx := range prefix {
+		for _, r := range m.Dict.Form(m.Dict.WordAt(idx)) {
+			if unicode.IsLetter(r) {
+				return unicode.ToLower(r), true
+			}
+			if unicode.IsDigit(r) {
+				return 0, false
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,73 @@
+package garkov
+
+// aliasTable draws one of n outcomes with given weights in constant time, by Vose's alias
+// method. Outcome i is drawn with probability prob[i] from slot i, otherwise alias[i] is.
+type aliasTable struct {
+	prob  []float64
+	alias []int32
+}
+
+// newAliasTable creates the alias table of the weights, which must not all be 0
+func newAliasTable(weights []float64) aliasTable {
+	n := len(weights)
+	t := aliasTable{
+		prob:  make([]float64, n),
+		alias: make([]int32, n),
+	}
+
+	total := 0.0
+	for _, w := range weights {
+		total = total + w
+	}
+
+	// scale the weights to an average of 1 and pair the slots below with those above
+	scaled := make([]float64, n)
+	small := make([]int32, 0, n)
+	large := make([]int32, 0, n)
+	for i, w := range weights {
+		scaled[i] = w * float64(n) / total
+		if scaled[i] < 1 {
+			small = append(small, int32(i))
+		} else {
+			large = append(large, int32(i))
+		}
+	}
+
+	for len(small) > 0 && len(large) > 0 {
+		s := small[len(small)-1]
+		small = small[:len(small)-1]
+		l := large[len(large)-1]
+
+		t.prob[s] = scaled[s]
+		t.alias[s] = l
+		scaled[l] = scaled[l] + scaled[s] - 1
+		if scaled[l] < 1 {
+			large = large[:len(large)-1]
+			small = append(small, l)
+		}
+	}
+
+	// the rest is 1 up to rounding
+	for _, i := range large {
+		t.prob[i] = 1
+		t.alias[i] = i
+	}
+	for _, i := range small {
+		t.prob[i] = 1
+		t.alias[i] = i
+	}
+	return t
+}
+
+// draw returns the outcome covering r, a number between 0 and 1
+func (t aliasTable) draw(r float64) int {
+	pos := r * float64(len(t.prob))
+	i := int(pos)
+	if i >= len(t.prob) {
+		i = len(t.prob) - 1
+	}
+	if pos-float64(i) < t.prob[i] {
+		return i
+	}
+	return int(t.alias[i])
+}
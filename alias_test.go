@@ -0,0 +1,94 @@
+package garkov
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// aliasWeights returns the probability of each outcome of the table, computed from its slots
+func aliasWeights(t aliasTable) []float64 {
+	n := len(t.prob)
+	p := make([]float64, n)
+	for i := range t.prob {
+		p[i] = p[i] + t.prob[i]/float64(n)
+		if t.prob[i] < 1 {
+			p[t.alias[i]] = p[t.alias[i]] + (1-t.prob[i])/float64(n)
+		}
+	}
+	return p
+}
+
+func TestNewAliasTable(t *testing.T) {
+	tests := [][]float64{
+		{1},
+		{1, 1},
+		{1, 2, 3, 4},
+		{0, 5, 0, 1},
+		{1000, 1, 1, 1, 1, 1},
+		zipfWeights(100),
+	}
+
+	for _, weights := range tests {
+		total := 0.0
+		for _, w := range weights {
+			total = total + w
+		}
+
+		p := aliasWeights(newAliasTable(weights))
+		for i, w := range weights {
+			if math.Abs(p[i]-w/total) > 1e-9 {
+				t.Errorf("weights %v: outcome %d has probability %v, want %v", weights, i, p[i], w/total)
+			}
+		}
+	}
+}
+
+// zipfWeights returns n weights that fall off like the counts of the suffixes of a chain
+func zipfWeights(n int) []float64 {
+	weights := make([]float64, n)
+	for i := range weights {
+		weights[i] = math.Floor(1000 / float64(i+1))
+	}
+	return weights
+}
+
+// drawModel returns a model whose prefix "a b" has n suffixes with weights that fall off
+// like zipfWeights, and the words of the prefix
+func drawModel(b *testing.B, n int) (*Markov, []dictionary.Word) {
+	m := New("draw", WithRandom(rand.NewSource(1)))
+	first, second := m.Dict.Add("a"), m.Dict.Add("b")
+	for i, w := range zipfWeights(n) {
+		suffix := m.Dict.Add(fmt.Sprintf("w%d", i))
+		if err := m.Chain.Update([]int{first.Idx, second.Idx}, suffix.Idx, w); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return m, []dictionary.Word{first, second}
+}
+
+// BenchmarkDraw compares drawing a suffix from the chains in memory, which scans the
+// suffixes, with drawing it from compiled chains, by binary search below AliasMinSuffixes
+// suffixes and from an alias table above
+func BenchmarkDraw(b *testing.B) {
+	for _, n := range []int{16, AliasMinSuffixes, 256, 2048} {
+		m, prefix := drawModel(b, n)
+		b.Run(fmt.Sprintf("chains/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				m.suffixFor(prefix, GenOptions{}, nil)
+			}
+		})
+
+		if err := m.Compile(); err != nil {
+			b.Fatal(err)
+		}
+		b.Run(fmt.Sprintf("compiled/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				m.suffixFor(prefix, GenOptions{}, nil)
+			}
+		})
+	}
+}
@@ -1,105 +1,645 @@
 package garkov
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
 
-	"github.com/jdkato/prose/tokenize"
 	"github.com/mickuehl/garkov/dictionary"
 )
 
 // Build reads an input file and updates the markov model with its content.
-func (m *Markov) Build(fileName string) {
+func (m *Markov) Build(fileName string) error {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return m.BuildReader(f)
+}
+
+// BuildStats summarizes the text a model was built from
+type BuildStats struct {
+	Files     int // number of files read
+	Sentences int // number of sentences
+	Tokens    int // number of words and punctuation marks
+}
+
+// BuildDir walks the directory tree at root and updates the markov model with every file
+// whose name matches pattern, see filepath.Match. An empty pattern matches all files. The
+// walk stops at the first file that can not be read, see BuildDirFunc to skip those.
+func (m *Markov) BuildDir(root, pattern string) (BuildStats, error) {
+	return m.BuildDirFunc(root, pattern, nil)
+}
+
+// BuildDirFunc updates the markov model with the files of a directory tree like BuildDir.
+// onError is called with the files that can not be read or trained on, the walk stops if it
+// returns an error and skips the file otherwise. A nil onError stops at the first error.
+func (m *Markov) BuildDirFunc(root, pattern string, onError func(path string, err error) error) (BuildStats, error) {
+	var stats BuildStats
+
+	// the progress counts the lines and tokens of all files
+	p := m.newProgress()
+	defer p.report(m, 0, true)
+
+	err := filepath.Walk(root, func(path string, f os.FileInfo, err error) error {
+		if err != nil {
+			return skipFile(path, err, onError)
+		}
+		if f.IsDir() {
+			return nil
+		}
+
+		if pattern != "" {
+			match, err := filepath.Match(pattern, f.Name())
+			if err != nil {
+				return err
+			}
+			if !match {
+				return nil
+			}
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return skipFile(path, err, onError)
+		}
+		defer file.Close()
+
+		s, err := m.build(context.Background(), file, 1, 1, p)
+		if errors.Is(err, ErrShortText) {
+			m.log(slog.LevelWarn, "skipped short file", "file", path, "tokens", s.Tokens)
+			return nil
+		}
+		if err != nil {
+			return skipFile(path, fmt.Errorf("%v: %w", path, err), onError)
+		}
+
+		m.log(slog.LevelDebug, "read file", "file", path, "sentences", s.Sentences, "tokens", s.Tokens)
+		stats.Files = stats.Files + 1
+		stats.Sentences = stats.Sentences + s.Sentences
+		stats.Tokens = stats.Tokens + s.Tokens
+
+		return nil
+	})
+
+	return stats, err
+}
+
+// skipFile returns the error of a file of BuildDirFunc, or nil if onError skips the file
+func skipFile(path string, err error, onError func(path string, err error) error) error {
+	if onError == nil {
+		return err
+	}
+	return onError(path, err)
+}
+
+// BuildReader reads all text from r and updates the markov model with it.
+func (m *Markov) BuildReader(r io.Reader) error {
+	return m.BuildContext(context.Background(), r)
+}
+
+// Forget reads all text from r and removes it from the markov model, reversing BuildReader
+// with the same text. Transitions whose count drops to zero are removed, the words stay in
+// the dictionary.
+func (m *Markov) Forget(r io.Reader) error {
+	_, err := m.build(context.Background(), r, -1, 1, nil)
+	return err
+}
+
+// BuildWeighted reads all text from r and updates the markov model with it, counting each
+// transition weight times. A weight > 1 lets a small corpus outweigh larger ones.
+func (m *Markov) BuildWeighted(r io.Reader, weight float64) error {
+	if !(weight > 0) || math.IsInf(weight, 0) {
+		return fmt.Errorf("%w %v", ErrInvalidWeight, weight)
+	}
+
+	_, err := m.build(context.Background(), r, weight, 1, nil)
+	return err
+}
+
+// BuildContext reads all text from r and updates the markov model with it. The text is
+// processed paragraph by paragraph and the build stops with the context's error if ctx is
+// done. The model keeps what was built until then.
+func (m *Markov) BuildContext(ctx context.Context, r io.Reader) error {
+	_, err := m.build(ctx, r, 1, 1, nil)
+	return err
+}
+
+// build updates the model with the text of r. More than one worker tokenizes the paragraphs
+// in parallel. The progress is reported to p, or to a progress of its own if p is nil.
+func (m *Markov) build(ctx context.Context, r io.Reader, weight float64, workers int, p *progress) (stats BuildStats, err error) {
+
+	// every text is a stream of its own, so concurrent builds do not mix their chains
+	s := stream{weight: weight}
+	defer func() {
+		if err != nil {
+			m.log(slog.LevelWarn, "training failed", "sentences", stats.Sentences, "tokens", stats.Tokens, "error", err)
+			return
+		}
+		m.log(slog.LevelInfo, "trained", "sentences", stats.Sentences, "tokens", stats.Tokens, "weight", s.weight)
+	}()
+
+	// the final report follows the end of the stream
+	own := p == nil
+	if own {
+		p = m.newProgress()
+	}
+	r = p.reader(r)
+	defer func() {
+		p.tokens = p.tokens + stats.Tokens
+		if own {
+			p.report(m, 0, true)
+		}
+	}()
+	defer func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		closed, finalErr := m.finalize(&s)
+		if closed {
+			stats.Sentences = stats.Sentences + 1
+			stats.Tokens = stats.Tokens + 1
+		}
+		if err == nil {
+			err = finalErr
+		}
+
+		if err == nil {
+			err = m.checkLength(stats.Tokens)
+		}
+	}()
+
+	// the filters see the complete text, markup is not split at blank lines
+	if m.hasFilters() {
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return stats, err
+		}
+		r = strings.NewReader(m.filter(string(data)))
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 2*maxParagraph)
+	scanner.Split(m.splitFunc())
+
+	if workers > 1 {
+		f, err := m.feedParallel(ctx, &s, scanner, workers, p)
+		stats.Sentences = stats.Sentences + f.Sentences
+		stats.Tokens = stats.Tokens + f.Tokens
+		return stats, err
+	}
+
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		paragraph := scanner.Text()
+		if strings.TrimSpace(paragraph) == "" {
+			continue
+		}
+
+		m.startParagraph(&s)
+		f, err := m.feed(ctx, &s, paragraph)
+		stats.Sentences = stats.Sentences + f.Sentences
+		stats.Tokens = stats.Tokens + f.Tokens
+		if err != nil {
+			return stats, err
+		}
+		p.report(m, stats.Tokens, false)
+	}
+
+	return stats, scanner.Err()
+}
 
-	// read the file line-by-line and create an array of words
-	var tokens []dictionary.Word
+// Feed updates the markov model with a piece of a stream of text. Consecutive calls
+// continue the chain where the previous text ended, until Finalize is called.
+func (m *Markov) Feed(text string) error {
+	_, err := m.feed(context.Background(), &m.stream, m.filter(text))
+	return err
+}
 
-	tokenizer := tokenize.NewTreebankWordTokenizer()
-	sentenizer, _ := tokenize.NewPragmaticSegmenter(m.Language)
+// Finalize ends the stream of text passed to Feed. A sentence that was not terminated is
+// closed and the next call to Feed starts a new chain. Like BuildReader, it returns
+// ErrShortText if the text of the stream does not fill a single prefix.
+func (m *Markov) Finalize() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	all, err := ioutil.ReadFile(fileName)
+	tokens := m.stream.tokens
+	closed, err := m.finalize(&m.stream)
+	if closed {
+		tokens = tokens + 1
+	}
 	if err != nil {
-		fmt.Print(err)
-		return
+		return err
 	}
+	return m.checkLength(tokens)
+}
 
-	// split the text into complete sentences fist, regardless of the individual lines.
-	content := string(all)
-	for _, sentence := range sentenizer.Tokenize(content) {
-		if len(sentence) > 0 {
-			var word dictionary.Word
-			prefix := make([]int, m.Depth)
-			_prefix := 0
+// checkLength returns ErrShortText if a text of n tokens does not fill a single prefix, a
+// text like that adds no chains
+func (m *Markov) checkLength(n int) error {
+	if n > 0 && n <= m.Depth {
+		return fmt.Errorf("%w: %v tokens in a model of depth %v", ErrShortText, n, m.Depth)
+	}
+	return nil
+}
+
+// feed tokenizes the text and appends it to the stream s
+func (m *Markov) feed(ctx context.Context, s *stream, text string) (BuildStats, error) {
+	tokenizer, err := m.tokenizer()
+	if err != nil {
+		return BuildStats{}, err
+	}
+
+	return m.feedTokens(ctx, s, m.tag(tokenizer.Tokenize(text)))
+}
 
-			// now split the sentence into words
-			for _, w := range tokenizer.Tokenize(sentence) {
+// feedTokens appends the tokens to the stream s. The chains are updated after the model
+// is unlocked, they have locks of their own and generation does not wait for them.
+func (m *Markov) feedTokens(ctx context.Context, s *stream, tokens []Token) (BuildStats, error) {
+	stats, pending, err := m.streamTokens(ctx, s, tokens)
+	addErr := m.addTransitions(pending)
+	if err == nil {
+		err = addErr
+	}
+	if m.Metrics != nil && s.weight >= 0 && stats.Tokens > 0 {
+		m.Metrics.Trained(m.Name, stats.Tokens)
+	}
 
-				if filter(w) {
-					continue
-				}
+	// the stream keeps the buffer for the next tokens
+	m.mu.Lock()
+	if addErr == nil {
+		m.publishStarts(pending)
+	}
+	if s.pending == nil {
+		s.pending = pending.words[:0]
+	}
+	m.mu.Unlock()
 
-				word = m.Dict.Add(w)
-				tokens = append(tokens, word)
+	return stats, err
+}
 
-				// build the start index vector
-				if _prefix < m.Depth {
-					prefix[_prefix] = word.Idx
-					_prefix = _prefix + 1
-				}
-			}
+// streamTokens adds the tokens to the dictionary and the stream s, and returns the
+// transitions they form
+func (m *Markov) streamTokens(ctx context.Context, s *stream, tokens []Token) (BuildStats, transitions, error) {
+	var stats BuildStats
 
-			// add the prefix to the index
-			m.Start = append(m.Start, prefix)
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-			// check if the sentence ends with a STOP token and add one if not
-			if word.Type != dictionary.SENTENCE_END {
-				tokens = append(tokens, m.Dict.Add(dictionary.SENTENCE_END_TOKEN))
+	// the dictionary of a compiled model is not extended with words it can never generate
+	if _, ok := m.Chain.(*Compiled); ok {
+		return stats, s.takePending(), ErrReadOnly
+	}
+	if err := checkDepth(m.Depth); err != nil {
+		return stats, s.takePending(), err
+	}
+
+	for i, t := range tokens {
+		// check for cancellation every now and then
+		if i%1000 == 999 {
+			if err := ctx.Err(); err != nil {
+				return stats, s.takePending(), err
 			}
+		}
 
+		if m.feedWord(s, m.streamWord(s, t)) {
+			stats.Sentences = stats.Sentences + 1
 		}
+		stats.Tokens = stats.Tokens + 1
+		s.tokens = s.tokens + 1
 	}
 
-	if len(tokens) > m.Depth+1 {
-		pos := 0
+	return stats, s.takePending(), nil
+}
 
-		// only so far as there are tuples + a word
-		for pos < len(tokens)-(m.Depth) {
-			prefix := make([]dictionary.Word, m.Depth)
+// addTransitions adds the transitions and the start prefixes to the chains. The start
+// prefixes are published to generation by publishStarts afterwards.
+func (m *Markov) addTransitions(t transitions) error {
+	if err := checkDepth(m.Depth); err != nil {
+		return err
+	}
+	for i := 0; i+m.Depth < len(t.words); i = i + m.Depth + 1 {
+		if err := m.update(t.words[i:i+m.Depth+1], t.weight); err != nil {
+			return err
+		}
+	}
+	for i := 0; i+m.Depth <= len(t.starts); i = i + m.Depth {
+		if err := m.Chain.UpdateStart(t.starts[i:i+m.Depth], t.weight); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-			// read the prefix
-			i := 0
-			for i < m.Depth {
-				prefix[i] = tokens[pos+i]
-				i = i + 1
-			}
+// publishStarts adds the start prefixes of the transitions to the start index, once their
+// chains were added, so that generation never draws a start without a chain. The caller
+// holds the lock of the model.
+func (m *Markov) publishStarts(t transitions) {
+	if t.weight < 0 {
+		return
+	}
+	for i := 0; i+m.Depth <= len(t.starts); i = i + m.Depth {
+		m.updateStart(t.starts[i:i+m.Depth:i+m.Depth], t.weight)
+	}
+}
+
+// streamWord adds a token to the dictionary. When a text is removed from the model, the
+// count of the word is decreased instead and unknown words get the index -1.
+func (m *Markov) streamWord(s *stream, t Token) dictionary.Word {
+	if t.Type == 0 {
+		t.Type = dictionary.TokenType(t.Word)
+	}
+
+	if m.FoldCase || m.Dict.Normalizer != nil {
+		form := t.Word
+		if m.FoldCase {
+			form = strings.ToLower(t.Word)
+		}
+		canonical := form
+		if t.Type == dictionary.WORD {
+			canonical = m.Dict.Normalize(form)
+		}
+		if !t.Control {
+			canonical = dictionary.Escape(canonical)
+		}
+
+		// the capital letter of the first word of a sentence says nothing about its spelling,
+		// a normalized first word is counted in lower case
+		if s.weight >= 0 && len(s.start) > 0 {
+			m.Dict.AddForm(canonical, t.Word)
+		} else if s.weight >= 0 && canonical != form {
+			m.Dict.AddForm(canonical, strings.ToLower(form))
+		}
+		t.Word = canonical
+	} else if !t.Control {
+		// a word of the text that spells a control token gets an entry of its own
+		t.Word = dictionary.Escape(t.Word)
+	}
+
+	// the dictionary starts out with the period as the end of a sentence. A model whose periods
+	// do not end sentences retypes it, unless the other models of a MultiModel share the
+	// dictionary: there its periods get an escaped entry of their own.
+	if t.Word == dictionary.SENTENCE_END_TOKEN && t.Type != dictionary.SENTENCE_END {
+		if m.shared {
+			t.Word = dictionary.ESCAPE_PREFIX + t.Word
+		} else if s.weight >= 0 {
+			m.Dict.SetType(t.Word, t.Type)
+		}
+	}
 
-			// the word following the prefix
-			suffix := tokens[pos+m.Depth]
+	// beyond the limit, new words share a single entry
+	if m.MaxWords > 0 && m.Dict.Len() >= m.MaxWords && !m.Dict.Exists(t.Word) {
+		t = Token{Word: dictionary.UNKNOWN_TOKEN, Type: dictionary.WORD, Control: true}
+	}
 
-			// update the chain
-			m.Update(prefix, suffix)
-			pos = pos + 1
+	if s.weight >= 0 {
+		if t.Tag != "" {
+			m.Dict.AddTag(t.Word, t.Tag)
 		}
+		return m.Dict.AddWithType(t.Word, t.Type)
 	}
 
+	word, found := m.Dict.Remove(t.Word)
+	if !found {
+		return dictionary.Word{Word: t.Word, Type: t.Type, Idx: -1}
+	}
+	return word
 }
 
-func filter(w string) bool {
-	if len(w) > 2 {
-		return false
+// feedWord appends a word to the stream and updates the chain with it. The result is
+// true if the word completed a sentence.
+func (m *Markov) feedWord(s *stream, word dictionary.Word) bool {
+
+	weight := s.weight
+	if weight == 0 {
+		weight = 1
 	}
 
-	if w == "\"" {
-		return true
+	// the word following the prefix, added to the chains by addTransitions
+	if len(s.window) == m.Depth {
+		for _, w := range s.window {
+			s.pending = append(s.pending, w.Idx)
+		}
+		s.pending = append(s.pending, word.Idx)
+		copy(s.window, s.window[1:])
+		s.window[m.Depth-1] = word
+	} else {
+		s.window = append(s.window, word)
 	}
 
-	if w == "``" {
-		return true
+	// remember the runs of words, to avoid repeating them in generation
+	if m.Novelty > 0 {
+		m.rememberRun(s, word.Idx, weight)
 	}
 
-	if w == "''" {
+	// build the start index vector
+	if len(s.start) < m.Depth && word.Type != dictionary.SENTENCE_END {
+		s.start = append(s.start, word.Idx)
+	}
+
+	// add the prefix to the index at the end of each sentence the model starts with. A
+	// sentence of no more than Depth tokens, its STOP word included, has no prefix within
+	// the sentence and is not a start.
+	if word.Type == dictionary.SENTENCE_END {
+		if m.isStart(s) && len(s.start) == m.Depth {
+			prefix := make([]int, m.Depth)
+			copy(prefix, s.start)
+			// a new start is published once its chain is added, see publishStarts, a
+			// forgotten one is withdrawn before its chain is removed
+			if weight < 0 {
+				m.updateStart(prefix, weight)
+			}
+			s.pendingStarts = append(s.pendingStarts, prefix...)
+		}
+		s.start = s.start[:0]
+		s.sentences = s.sentences + 1
+		s.paragraph = s.paragraph + 1
+
 		return true
 	}
 
 	return false
 }
+
+// rememberRun counts the run of the last Novelty+1 words of the stream
+func (m *Markov) rememberRun(s *stream, idx int, weight float64) {
+	if len(s.run) == m.Novelty+1 {
+		copy(s.run, s.run[1:])
+		s.run[m.Novelty] = idx
+	} else {
+		s.run = append(s.run, idx)
+	}
+	if len(s.run) <= m.Novelty {
+		return
+	}
+
+	h := ngramHash(s.run)
+	if weight < 0 {
+		if m.ngrams[h] > 1 {
+			m.ngrams[h] = m.ngrams[h] - 1
+		} else {
+			delete(m.ngrams, h)
+		}
+		return
+	}
+
+	if m.ngrams == nil {
+		m.ngrams = make(map[uint64]int)
+	}
+	m.ngrams[h] = m.ngrams[h] + 1
+}
+
+// finalize closes a sentence the tokenizer did not terminate and resets the stream.
+// The result is true if a sentence had to be closed.
+func (m *Markov) finalize(s *stream) (bool, error) {
+	closed := false
+	if len(s.start) > 0 {
+		closed = m.feedWord(s, m.streamWord(s, Token{Word: m.endToken(), Type: dictionary.SENTENCE_END}))
+	}
+	pending := s.takePending()
+	err := m.addTransitions(pending)
+	if err == nil {
+		m.publishStarts(pending)
+	}
+
+	*s = stream{weight: s.weight}
+	return closed, err
+}
+
+// maxParagraph is the size beyond which a paragraph is split at a line break
+const maxParagraph int = 1024 * 1024
+
+// scanParagraphs is a bufio.SplitFunc that splits a text at blank lines
+func scanParagraphs(data []byte, atEOF bool) (int, []byte, error) {
+	if i, n := blankLine(data); i >= 0 {
+		return i + n, data[:i], nil
+	}
+
+	if len(data) >= maxParagraph {
+		if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		return len(data), data, nil
+	}
+
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+
+	// request more data
+	return 0, nil, nil
+}
+
+// blankLine returns the position and length of the first empty line break in data, or -1
+func blankLine(data []byte) (int, int) {
+	for i := 0; i < len(data); i++ {
+		if data[i] != '\n' {
+			continue
+		}
+
+		j := i + 1
+		if j < len(data) && data[j] == '\r' {
+			j = j + 1
+		}
+		if j < len(data) && data[j] == '\n' {
+			return i, j + 1 - i
+		}
+	}
+
+	return -1, 0
+}
+
+// tokenizer returns the tokenizer of the model, or the default one for its language.
+func (m *Markov) tokenizer() (Tokenizer, error) {
+	if m.Tokenizer != nil {
+		return m.Tokenizer, nil
+	}
+	if m.Mode == CharLevel {
+		return &CharTokenizer{}, nil
+	}
+	if isCJKLanguage(m.Language) {
+		return &CJKTokenizer{}, nil
+	}
+
+	t, err := NewTreebankTokenizer(m.Language)
+	if err != nil {
+		return nil, err
+	}
+	t.StopTokens = m.StopTokens
+	t.Abbreviations = m.Abbreviations
+	t.LineBreaks = m.LineBreaks
+	t.Placeholders = m.Placeholders
+
+	return t, nil
+}
+
+// tokenize splits a text with the tokenizer of the model, and folds the case of the
+// words if the model does
+func (m *Markov) tokenize(text string) ([]Token, error) {
+	tokenizer, err := m.tokenizer()
+	if err != nil {
+		return nil, err
+	}
+	tokens := tokenizer.Tokenize(text)
+	if m.FoldCase {
+		for i := range tokens {
+			tokens[i].Word = strings.ToLower(tokens[i].Word)
+		}
+	}
+	if m.Dict.Normalizer != nil {
+		for i, t := range tokens {
+			if t.Type == dictionary.WORD || (t.Type == 0 && dictionary.TokenType(t.Word) == dictionary.WORD) {
+				tokens[i].Word = m.Dict.Normalize(t.Word)
+			}
+		}
+	}
+
+	return tokens, nil
+}
+
+// entry returns the dictionary entry of a token of a text, folded, normalized and escaped
+// like the words the model is trained with
+func (m *Markov) entry(t Token) string {
+	w := t.Word
+	if m.FoldCase {
+		w = strings.ToLower(w)
+	}
+	if t.Type == dictionary.WORD || (t.Type == 0 && dictionary.TokenType(t.Word) == dictionary.WORD) {
+		w = m.Dict.Normalize(w)
+	}
+	if !t.Control {
+		w = dictionary.Escape(w)
+	}
+	return w
+}
+
+// endToken returns the token terminating sentences in the model
+func (m *Markov) endToken() string {
+	if t, ok := m.Tokenizer.(interface{ EndToken() string }); ok {
+		return t.EndToken()
+	}
+	if m.Mode == CharLevel {
+		return CHAR_END_TOKEN
+	}
+	if isCJKLanguage(m.Language) {
+		return CJK_END_TOKEN
+	}
+	if m.Tokenizer == nil && m.StopTokens != nil {
+		return endToken(m.StopTokens)
+	}
+	return dictionary.SENTENCE_END_TOKEN
+}
@@ -0,0 +1,166 @@
+package garkov
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestBuildDepth(t *testing.T) {
+	tests := []struct {
+		depth int
+		err   error
+	}{
+		{-1, ErrInvalidDepth},
+		{0, ErrInvalidDepth},
+		{1, nil},
+		{MaxDepth, nil},
+		{MaxDepth + 1, ErrInvalidDepth},
+	}
+
+	text := strings.Repeat("one two three four five six seven eight nine ten. ", 2)
+	for _, tt := range tests {
+		m := New("depth", WithDepth(tt.depth))
+		if err := m.BuildReader(strings.NewReader(text)); !errors.Is(err, tt.err) {
+			t.Errorf("BuildReader with depth %d: got %v, want %v", tt.depth, err, tt.err)
+		}
+
+		m = New("depth", WithDepth(tt.depth))
+		err := m.Feed(text)
+		if err == nil {
+			err = m.Finalize()
+		}
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Feed with depth %d: got %v, want %v", tt.depth, err, tt.err)
+		}
+	}
+}
+
+func TestBuildShortText(t *testing.T) {
+	tests := []struct {
+		depth int
+		text  string
+		err   error
+	}{
+		{3, "", nil},
+		{3, "Hi.", ErrShortText},
+		{3, "Hi", ErrShortText},
+		{3, "Hi there.", ErrShortText},
+		{3, "Hi there you.", nil},
+		{1, "Hi", nil},
+		{2, "Hi", ErrShortText},
+	}
+
+	for _, tt := range tests {
+		m := New("short", WithDepth(tt.depth))
+		if err := m.BuildReader(strings.NewReader(tt.text)); !errors.Is(err, tt.err) {
+			t.Errorf("BuildReader(%q) with depth %d: got %v, want %v", tt.text, tt.depth, err, tt.err)
+		}
+
+		m = New("short", WithDepth(tt.depth))
+		if err := m.Feed(tt.text); err != nil {
+			t.Fatalf("Feed(%q): %v", tt.text, err)
+		}
+		if err := m.Finalize(); !errors.Is(err, tt.err) {
+			t.Errorf("Feed(%q) and Finalize with depth %d: got %v, want %v", tt.text, tt.depth, err, tt.err)
+		}
+	}
+}
+
+func TestFinalizeFeedsAcrossCalls(t *testing.T) {
+	m := New("stream", WithDepth(3))
+	for _, text := range []string{"Hi.", "How are you?"} {
+		if err := m.Feed(text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.Finalize(); err != nil {
+		t.Errorf("Finalize after two texts: %v", err)
+	}
+}
+
+func TestBuildWeighted(t *testing.T) {
+	for _, weight := range []float64{0, -1, math.Inf(1), math.NaN()} {
+		if err := New("weighted").BuildWeighted(strings.NewReader("The cat sat on the mat."), weight); !errors.Is(err, ErrInvalidWeight) {
+			t.Errorf("BuildWeighted with weight %v: got %v, want %v", weight, err, ErrInvalidWeight)
+		}
+	}
+	if err := New("weighted").BuildWeighted(strings.NewReader("The cat sat on the mat."), 2); err != nil {
+		t.Errorf("BuildWeighted with weight 2: %v", err)
+	}
+}
+
+func TestBuildDirFunc(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("The cat sat on the mat."), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "missing"), filepath.Join(dir, "b.txt")); err != nil {
+		t.Skip(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "c.txt"), []byte("The dog ate a bone."), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := New("dir").BuildDir(dir, ""); err == nil {
+		t.Error("BuildDir read the broken link")
+	}
+
+	var skipped []string
+	stats, err := New("dir").BuildDirFunc(dir, "", func(path string, err error) error {
+		skipped = append(skipped, filepath.Base(path))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Files != 2 || len(skipped) != 1 || skipped[0] != "b.txt" {
+		t.Errorf("read %d files and skipped %v, want 2 files and b.txt", stats.Files, skipped)
+	}
+}
+
+// slowStorage yields before the updates of the chains, to widen the window in which training and
+// generation overlap
+type slowStorage struct {
+	*Chains
+}
+
+func (s slowStorage) Update(prefix []int, suffix int, weight float64) error {
+	runtime.Gosched()
+	return s.Chains.Update(prefix, suffix, weight)
+}
+
+func TestStartsHaveChains(t *testing.T) {
+	m := New("concurrent", WithStorage(slowStorage{NewChains()}, nil))
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			m.Feed(benchText(5))
+		}
+		m.Finalize()
+	}()
+
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		m.mu.RLock()
+		for i := len(m.Start) - 1; i >= 0 && i >= len(m.Start)-20; i-- {
+			if _, found := m.Chain.GetChain(m.Start[i]); !found {
+				m.mu.RUnlock()
+				t.Fatalf("start prefix %v has no chain", m.Start[i])
+			}
+		}
+		m.mu.RUnlock()
+		runtime.Gosched()
+	}
+}
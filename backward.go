@@ -0,0 +1,80 @@
+package garkov
+
+import (
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// extendLeft prepends words to a sentence, that has at least Depth words, until it reaches
+// the beginning of a sentence in the reverse chain. Without a reverse chain the sentence is
+// returned unchanged.
+func (m *Markov) extendLeft(sentence []dictionary.Word, opts GenOptions) []dictionary.Word {
+	maxTokens := opts.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = DefaultMaxTokens
+	}
+
+	// the novelty of the words is only checked to the right
+	allow := m.allowFunc(nil, GenOptions{WordFilter: opts.WordFilter})
+
+	// the words are collected in reverse order
+	var left []dictionary.Word
+	for len(left)+len(sentence)+1 < maxTokens {
+		window := make([]dictionary.Word, 0, m.Depth)
+		for i := len(left) - 1; i >= 0 && len(window) < m.Depth; i-- {
+			window = append(window, left[i])
+		}
+		for i := 0; len(window) < m.Depth; i++ {
+			window = append(window, sentence[i])
+		}
+
+		suffixes, found := findSuffixes(m.Reverse, window)
+		if !found {
+			break
+		}
+
+		word := m.pick(suffixes, opts.Temperature, allow)
+		if word.Word == "" || word.Type == dictionary.STOP {
+			// the end of the previous sentence
+			break
+		}
+		left = append(left, word)
+	}
+
+	extended := make([]dictionary.Word, 0, len(left)+len(sentence))
+	for i := len(left) - 1; i >= 0; i-- {
+		extended = append(extended, left[i])
+	}
+	return append(extended, sentence...)
+}
+
+// pick samples one of the suffixes, weighted by its count. Only the words for which allow is
+// true are sampled, a nil allow accepts all words. The word is empty if none is allowed.
+func (m *Markov) pick(suffixes []WordCount, temperature float64, allow func(idx int) bool) dictionary.Word {
+	var words []WordCount
+	var weights []float64
+	total := 0.0
+	for _, w := range suffixes {
+		if allow != nil && !allow(w.Idx) {
+			continue
+		}
+		words = append(words, w)
+		weights = append(weights, weight(w.Count, temperature))
+		total = total + weights[len(weights)-1]
+	}
+	if len(words) == 0 {
+		return dictionary.Word{}
+	}
+
+	idx := words[len(words)-1].Idx
+	pos := m.float64() * total
+	for i, w := range words {
+		if pos < weights[i] {
+			idx = w.Idx
+			break
+		}
+		pos = pos - weights[i]
+	}
+
+	word, _ := m.Dict.GetAt(idx)
+	return word
+}
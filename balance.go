@@ -0,0 +1,79 @@
+package garkov
+
+import (
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// brackets maps the tokens that open a quote or a parenthesis to the tokens that close it
+var brackets = map[string]string{
+	"(":  ")",
+	"[":  "]",
+	"{":  "}",
+	"«":  "»",
+	"‹":  "›",
+	"„":  "“",
+	"“":  "”",
+	"‘":  "’",
+	"\"": "\"",
+	"``": "''",
+}
+
+// closers are the tokens that only close a quote or a parenthesis. Generation uses them
+// only to close the innermost open one. The apostrophe ’ is not one of them.
+var closers = map[string]bool{
+	")":  true,
+	"]":  true,
+	"}":  true,
+	"»":  true,
+	"›":  true,
+	"”":  true,
+	"''": true,
+}
+
+// openBrackets returns the tokens that close the quotes and parentheses left open in the
+// sentence, the innermost last. A token that closes the innermost one, like the second “ of
+// „…“, is not taken as opening another.
+func openBrackets(sentence []dictionary.Word) []string {
+	var open []string
+	for _, w := range sentence {
+		if n := len(open); n > 0 && w.Word == open[n-1] {
+			open = open[:n-1]
+		} else if closer, found := brackets[w.Word]; found {
+			open = append(open, closer)
+		}
+	}
+	return open
+}
+
+// allowBalanced extends allow to keep the quotes and parentheses of a sentence balanced.
+// While some are open, it rejects the words that end the sentence and the closing tokens
+// other than the one of the innermost. Otherwise it rejects all closing tokens.
+func (m *Markov) allowBalanced(allow func(idx int) bool, open []string) func(idx int) bool {
+	innermost := ""
+	if len(open) > 0 {
+		innermost = open[len(open)-1]
+	}
+
+	return func(idx int) bool {
+		if allow != nil && !allow(idx) {
+			return false
+		}
+
+		w := m.Dict.WordAt(idx)
+		if w == innermost {
+			return true
+		}
+		if closers[w] {
+			return false
+		}
+		return innermost == "" || m.Dict.Words[w].Type != dictionary.STOP
+	}
+}
+
+// bracketWord returns the word of a token that closes a quote or a parenthesis
+func (m *Markov) bracketWord(token string) dictionary.Word {
+	if w, found := m.Dict.Get(token); found {
+		return w
+	}
+	return dictionary.Word{Word: token, Type: dictionary.TokenType(token), Idx: -1}
+}
@@ -0,0 +1,86 @@
+package garkov
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// batchTries is the number of sentences GenerateBatch generates per sentence it returns,
+// before it gives up finding more distinct ones
+const batchTries int = 10
+
+// GenerateBatch creates up to n distinct sentences with the options, see Generate. Their
+// length in tokens is bound by opts.MinWords and opts.MaxTokens. The model is locked once for
+// the batch and the words of the sentences share a buffer. Sentences are not cut to
+// opts.CharLimit, those that exceed it or fall short of opts.MinChars are dropped.
+// Fewer than n sentences are returned if the model does not generate as many distinct ones
+// within batchTries attempts per sentence, none if it is empty or opts.StartWith is unknown.
+func (m *Markov) GenerateBatch(n int, opts GenOptions) []string {
+	var seed []Token
+	if opts.StartWith != "" {
+		tokens, err := m.tokenize(opts.StartWith)
+		if err != nil {
+			return nil
+		}
+		seed = tokens
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if n <= 0 || len(m.Start) == 0 {
+		return nil
+	}
+
+	sentences := make([]string, 0, n)
+	seen := make(map[string]bool, n)
+	var buf []dictionary.Word
+
+	for i := 0; len(sentences) < n && i < n*batchTries; i++ {
+		begin := time.Now()
+
+		var s string
+		if opts.Syllables > 0 || opts.LastWord != nil {
+			// the constrained modes search sentences of their own
+			var err error
+			if opts.Syllables > 0 {
+				s, err = m.generateMeter(seed, opts)
+			} else {
+				s, err = m.generateEnding(seed, opts)
+			}
+			if errors.Is(err, ErrNoMeter) || errors.Is(err, ErrNoEnding) {
+				continue
+			}
+			if err != nil {
+				break
+			}
+		} else {
+			var start []dictionary.Word
+			if opts.StartWith != "" {
+				start = m.seedStart(seed)
+			} else {
+				start = m.randomStart(opts)
+			}
+			if start == nil {
+				break
+			}
+
+			buf = m.generate(append(buf[:0], start...), opts)
+			if s = m.text(buf, opts); !fits(s, opts) || !longEnough(s, opts) {
+				continue
+			}
+			if m.Metrics != nil {
+				m.Metrics.Generated(m.Name, time.Since(begin))
+			}
+		}
+
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		sentences = append(sentences, s)
+	}
+	return sentences
+}
@@ -0,0 +1,485 @@
+// Package bolt keeps the chains of a markov model in a bbolt key-value database, for models
+// that are larger than the memory. Generation reads the chains from the memory-mapped
+// database file, the dictionary and the start prefixes stay in memory.
+//
+//	chains, err := bolt.Open("model.bolt")
+//	if err != nil {
+//		...
+//	}
+//	defer chains.Close()
+//
+//	m := garkov.New("model", garkov.WithDepth(2))
+//	err = m.UseStorage(chains, nil)
+//
+// A model with a backward chain needs a second database for m.Reverse. The database holds
+// word indices only, the dictionary they refer to is written by m.Close and restored in the
+// next run with dictionary.Open and garkov.WithDictionary.
+package bolt
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"go.etcd.io/bbolt"
+
+	"github.com/mickuehl/garkov"
+)
+
+// DefaultBatchSize is the number of updates written in one transaction if Storage.BatchSize is not set
+const DefaultBatchSize int = 10000
+
+// countEpsilon is the count below which a suffix is considered removed
+const countEpsilon float64 = 1e-9
+
+// bucket holds the chains, keyed by garkov.EncodePrefix
+var bucket = []byte("chains")
+
+// startsBucket holds the counts of the start prefixes, keyed by garkov.EncodePrefix
+var startsBucket = []byte("starts")
+
+// Storage is a garkov.Storage in a bbolt database. Updates are collected in a transaction
+// that is committed after BatchSize updates, before the chains are read, and by Flush and Close.
+type Storage struct {
+	BatchSize int          // number of updates written in one transaction, 0 selects DefaultBatchSize
+	Logger    *slog.Logger // receives the errors GetChain and Len can not return, nil discards them
+
+	db      *bbolt.DB
+	tx      *bbolt.Tx // the open write transaction, or nil
+	pending int       // the number of updates in tx
+	mu      sync.Mutex
+}
+
+var _ garkov.Storage = (*Storage)(nil)
+
+// Open opens the database at path, or creates it if it does not exist. A database can only
+// be opened by one process at a time.
+func Open(path string) (*Storage, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(startsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+
+	return &Storage{db: db}, nil
+}
+
+// Close commits the pending updates and closes the database
+func (s *Storage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.commit()
+	if closeErr := s.db.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Flush commits the pending updates
+func (s *Storage) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.commit()
+}
+
+// GetChain returns the suffixes of the chain of a prefix, ordered by their word index. A
+// chain that can not be read is not found, the error goes to the Logger.
+func (s *Storage) GetChain(prefix []int) ([]garkov.WordCount, bool) {
+	if err := s.Flush(); err != nil {
+		s.logError("commit failed", err)
+		return nil, false
+	}
+
+	var suffixes []garkov.WordCount
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		value := tx.Bucket(bucket).Get(garkov.EncodePrefix(prefix))
+		if value == nil {
+			return nil
+		}
+
+		var err error
+		suffixes, err = decode(value)
+		return err
+	})
+	if err != nil {
+		s.logError("reading a chain failed", err)
+		return nil, false
+	}
+	if len(suffixes) == 0 {
+		return nil, false
+	}
+	return suffixes, true
+}
+
+// Update counts the suffix of the prefix weight times, or removes that many counts if
+// weight is negative
+func (s *Storage) Update(prefix []int, suffix int, weight float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.begin()
+	if err != nil {
+		return err
+	}
+	b := tx.Bucket(bucket)
+
+	key := garkov.EncodePrefix(prefix)
+	suffixes, err := decode(b.Get(key))
+	if err != nil {
+		return err
+	}
+
+	i := sort.Search(len(suffixes), func(i int) bool {
+		return suffixes[i].Idx >= suffix
+	})
+	if i == len(suffixes) || suffixes[i].Idx != suffix {
+		if weight < 0 {
+			return nil
+		}
+		suffixes = append(suffixes, garkov.WordCount{})
+		copy(suffixes[i+1:], suffixes[i:])
+		suffixes[i] = garkov.WordCount{Idx: suffix}
+	}
+
+	suffixes[i].Count = suffixes[i].Count + weight
+	if suffixes[i].Count <= countEpsilon {
+		suffixes = append(suffixes[:i], suffixes[i+1:]...)
+	}
+
+	if err := put(b, key, suffixes); err != nil {
+		return err
+	}
+	return s.written(1)
+}
+
+// PutChain replaces the chain of a prefix, or removes it if there are no suffixes
+func (s *Storage) PutChain(prefix []int, suffixes []garkov.WordCount) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.begin()
+	if err != nil {
+		return err
+	}
+	b := tx.Bucket(bucket)
+
+	// the suffixes are stored ordered by their index, without duplicates
+	sorted := append([]garkov.WordCount(nil), suffixes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Idx < sorted[j].Idx
+	})
+	merged := sorted[:0]
+	for _, suffix := range sorted {
+		if n := len(merged); n > 0 && merged[n-1].Idx == suffix.Idx {
+			merged[n-1].Count = merged[n-1].Count + suffix.Count
+			continue
+		}
+		merged = append(merged, suffix)
+	}
+
+	if err := put(b, garkov.EncodePrefix(prefix), merged); err != nil {
+		return err
+	}
+	return s.written(1)
+}
+
+// UpdateStart counts a start prefix weight times, or removes that many counts if weight
+// is negative
+func (s *Storage) UpdateStart(prefix []int, weight float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.begin()
+	if err != nil {
+		return err
+	}
+	b := tx.Bucket(startsBucket)
+
+	key := garkov.EncodePrefix(prefix)
+	value := b.Get(key)
+	if value == nil && weight < 0 {
+		return nil
+	}
+
+	count := weight
+	if value != nil {
+		if len(value) != 8 {
+			return fmt.Errorf("corrupt start prefix")
+		}
+		count = count + math.Float64frombits(binary.LittleEndian.Uint64(value))
+	}
+
+	if count <= countEpsilon {
+		err = b.Delete(key)
+	} else {
+		err = b.Put(key, binary.LittleEndian.AppendUint64(nil, math.Float64bits(count)))
+	}
+	if err != nil {
+		return err
+	}
+	return s.written(1)
+}
+
+// IterStarts calls f for every start prefix and its count until f returns false
+func (s *Storage) IterStarts(f func(prefix []int, count float64) bool) error {
+	if err := s.Flush(); err != nil {
+		return err
+	}
+
+	return s.db.View(func(tx *bbolt.Tx) error {
+		return rangeStarts(tx, f)
+	})
+}
+
+// Snapshot returns an in-memory copy of the chains and the start prefixes, read in one
+// transaction
+func (s *Storage) Snapshot() (garkov.Storage, error) {
+	if err := s.Flush(); err != nil {
+		return nil, err
+	}
+
+	snapshot := garkov.NewChains()
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		err := rangeChains(tx, func(prefix []int, suffixes []garkov.WordCount) bool {
+			snapshot.PutChain(prefix, suffixes)
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		return rangeStarts(tx, func(prefix []int, count float64) bool {
+			snapshot.UpdateStart(prefix, count)
+			return true
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// Len returns the number of chains, 0 if the database can not be read
+func (s *Storage) Len() int {
+	if err := s.Flush(); err != nil {
+		s.logError("commit failed", err)
+		return 0
+	}
+
+	n := 0
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		n = tx.Bucket(bucket).Stats().KeyN
+		return nil
+	})
+	if err != nil {
+		s.logError("counting the chains failed", err)
+	}
+	return n
+}
+
+// Range calls f for every chain until f returns false. The chains are ordered by the key
+// of their prefix.
+func (s *Storage) Range(f func(prefix []int, suffixes []garkov.WordCount) bool) error {
+	if err := s.Flush(); err != nil {
+		return err
+	}
+
+	return s.db.View(func(tx *bbolt.Tx) error {
+		return rangeChains(tx, f)
+	})
+}
+
+// Prune removes the suffixes counted less than minCount times, the chains left without
+// suffixes and the start prefixes without a chain. It returns the number of removed suffixes.
+func (s *Storage) Prune(minCount int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.commit(); err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucket)
+
+		// the bucket must not be modified while the cursor moves over it
+		changed := make(map[string][]garkov.WordCount)
+		c := b.Cursor()
+		for key, value := c.First(); key != nil; key, value = c.Next() {
+			suffixes, err := decode(value)
+			if err != nil {
+				return err
+			}
+
+			kept := suffixes[:0]
+			for _, suffix := range suffixes {
+				if suffix.Count >= float64(minCount) {
+					kept = append(kept, suffix)
+				}
+			}
+			if len(kept) < len(suffixes) {
+				removed = removed + len(suffixes) - len(kept)
+				changed[string(key)] = kept
+			}
+		}
+
+		for key, suffixes := range changed {
+			if err := put(b, []byte(key), suffixes); err != nil {
+				return err
+			}
+		}
+
+		var orphans [][]byte
+		starts := tx.Bucket(startsBucket)
+		c = starts.Cursor()
+		for key, _ := c.First(); key != nil; key, _ = c.Next() {
+			if b.Get(key) == nil {
+				orphans = append(orphans, append([]byte(nil), key...))
+			}
+		}
+		for _, key := range orphans {
+			if err := starts.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
+// begin returns the open write transaction, and begins one if there is none
+func (s *Storage) begin() (*bbolt.Tx, error) {
+	if s.tx == nil {
+		tx, err := s.db.Begin(true)
+		if err != nil {
+			return nil, err
+		}
+		s.tx = tx
+	}
+	return s.tx, nil
+}
+
+// written counts n updates and commits the transaction when the batch is full
+func (s *Storage) written(n int) error {
+	batchSize := s.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	s.pending = s.pending + n
+	if s.pending < batchSize {
+		return nil
+	}
+	return s.commit()
+}
+
+// commit commits the open transaction, if any
+func (s *Storage) commit() error {
+	if s.tx == nil {
+		return nil
+	}
+
+	err := s.tx.Commit()
+	s.tx = nil
+	s.pending = 0
+	return err
+}
+
+// rangeChains calls f for every chain of the transaction until f returns false
+func rangeChains(tx *bbolt.Tx, f func(prefix []int, suffixes []garkov.WordCount) bool) error {
+	c := tx.Bucket(bucket).Cursor()
+	for key, value := c.First(); key != nil; key, value = c.Next() {
+		prefix, err := garkov.DecodePrefix(key)
+		if err != nil {
+			return err
+		}
+		suffixes, err := decode(value)
+		if err != nil {
+			return err
+		}
+
+		if !f(prefix, suffixes) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// rangeStarts calls f for every start prefix of the transaction until f returns false
+func rangeStarts(tx *bbolt.Tx, f func(prefix []int, count float64) bool) error {
+	c := tx.Bucket(startsBucket).Cursor()
+	for key, value := c.First(); key != nil; key, value = c.Next() {
+		prefix, err := garkov.DecodePrefix(key)
+		if err != nil {
+			return err
+		}
+		if len(value) != 8 {
+			return fmt.Errorf("corrupt start prefix")
+		}
+
+		if !f(prefix, math.Float64frombits(binary.LittleEndian.Uint64(value))) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// put stores the suffixes of a key, or removes the key if there are none
+func put(b *bbolt.Bucket, key []byte, suffixes []garkov.WordCount) error {
+	if len(suffixes) == 0 {
+		return b.Delete(key)
+	}
+	return b.Put(key, encode(suffixes))
+}
+
+// encode packs the suffixes as the varint of each word index followed by its count
+func encode(suffixes []garkov.WordCount) []byte {
+	buf := make([]byte, 0, len(suffixes)*(binary.MaxVarintLen32+8))
+	for _, suffix := range suffixes {
+		buf = binary.AppendVarint(buf, int64(suffix.Idx))
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(suffix.Count))
+	}
+	return buf
+}
+
+// decode returns the suffixes packed by encode. The result does not share the memory of buf.
+func decode(buf []byte) ([]garkov.WordCount, error) {
+	var suffixes []garkov.WordCount
+	for len(buf) > 0 {
+		idx, n := binary.Varint(buf)
+		if n <= 0 || len(buf) < n+8 {
+			return nil, fmt.Errorf("corrupt suffixes")
+		}
+		count := math.Float64frombits(binary.LittleEndian.Uint64(buf[n:]))
+		suffixes = append(suffixes, garkov.WordCount{Idx: int(idx), Count: count})
+		buf = buf[n+8:]
+	}
+	return suffixes, nil
+}
+
+// logError writes an error to the logger of the storage, if it has one
+func (s *Storage) logError(msg string, err error) {
+	if s.Logger != nil {
+		s.Logger.Error(msg, "error", err)
+	}
+}
@@ -0,0 +1,82 @@
+package garkov
+
+import (
+	"bufio"
+	"bytes"
+)
+
+// StartMode selects the sentences whose first words become start prefixes of the model
+type StartMode int
+
+const (
+	// SentenceStarts makes the beginning of every sentence a start prefix
+	SentenceStarts StartMode = iota
+	// ParagraphStarts makes the beginning of the first sentence of each paragraph a start
+	// prefix, e.g. of the stanzas of poems
+	ParagraphStarts
+	// TextStarts makes the beginning of the first sentence of each text a start prefix
+	TextStarts
+)
+
+// isStart is true if the sentence completed in the stream begins with a start prefix
+func (m *Markov) isStart(s *stream) bool {
+	switch m.Starts {
+	case ParagraphStarts:
+		return s.paragraph == 0
+	case TextStarts:
+		return s.sentences == 0
+	}
+	return true
+}
+
+// startParagraph tells the stream that a paragraph begins
+func (m *Markov) startParagraph(s *stream) {
+	if !m.NoParagraphs {
+		s.paragraph = 0
+	}
+}
+
+// splitFunc returns the function that splits a text into the paragraphs fed to the model.
+// Without paragraphs, a text is split only at blank lines that follow the end of a sentence.
+func (m *Markov) splitFunc() bufio.SplitFunc {
+	if !m.NoParagraphs || m.LineBreaks {
+		return scanParagraphs
+	}
+
+	stops := m.StopTokens
+	if stops == nil {
+		stops = DefaultStopTokens
+	}
+
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		for offset := 0; ; {
+			i, n := blankLine(data[offset:])
+			if i < 0 {
+				break
+			}
+			if i = offset + i; endsSentence(data[:i], stops) {
+				return i + n, data[:i], nil
+			}
+			offset = i + n
+		}
+
+		if len(data) >= maxParagraph || atEOF {
+			return scanParagraphs(data, atEOF)
+		}
+		return 0, nil, nil
+	}
+}
+
+// endsSentence is true if the text ends with one of the stop tokens, or ends empty
+func endsSentence(text []byte, stops []string) bool {
+	text = bytes.TrimRight(text, " \t\r\n")
+	if len(text) == 0 {
+		return true
+	}
+	for _, stop := range stops {
+		if bytes.HasSuffix(text, []byte(stop)) {
+			return true
+		}
+	}
+	return false
+}
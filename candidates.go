@@ -0,0 +1,61 @@
+package garkov
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// ScoreFunc rates a generated sentence, a higher score is better
+type ScoreFunc func(sentence string) float64
+
+// Candidate is a generated sentence and its score
+type Candidate struct {
+	Sentence string
+	Score    float64
+}
+
+// Sentences creates n sentences based on the markov-chain
+func (m *Markov) Sentences(n int) []string {
+	sentences := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		sentences = append(sentences, m.SentenceWithOptions(GenOptions{}))
+	}
+
+	return sentences
+}
+
+// Candidates creates n sentences and rates them with the score function. The candidates
+// are ordered by their score, the best first.
+func (m *Markov) Candidates(n int, opts GenOptions, score ScoreFunc) []Candidate {
+	candidates := make([]Candidate, 0, n)
+	for i := 0; i < n; i++ {
+		s := m.SentenceWithOptions(opts)
+		if s == "" {
+			continue
+		}
+		candidates = append(candidates, Candidate{Sentence: s, Score: score(s)})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+
+	return candidates
+}
+
+// Best creates n sentences and returns the one with the highest score
+func (m *Markov) Best(n int, opts GenOptions, score ScoreFunc) string {
+	candidates := m.Candidates(n, opts, score)
+	if len(candidates) == 0 {
+		return ""
+	}
+	return candidates[0].Sentence
+}
+
+// ByLength prefers sentences with a number of words close to words
+func ByLength(words int) ScoreFunc {
+	return func(sentence string) float64 {
+		return -math.Abs(float64(len(strings.Fields(sentence)) - words))
+	}
+}
@@ -0,0 +1,327 @@
+package garkov
+
+import (
+	"encoding/binary"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultShards is the number of shards of the chains created by NewChains
+const DefaultShards int = 32
+
+// Chains is the in-memory Storage of a model. The chains are spread over shards by the hash
+// of their prefix, each with a lock of its own, so that generation and training only wait
+// for each other when they touch the same shard.
+type Chains struct {
+	shards []chainShard
+
+	starts  map[string]startEntry // the start prefixes
+	startMu sync.RWMutex          // guards starts
+}
+
+type chainShard struct {
+	mu     sync.RWMutex
+	chains map[string]*chainEntry
+}
+
+// chainEntry is a prefix and the counts of its suffixes, by their word index. The suffixes
+// ordered by their index are cached for GetChain until the counts change.
+type chainEntry struct {
+	prefix []int
+	counts map[int]float64
+	sorted atomic.Value // the []WordCount of suffixes, nil after an update
+}
+
+// startEntry is a start prefix and its count
+type startEntry struct {
+	prefix []int
+	count  float64
+}
+
+// NewChains creates empty chains with DefaultShards shards
+func NewChains() *Chains {
+	return NewShardedChains(DefaultShards)
+}
+
+// NewShardedChains creates empty chains with n shards, at least one
+func NewShardedChains(n int) *Chains {
+	if n < 1 {
+		n = 1
+	}
+
+	c := Chains{
+		shards: make([]chainShard, n),
+		starts: make(map[string]startEntry),
+	}
+	for i := range c.shards {
+		c.shards[i].chains = make(map[string]*chainEntry)
+	}
+	return &c
+}
+
+// GetChain returns the suffixes of the chain of a prefix, ordered by their word index
+func (c *Chains) GetChain(prefix []int) ([]WordCount, bool) {
+	var buf [maxKeyDepth * binary.MaxVarintLen32]byte
+	key := appendPrefixKey(buf[:0], prefix)
+	s := c.shard(key)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, found := s.chains[string(key)]
+	if !found {
+		return nil, false
+	}
+	return entry.suffixes(), true
+}
+
+// Update counts the suffix of the prefix weight times, or removes that many counts if
+// weight is negative
+func (c *Chains) Update(prefix []int, suffix int, weight float64) error {
+	var buf [maxKeyDepth * binary.MaxVarintLen32]byte
+	key := appendPrefixKey(buf[:0], prefix)
+	s := c.shard(key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, found := s.chains[string(key)]
+
+	if weight < 0 {
+		if !found {
+			return nil
+		}
+		count, found := entry.counts[suffix]
+		if !found {
+			return nil
+		}
+
+		entry.invalidate()
+		count = count + weight
+		if count > countEpsilon {
+			entry.counts[suffix] = count
+			return nil
+		}
+		delete(entry.counts, suffix)
+		if len(entry.counts) == 0 {
+			delete(s.chains, string(key))
+		}
+		return nil
+	}
+
+	// only a new chain needs a key, the counts are updated in place
+	if !found {
+		entry = &chainEntry{
+			prefix: append([]int(nil), prefix...),
+			counts: make(map[int]float64),
+		}
+		s.chains[string(key)] = entry
+	}
+	entry.counts[suffix] = entry.counts[suffix] + weight
+	entry.invalidate()
+	return nil
+}
+
+// PutChain replaces the chain of a prefix, or removes it if there are no suffixes
+func (c *Chains) PutChain(prefix []int, suffixes []WordCount) error {
+	key := prefixKey(prefix)
+	s := c.shard([]byte(key))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(suffixes) == 0 {
+		delete(s.chains, key)
+		return nil
+	}
+
+	entry := &chainEntry{
+		prefix: append([]int(nil), prefix...),
+		counts: make(map[int]float64, len(suffixes)),
+	}
+	for _, suffix := range suffixes {
+		entry.counts[suffix.Idx] = entry.counts[suffix.Idx] + suffix.Count
+	}
+	s.chains[key] = entry
+	return nil
+}
+
+// UpdateStart counts a start prefix weight times, or removes that many counts if weight
+// is negative
+func (c *Chains) UpdateStart(prefix []int, weight float64) error {
+	key := prefixKey(prefix)
+
+	c.startMu.Lock()
+	defer c.startMu.Unlock()
+
+	entry, found := c.starts[key]
+	if !found {
+		if weight < 0 {
+			return nil
+		}
+		entry.prefix = append([]int(nil), prefix...)
+	}
+
+	entry.count = entry.count + weight
+	if entry.count <= countEpsilon {
+		delete(c.starts, key)
+		return nil
+	}
+	c.starts[key] = entry
+	return nil
+}
+
+// IterStarts calls f for every start prefix and its count until f returns false
+func (c *Chains) IterStarts(f func(prefix []int, count float64) bool) error {
+	c.startMu.RLock()
+	defer c.startMu.RUnlock()
+
+	for _, entry := range c.starts {
+		if !f(entry.prefix, entry.count) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Len returns the number of chains
+func (c *Chains) Len() int {
+	n := 0
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.RLock()
+		n = n + len(s.chains)
+		s.mu.RUnlock()
+	}
+	return n
+}
+
+// Range calls f for every chain until f returns false. The shard of the chain is locked
+// while f runs.
+func (c *Chains) Range(f func(prefix []int, suffixes []WordCount) bool) error {
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.RLock()
+		for _, entry := range s.chains {
+			if !f(entry.prefix, entry.suffixes()) {
+				s.mu.RUnlock()
+				return nil
+			}
+		}
+		s.mu.RUnlock()
+	}
+	return nil
+}
+
+// Prune removes the suffixes counted less than minCount times, the chains left without
+// suffixes and the start prefixes without a chain. It returns the number of removed suffixes.
+func (c *Chains) Prune(minCount int) (int, error) {
+	removed := 0
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		for key, entry := range s.chains {
+			for idx, count := range entry.counts {
+				if count < float64(minCount) {
+					delete(entry.counts, idx)
+					entry.invalidate()
+					removed = removed + 1
+				}
+			}
+
+			if len(entry.counts) == 0 {
+				delete(s.chains, key)
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	c.startMu.Lock()
+	defer c.startMu.Unlock()
+
+	for key, entry := range c.starts {
+		if !c.has(entry.prefix) {
+			delete(c.starts, key)
+		}
+	}
+	return removed, nil
+}
+
+// Snapshot returns a copy of the chains. All shards are locked while they are copied, so
+// the copy contains each update completely or not at all.
+func (c *Chains) Snapshot() (Storage, error) {
+	// the starts are locked first, like in Prune
+	c.startMu.RLock()
+	defer c.startMu.RUnlock()
+	for i := range c.shards {
+		c.shards[i].mu.RLock()
+		defer c.shards[i].mu.RUnlock()
+	}
+
+	snapshot := NewShardedChains(len(c.shards))
+	for i := range c.shards {
+		for key, entry := range c.shards[i].chains {
+			counts := make(map[int]float64, len(entry.counts))
+			for idx, count := range entry.counts {
+				counts[idx] = count
+			}
+			snapshot.shards[i].chains[key] = &chainEntry{prefix: entry.prefix, counts: counts}
+		}
+	}
+	for key, entry := range c.starts {
+		snapshot.starts[key] = entry
+	}
+	return snapshot, nil
+}
+
+// has is true if there is a chain for the prefix
+func (c *Chains) has(prefix []int) bool {
+	var buf [maxKeyDepth * binary.MaxVarintLen32]byte
+	key := appendPrefixKey(buf[:0], prefix)
+	s := c.shard(key)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, found := s.chains[string(key)]
+	return found
+}
+
+// shard returns the shard of a key
+func (c *Chains) shard(key []byte) *chainShard {
+	// FNV-1a
+	h := uint32(2166136261)
+	for _, b := range key {
+		h = h ^ uint32(b)
+		h = h * 16777619
+	}
+	return &c.shards[h%uint32(len(c.shards))]
+}
+
+// suffixes returns the counts of the entry ordered by their word index. The slice is cached
+// and shared by the callers until the counts change, it must not be modified. The caller
+// holds a lock of the shard, concurrent readers may both sort the counts, but store the same.
+func (e *chainEntry) suffixes() []WordCount {
+	if sorted, _ := e.sorted.Load().([]WordCount); sorted != nil {
+		return sorted
+	}
+
+	suffixes := make([]WordCount, 0, len(e.counts))
+	for idx, count := range e.counts {
+		suffixes = append(suffixes, WordCount{Idx: idx, Count: count})
+	}
+	sort.Slice(suffixes, func(i, j int) bool {
+		return suffixes[i].Idx < suffixes[j].Idx
+	})
+	e.sorted.Store(suffixes)
+	return suffixes
+}
+
+// invalidate drops the cached suffixes after the counts changed. The caller holds the write
+// lock of the shard.
+func (e *chainEntry) invalidate() {
+	if e.sorted.Load() != nil {
+		e.sorted.Store([]WordCount(nil))
+	}
+}
@@ -0,0 +1,193 @@
+package garkov
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// benchPrefixes returns the prefixes of the chains of the benchmark model
+func benchPrefixes(b *testing.B) (*Chains, [][]int) {
+	m := newBenchModel(b)
+	chains := m.Chain.(*Chains)
+
+	var prefixes [][]int
+	chains.Range(func(prefix []int, suffixes []WordCount) bool {
+		prefixes = append(prefixes, prefix)
+		return true
+	})
+	return chains, prefixes
+}
+
+func TestChainsLookupAllocs(t *testing.T) {
+	c := NewChains()
+	prefix := []int{1, 2}
+	if err := c.Update(prefix, 3, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := testing.AllocsPerRun(100, func() { c.has(prefix) }); n != 0 {
+		t.Errorf("has allocates %v times, want 0", n)
+	}
+	if n := testing.AllocsPerRun(100, func() { c.Update(prefix, 3, 1) }); n != 0 {
+		t.Errorf("Update of an existing suffix allocates %v times, want 0", n)
+	}
+}
+
+func TestGetChainCache(t *testing.T) {
+	c := NewChains()
+	prefix := []int{1, 2}
+	for _, suffix := range []int{5, 3, 4} {
+		if err := c.Update(prefix, suffix, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	suffixes, _ := c.GetChain(prefix)
+	if len(suffixes) != 3 || suffixes[0].Idx != 3 || suffixes[2].Idx != 5 {
+		t.Fatalf("GetChain = %v, want the suffixes 3, 4 and 5", suffixes)
+	}
+	if n := testing.AllocsPerRun(100, func() { c.GetChain(prefix) }); n != 0 {
+		t.Errorf("GetChain of an unchanged chain allocates %v times, want 0", n)
+	}
+
+	// updates replace the cached suffixes, and leave the returned ones alone
+	c.Update(prefix, 4, 2)
+	c.Update(prefix, 5, -1)
+	updated, _ := c.GetChain(prefix)
+	if len(updated) != 2 || updated[1].Idx != 4 || updated[1].Count != 3 {
+		t.Errorf("GetChain after Update = %v, want 3:1 and 4:3", updated)
+	}
+	if len(suffixes) != 3 || suffixes[1].Count != 1 {
+		t.Errorf("Update changed the suffixes returned before: %v", suffixes)
+	}
+
+	c.Prune(2)
+	if pruned, _ := c.GetChain(prefix); len(pruned) != 1 || pruned[0].Idx != 4 {
+		t.Errorf("GetChain after Prune = %v, want 4:3", pruned)
+	}
+}
+
+func TestChainsConcurrent(t *testing.T) {
+	c := NewShardedChains(1)
+	prefix := []int{1, 2}
+	c.Update(prefix, 0, 1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				if i == 0 {
+					c.Update(prefix, j%10, 1)
+					continue
+				}
+				suffixes, _ := c.GetChain(prefix)
+				for k := 1; k < len(suffixes); k++ {
+					if suffixes[k-1].Idx >= suffixes[k].Idx {
+						t.Errorf("suffixes out of order: %v", suffixes)
+						return
+					}
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func BenchmarkGetChain(b *testing.B) {
+	chains, prefixes := benchPrefixes(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, found := chains.GetChain(prefixes[i%len(prefixes)]); !found {
+			b.Fatal("chain not found")
+		}
+	}
+}
+
+func BenchmarkHasChain(b *testing.B) {
+	chains, prefixes := benchPrefixes(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if !chains.has(prefixes[i%len(prefixes)]) {
+			b.Fatal("chain not found")
+		}
+	}
+}
+
+func BenchmarkUpdate(b *testing.B) {
+	_, prefixes := benchPrefixes(b)
+	chains := NewChains()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		prefix := prefixes[i%len(prefixes)]
+		if err := chains.Update(prefix, prefix[0], 1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// fieldsTokenizer splits a text at white space and ends a sentence after a word ending in
+// a period, exclamation or question mark. It tokenizes the text of benchText, in a fraction
+// of the time the TreebankTokenizer takes.
+type fieldsTokenizer struct{}
+
+func (fieldsTokenizer) Tokenize(text string) []Token {
+	var tokens []Token
+	for _, f := range strings.Fields(text) {
+		if end := f[len(f)-1:]; strings.ContainsAny(end, ".!?") {
+			tokens = append(tokens, Token{Word: f[:len(f)-1]}, Token{Word: end, Type: dictionary.SENTENCE_END})
+			continue
+		}
+		tokens = append(tokens, Token{Word: f})
+	}
+	return tokens
+}
+
+func BenchmarkBuildReader(b *testing.B) {
+	text := benchText(50000)
+
+	for _, tokenizer := range []struct {
+		name string
+		t    Tokenizer
+	}{
+		{"treebank", nil},
+		{"fields", fieldsTokenizer{}},
+	} {
+		b.Run(tokenizer.name, func(b *testing.B) {
+			var stats runtime.MemStats
+			runtime.GC()
+			runtime.ReadMemStats(&stats)
+			heap := stats.HeapAlloc
+
+			b.SetBytes(int64(len(text)))
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			var m *Markov
+			for i := 0; i < b.N; i++ {
+				m = New("bench", WithTokenizer(tokenizer.t))
+				if err := m.BuildReader(strings.NewReader(text)); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			// the heap the last model holds on to
+			b.StopTimer()
+			runtime.GC()
+			runtime.ReadMemStats(&stats)
+			b.ReportMetric(float64(stats.HeapAlloc-heap), "model-B")
+			runtime.KeepAlive(m)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package garkov
+
+import (
+	"bufio"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+)
+
+// castagnoli is the CRC-32 polynomial of the checksums, which most processors compute in
+// hardware
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// checksumWriter computes the checksum of the data written to w
+type checksumWriter struct {
+	w   io.Writer
+	crc uint32
+}
+
+// Write writes p to w and adds it to the checksum
+func (c *checksumWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.crc = crc32.Update(c.crc, castagnoli, p[:n])
+	return n, err
+}
+
+// sum returns the checksum as it is stored in a file
+func (c *checksumWriter) sum() []byte {
+	return binary.LittleEndian.AppendUint32(nil, c.crc)
+}
+
+// checksumReader computes the checksum of the data read from r. It is an io.ByteReader, so
+// a gob decoder reads from it without buffering and leaves the checksum that follows the
+// data in r.
+type checksumReader struct {
+	r   *bufio.Reader
+	crc uint32
+}
+
+// Read reads from r and adds the data to the checksum
+func (c *checksumReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.crc = crc32.Update(c.crc, castagnoli, p[:n])
+	return n, err
+}
+
+// ReadByte reads a byte from r and adds it to the checksum
+func (c *checksumReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.crc = crc32.Update(c.crc, castagnoli, []byte{b})
+	}
+	return b, err
+}
+
+// verify reads the checksum that follows the data from r and compares it
+func (c *checksumReader) verify() error {
+	var stored [4]byte
+	if _, err := io.ReadFull(c.r, stored[:]); err != nil {
+		return ErrChecksum
+	}
+	if binary.LittleEndian.Uint32(stored[:]) != c.crc {
+		return ErrChecksum
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package garkov
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// CJK_END_TOKEN terminates the sentences of Chinese and Japanese text
+const CJK_END_TOKEN string = "。"
+
+var (
+	// full-width quotes and brackets and the ones that close them
+	cjkPairs = map[string]string{"「": "」", "『": "』", "（": "）", "【": "】", "《": "》", "“": "”"}
+)
+
+// CJKTokenizer splits Chinese and Japanese text, which has no spaces between words, into
+// single characters or pairs of characters. Other scripts within the text are split into words.
+type CJKTokenizer struct {
+	Bigrams bool // pairs of characters instead of single characters
+}
+
+// Tokenize splits the text into sentences at full-width and ASCII stop punctuation
+func (t *CJKTokenizer) Tokenize(text string) []Token {
+	var tokens []Token
+
+	last := dictionary.SENTENCE_END
+	add := func(w string) {
+		last = dictionary.TokenType(w)
+		tokens = append(tokens, Token{Word: w, Type: last})
+	}
+
+	var run []rune  // consecutive CJK characters
+	var word []rune // consecutive characters of other scripts
+
+	flush := func() {
+		for i := 0; i < len(run); i++ {
+			if t.Bigrams && i+1 < len(run) {
+				add(string(run[i : i+2]))
+				i = i + 1
+			} else {
+				add(string(run[i]))
+			}
+		}
+		run = run[:0]
+
+		if len(word) > 0 {
+			add(string(word))
+			word = word[:0]
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			if len(word) > 0 {
+				flush()
+			}
+			run = append(run, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r):
+			if len(run) > 0 {
+				flush()
+			}
+			word = append(word, r)
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			flush()
+			add(string(r))
+		}
+	}
+	flush()
+
+	// check if the text ends with a STOP token and add one if not
+	if last != dictionary.SENTENCE_END {
+		tokens = append(tokens, Token{Word: CJK_END_TOKEN, Type: dictionary.SENTENCE_END})
+	}
+
+	return tokens
+}
+
+// EndToken returns the token that terminates sentences the text did not terminate
+func (t *CJKTokenizer) EndToken() string {
+	return CJK_END_TOKEN
+}
+
+// CJKDetokenizer joins Chinese and Japanese text without spaces, except between words of
+// other scripts. Quotes and brackets that were left open are closed.
+type CJKDetokenizer struct{}
+
+// Detokenize joins the tokens into a sentence
+func (d *CJKDetokenizer) Detokenize(tokens []Token) string {
+	var b strings.Builder
+	var open []string // the open quotes and brackets
+
+	prev := ""
+	for _, t := range tokens {
+		w := t.Word
+		if w == "" {
+			continue
+		}
+
+		if _, opens := cjkPairs[w]; opens {
+			open = append(open, w)
+		} else if isCJKCloser(w) {
+			if len(open) == 0 || cjkPairs[open[len(open)-1]] != w {
+				continue // a closing quote without an opening one
+			}
+			open = open[:len(open)-1]
+		}
+
+		// a space only between two words of other scripts
+		if isWordOf(prev) && isWordOf(w) {
+			b.WriteString(" ")
+		}
+		b.WriteString(w)
+		prev = w
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString(cjkPairs[open[i]])
+	}
+
+	return b.String()
+}
+
+// isCJK is true for Chinese characters and Japanese kana
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) || r == 'ー'
+}
+
+func isCJKCloser(w string) bool {
+	for _, closer := range cjkPairs {
+		if w == closer {
+			return true
+		}
+	}
+	return false
+}
+
+// isWordOf is true for words that are not written in a CJK script and are no punctuation
+func isWordOf(w string) bool {
+	r, _ := utf8.DecodeRuneInString(w)
+	return w != "" && !isCJK(r) && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// isCJKLanguage is true for the languages that are written without spaces
+func isCJKLanguage(language string) bool {
+	return language == "zh" || language == "ja"
+}
@@ -0,0 +1,166 @@
+package garkov
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// spanPlaceholder stands in for a protected span of the text while it is tokenized, followed
+// by the index of the span and spanPlaceholderEnd. A text that contains it gets a longer one.
+const (
+	spanPlaceholder    string = "garkovspan"
+	spanPlaceholderEnd string = "x"
+)
+
+// emoji matches an emoji with its modifiers, skin tones and the emoji joined to it, or a
+// flag of two regional indicators
+const emoji string = `(?:[\x{1F1E6}-\x{1F1FF}]{2}|[\x{1F000}-\x{1FAFF}\x{2600}-\x{27BF}\x{2300}-\x{23FF}\x{2B00}-\x{2BFF}])` +
+	`(?:[\x{FE0E}\x{FE0F}\x{1F3FB}-\x{1F3FF}\x{20E3}\x{E0020}-\x{E007F}]|\x{200D}[\x{1F000}-\x{1FAFF}\x{2600}-\x{27BF}\x{2300}-\x{23FF}\x{2B00}-\x{2BFF}])*`
+
+// emoticon matches the common emoticons
+const emoticon string = `¯\\_\(ツ\)_/¯|[:;=][-^o']?[)(\]\[DPpOo3/\\|*]|<3|\^_\^|[oO]_[oO]|-_-|>_<`
+
+// spanClasses are the spans of text kept as single tokens, and their placeholders, in the
+// order they are searched for. Spans without a placeholder are kept as they are.
+var spanClasses = []struct {
+	pattern     *regexp.Regexp
+	placeholder string
+	hint        string // the text contains a span only if it contains one of these characters, "" for any text
+	boundary    bool   // the span must neither follow nor precede a letter or digit
+	trim        bool   // trailing punctuation is not part of the span
+}{
+	{regexp.MustCompile(`(?i)(?:https?://|www\.)[^\s<>"]+`), dictionary.URL_TOKEN, "/.", true, true},
+	{regexp.MustCompile(`[\p{L}\p{N}._%+-]+@[\p{L}\p{N}-]+(?:\.[\p{L}\p{N}-]+)+`), dictionary.EMAIL_TOKEN, "@", true, true},
+	{regexp.MustCompile(`@[\p{L}\p{N}_]+`), dictionary.MENTION_TOKEN, "@", true, false},
+	{regexp.MustCompile(`#[\p{L}\p{N}_]+`), dictionary.HASHTAG_TOKEN, "#", true, false},
+	{regexp.MustCompile(emoticon), "", ":;=<^oO-¯>", true, false},
+	{regexp.MustCompile(emoji), "", "", false, false},
+}
+
+// number matches the numbers the word tokenizer keeps in one piece, e.g. -3, 1,000 or 3.14
+var number = regexp.MustCompile(`^[-+]?\d[\d,]*(?:\.\d+)?(?:[eE][-+]?\d+)?$`)
+
+// span is a piece of text kept as a single token
+type span struct {
+	text        string
+	placeholder string
+}
+
+// protectSpans replaces the URLs, email addresses, @mentions and hashtags of the text by
+// placeholders that the sentence and word tokenizers keep in one piece. It returns the text,
+// the spans and the placeholder marking them, which the text does not contain otherwise.
+func protectSpans(text string) (string, []span, string) {
+	var spans []span
+
+	marker := spanPlaceholder
+	for strings.Contains(text, marker) {
+		marker = marker + spanPlaceholderEnd
+	}
+
+	ascii := isASCII(text)
+	for _, class := range spanClasses {
+		if (class.hint != "" && !strings.ContainsAny(text, class.hint)) || (class.hint == "" && ascii) {
+			continue
+		}
+
+		var b strings.Builder
+		last := 0
+		for _, loc := range class.pattern.FindAllStringIndex(text, -1) {
+			start, end := loc[0], loc[1]
+			if start < last {
+				continue
+			}
+			if class.boundary && (!boundary(text[:start], utf8.DecodeLastRuneInString) || !boundary(text[end:], utf8.DecodeRuneInString)) {
+				continue
+			}
+
+			// punctuation ending the sentence is not part of the span
+			if class.trim {
+				end = start + len(trimSpan(text[start:end]))
+			}
+			if strings.HasPrefix(text[start:end], marker) || end-start < 2 {
+				continue
+			}
+
+			b.WriteString(text[last:start])
+			b.WriteString(" " + marker + strconv.Itoa(len(spans)) + spanPlaceholderEnd + " ")
+			spans = append(spans, span{text: text[start:end], placeholder: class.placeholder})
+			last = end
+		}
+		if last > 0 {
+			b.WriteString(text[last:])
+			text = b.String()
+		}
+	}
+
+	return text, spans, marker
+}
+
+// boundary is true if the text is empty or the rune that decode returns is neither a letter
+// nor a digit
+func boundary(text string, decode func(string) (rune, int)) bool {
+	if text == "" {
+		return true
+	}
+	r, _ := decode(text)
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// isASCII is true if the text has no multi-byte characters
+func isASCII(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if text[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// trimSpan removes trailing punctuation from a span, and closing parentheses the span does
+// not open
+func trimSpan(s string) string {
+	for len(s) > 0 {
+		r, size := utf8.DecodeLastRuneInString(s)
+		if strings.ContainsRune(".,;:!?'\"", r) || (r == ')' && strings.Count(s, ")") > strings.Count(s, "(")) {
+			s = s[:len(s)-size]
+			continue
+		}
+		break
+	}
+	return s
+}
+
+// restoreSpans replaces the placeholders of protectSpans by the spans they stand for, or by
+// the placeholders of their class if placeholders is set. Numbers are replaced by
+// dictionary.NUMBER_TOKEN then too. The words replaced by a class placeholder are marked
+// in control.
+func restoreSpans(words []string, spans []span, marker string, placeholders bool) ([]string, []bool) {
+	control := make([]bool, len(words))
+	for i, w := range words {
+		if placeholders && number.MatchString(w) {
+			words[i] = dictionary.NUMBER_TOKEN
+			control[i] = true
+			continue
+		}
+		if len(spans) == 0 || !strings.HasPrefix(w, marker) || !strings.HasSuffix(w, spanPlaceholderEnd) {
+			continue
+		}
+
+		n, err := strconv.Atoi(w[len(marker) : len(w)-len(spanPlaceholderEnd)])
+		if err != nil || n < 0 || n >= len(spans) {
+			continue
+		}
+		if placeholders && spans[n].placeholder != "" {
+			words[i] = spans[n].placeholder
+			control[i] = true
+		} else {
+			words[i] = spans[n].text
+		}
+	}
+	return words, control
+}
@@ -24,7 +24,7 @@ func main() {
 	prefix, _ := strconv.Atoi(os.Args[1])
 
 	// initiate the model
-	model := garkov.New("test", prefix)
+	model := garkov.New("test", garkov.WithDepth(prefix))
 
 	// load the files
 	i := 2
@@ -40,22 +40,23 @@ func main() {
 		if fi.IsDir() {
 			fmt.Println("Scanning directory: " + fileOrDir)
 
-			fileList := []string{}
-			filepath.Walk(fileOrDir, func(path string, f os.FileInfo, err error) error {
-				fileList = append(fileList, path)
+			// files that can not be read are reported and skipped
+			stats, err := model.BuildDirFunc(fileOrDir, "", func(path string, err error) error {
+				fmt.Println(err)
 				return nil
 			})
-
-			fileList = fileList[1:] // remove the first entry, its the directory itself
-
-			for _, file := range fileList {
-				fmt.Println("Reading file: " + file)
-				model.Build(file)
+			if err != nil {
+				fmt.Println(err)
+				return
 			}
+			fmt.Printf("Read %v files, %v sentences, %v tokens\n", stats.Files, stats.Sentences, stats.Tokens)
 
 		} else {
 			fmt.Println("Reading file: " + fileOrDir)
-			model.Build(fileOrDir)
+			if err := model.Build(fileOrDir); err != nil {
+				fmt.Println(err)
+				return
+			}
 		}
 
 		i = i + 1
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mickuehl/garkov"
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+const usage = `usage: %[1]s <command> [flags] [files or directories]
+
+commands:
+  train     build a model from files, directories or stdin and save it
+  generate  print sentences of a saved model, or of a model built from files
+  stats     print the statistics of a saved model
+  graph     print the transition graph of a saved model in DOT or JSON
+  migrate   rewrite a saved model in the current file format
+
+Run '%[1]s <command> -h' for the flags of a command.
+`
+
+func main() {
+	name := filepath.Base(os.Args[0])
+	if len(os.Args) < 2 {
+		fmt.Fprintf(os.Stderr, usage, name)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "train":
+		err = train(os.Args[2:])
+	case "generate":
+		err = generate(os.Args[2:])
+	case "stats":
+		err = stats(os.Args[2:])
+	case "graph":
+		err = graph(os.Args[2:])
+	case "migrate":
+		err = migrate(os.Args[2:])
+	default:
+		fmt.Fprintf(os.Stderr, usage, name)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// train builds a model and saves it
+func train(args []string) error {
+	flags := flag.NewFlagSet("train", flag.ExitOnError)
+	path := flags.String("model", "model.garkov", "the model file to write")
+	depth := flags.Int("depth", 2, "the prefix size")
+	update := flags.Bool("update", false, "continue training the existing model file")
+	novelty := flags.Int("novelty", 0, "remember the runs of novelty+1 words to avoid repeating them, must exceed depth")
+	maxWords := flags.Int("words", 0, "limit the dictionary to this many words, further words are replaced by "+dictionary.UNKNOWN_TOKEN)
+	backward := flags.Bool("backward", false, "also build the backward chain, to extend sentences to the left")
+	verbose := flags.Bool("v", false, "log the files read and the model saved to stderr")
+	showProgress := flags.Bool("progress", false, "show the lines and tokens read so far on stderr")
+	flags.Parse(args)
+
+	var model *garkov.Markov
+	if *update {
+		m, err := garkov.Load(*path)
+		if err != nil {
+			return err
+		}
+		model = m
+	} else {
+		model = garkov.New(modelName(*path), garkov.WithDepth(*depth))
+		model.Novelty = *novelty
+		model.MaxWords = *maxWords
+		model.Backward = *backward
+	}
+	if *verbose {
+		model.Logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+	if *showProgress {
+		model.OnProgress = func(p garkov.Progress) {
+			fmt.Fprintf(os.Stderr, "\r%v lines, %v tokens, %v chains", p.Lines, p.Tokens, p.Chains)
+		}
+	}
+
+	err := build(model, flags.Args())
+	if *showProgress {
+		fmt.Fprintln(os.Stderr)
+	}
+	if err != nil {
+		return err
+	}
+
+	return model.Save(*path)
+}
+
+// generate prints sentences
+func generate(args []string) error {
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	path := flags.String("model", "", "the model file, instead of building a model from files or stdin")
+	depth := flags.Int("depth", 2, "the prefix size of a model built from files or stdin")
+	num := flags.Int("n", 1, "the number of sentences")
+	temperature := flags.Float64("temp", 1, "< 1 favours frequent words, > 1 flattens the distribution")
+	topK := flags.Int("topk", 0, "sample from the k most frequent words only")
+	topP := flags.Float64("topp", 0, "sample from the most frequent words that cover this share of the weight")
+	novel := flags.Bool("novel", false, "avoid repeating runs of the training text, see -novelty of train")
+	seed := flags.Int64("seed", 0, "the seed of the random number generator, 0 selects a random seed")
+	start := flags.String("start", "", "a word or phrase the sentences continue")
+	minWords := flags.Int("min", 4, "the number of words before a sentence may end")
+	maxTokens := flags.Int("max", 60, "the maximum number of tokens of a sentence")
+	chars := flags.Int("chars", 0, "the maximum number of characters of a sentence, e.g. 280 for a post")
+	minChars := flags.Int("minchars", 0, "the minimum number of characters of a sentence, e.g. 40 for a headline")
+	title := flags.Bool("title", false, "capitalize the sentences like headlines")
+	template := flags.String("template", "", "a template the sentences follow, e.g. 'The {*} of the {*}'")
+	syllables := flags.Int("syllables", 0, "the number of syllables of each sentence, e.g. 5 or 7 for the lines of a haiku")
+	end := flags.String("end", "", "the ending of the last word of the sentences, e.g. 'ight' for a rhyme, best with a backward chain")
+	flags.Parse(args)
+
+	var model *garkov.Markov
+	if *path != "" {
+		m, err := garkov.Load(*path)
+		if err != nil {
+			return err
+		}
+		model = m
+	} else {
+		model = garkov.New("stdin", garkov.WithDepth(*depth))
+		if err := build(model, flags.Args()); err != nil {
+			return err
+		}
+	}
+
+	if *seed != 0 {
+		model.Seed(*seed)
+	}
+
+	opts := garkov.GenOptions{
+		MinWords:    *minWords,
+		MaxTokens:   *maxTokens,
+		Temperature: *temperature,
+		TopK:        *topK,
+		TopP:        *topP,
+		Novel:       *novel,
+		CharLimit:   *chars,
+		MinChars:    *minChars,
+		TitleCase:   *title,
+		StartWith:   *start,
+		Syllables:   *syllables,
+	}
+	if *end != "" {
+		opts.LastWord = garkov.WordSuffix(*end)
+	}
+	for i := 0; i < *num; i++ {
+		if *template != "" {
+			s, err := model.Fill(*template)
+			if err != nil {
+				return err
+			}
+			fmt.Println(s)
+			continue
+		}
+		// a sentence that can not be generated is an empty line, flags that contradict each
+		// other are an error
+		s, err := model.Generate(opts)
+		if errors.Is(err, garkov.ErrInvalidOptions) {
+			return err
+		}
+		fmt.Println(s)
+	}
+
+	return nil
+}
+
+// stats prints the statistics of a model
+func stats(args []string) error {
+	flags := flag.NewFlagSet("stats", flag.ExitOnError)
+	path := flags.String("model", "model.garkov", "the model file")
+	prefix := flags.String("prefix", "", "the words of a prefix, prints the statistics of its chain instead")
+	flags.Parse(args)
+
+	model, err := garkov.Load(*path)
+	if err != nil {
+		return err
+	}
+
+	if *prefix != "" {
+		p, found := model.PrefixStats(strings.Fields(*prefix))
+		if !found {
+			return fmt.Errorf("no chain of the prefix '%v'", *prefix)
+		}
+		fmt.Printf("Prefix:      %v\n", strings.Join(p.Prefix, " "))
+		fmt.Printf("Suffixes:    %v\n", p.Suffixes)
+		fmt.Printf("Count:       %v\n", p.Count)
+		fmt.Printf("End:         %.3f\n", p.End)
+		fmt.Printf("State:       %v\n", p.State)
+
+		successors, err := model.Successors(p.Prefix)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Successors:\n")
+		for i, w := range successors {
+			if i == garkov.DefaultTopWords {
+				break
+			}
+			fmt.Printf("%5d  %-20v %8.3f\n", i+1, w.Word, w.Prob)
+		}
+		return nil
+	}
+
+	s := model.Stats()
+	fmt.Printf("Name:        %v\n", s.Name)
+	fmt.Printf("Depth:       %v\n", s.Depth)
+	fmt.Printf("Words:       %v\n", s.Words)
+	fmt.Printf("Chains:      %v\n", s.Chains)
+	fmt.Printf("Transitions: %v\n", s.Transitions)
+	fmt.Printf("Starts:      %v\n", s.Starts)
+	fmt.Printf("Top words:\n")
+	for _, w := range s.TopWords {
+		fmt.Printf("%5d  %-20v %8d occurrences, %6d successors\n", w.Rank, w.Word, w.Count, w.Successors)
+	}
+
+	return nil
+}
+
+// graph prints the transition graph of a model
+func graph(args []string) error {
+	flags := flag.NewFlagSet("graph", flag.ExitOnError)
+	path := flags.String("model", "model.garkov", "the model file")
+	format := flags.String("format", "dot", "the format of the graph, dot or json")
+	edges := flags.Int("edges", 100, "the number of most frequent transitions, 0 prints all")
+	flags.Parse(args)
+
+	model, err := garkov.Load(*path)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "dot":
+		return model.ExportGraph(os.Stdout, garkov.GraphDOT, *edges)
+	case "json":
+		return model.ExportGraph(os.Stdout, garkov.GraphJSON, *edges)
+	}
+	return fmt.Errorf("unknown format '%v'", *format)
+}
+
+// migrate rewrites a model file in the current format
+func migrate(args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	path := flags.String("model", "model.garkov", "the model file")
+	out := flags.String("out", "", "the file to write, the model file if empty")
+	flags.Parse(args)
+
+	if *out == "" {
+		*out = *path
+	}
+	return garkov.Migrate(*path, *out)
+}
+
+// build updates the model with files and directories, or with stdin if there are none
+func build(model *garkov.Markov, paths []string) error {
+	if len(paths) == 0 || (len(paths) == 1 && paths[0] == "-") {
+		return model.BuildReader(os.Stdin)
+	}
+
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+
+		if fi.IsDir() {
+			stats, err := model.BuildDir(path, "")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Read %v files, %v sentences, %v tokens from %v\n", stats.Files, stats.Sentences, stats.Tokens, path)
+			continue
+		}
+
+		if err := model.Build(path); err != nil {
+			return fmt.Errorf("%v: %v", path, err)
+		}
+	}
+
+	return nil
+}
+
+// modelName derives the name of a model from its file name
+func modelName(path string) string {
+	base := filepath.Base(path)
+	return base[:len(base)-len(filepath.Ext(base))]
+}
@@ -0,0 +1,302 @@
+package garkov
+
+import (
+	"bytes"
+	"encoding/binary"
+	"log/slog"
+	"sort"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// AliasMinSuffixes is the number of suffixes from which Compile gives a chain an alias table,
+// which draws a suffix in constant time instead of by binary search
+const AliasMinSuffixes int = 64
+
+// Compiled is a read-only Storage made by Compile. The chains are kept sorted by their
+// prefix key in a few flat slices instead of maps, with the running total of the counts of
+// their suffixes, so they take less memory and a suffix is drawn by binary search, or from
+// an alias table for chains with at least AliasMinSuffixes suffixes.
+type Compiled struct {
+	keys       []byte    // the keys of the prefixes of the chains, sorted and concatenated
+	keyEnd     []uint32  // the end of the key of each chain in keys
+	suffixEnd  []uint32  // the end of the suffixes of each chain in suffixes
+	suffixes   []int32   // the word indices of the suffixes, ordered by index within a chain
+	cumulative []float64 // the running total of the counts of the suffixes within a chain
+
+	aliasChains []uint32  // the positions of the chains with an alias table, ascending
+	aliasEnd    []uint32  // the end of the table of each of these chains in aliasProb and aliasIdx
+	aliasProb   []float64 // the probabilities of the slots of the alias tables
+	aliasIdx    []int32   // the aliases of the slots, relative to the suffixes of the chain
+
+	startKeys   []byte    // the keys of the start prefixes, concatenated
+	startEnd    []uint32  // the end of the key of each start prefix in startKeys
+	startCounts []float64 // the count of each start prefix
+}
+
+var _ Storage = (*Compiled)(nil)
+
+// Compile replaces the chains of the model with Compiled copies, for models that only
+// generate. Training and pruning the model fails with ErrReadOnly afterwards.
+func (m *Markov) Compile() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	chain, err := Compile(m.Chain)
+	if err != nil {
+		return err
+	}
+	reverse, err := Compile(m.Reverse)
+	if err != nil {
+		return err
+	}
+
+	m.Chain = chain
+	m.Reverse = reverse
+
+	m.log(slog.LevelInfo, "compiled", "chains", chain.Len())
+	return nil
+}
+
+// Compile returns a Compiled copy of the chains of a storage
+func Compile(s Storage) (*Compiled, error) {
+	if c, ok := s.(*Compiled); ok {
+		return c, nil
+	}
+
+	type chain struct {
+		key      string
+		suffixes []WordCount
+	}
+
+	chains := make([]chain, 0, s.Len())
+	n := 0
+	err := s.Range(func(prefix []int, suffixes []WordCount) bool {
+		chains = append(chains, chain{key: prefixKey(prefix), suffixes: suffixes})
+		n = n + len(suffixes)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(chains, func(i, j int) bool {
+		return chains[i].key < chains[j].key
+	})
+
+	c := Compiled{
+		keyEnd:     make([]uint32, len(chains)),
+		suffixEnd:  make([]uint32, len(chains)),
+		suffixes:   make([]int32, 0, n),
+		cumulative: make([]float64, 0, n),
+	}
+	size := 0
+	for _, ch := range chains {
+		size = size + len(ch.key)
+	}
+	c.keys = make([]byte, 0, size)
+
+	for i, ch := range chains {
+		c.keys = append(c.keys, ch.key...)
+		c.keyEnd[i] = uint32(len(c.keys))
+
+		total := 0.0
+		for _, suffix := range ch.suffixes {
+			total = total + suffix.Count
+			c.suffixes = append(c.suffixes, int32(suffix.Idx))
+			c.cumulative = append(c.cumulative, total)
+		}
+		c.suffixEnd[i] = uint32(len(c.suffixes))
+
+		if len(ch.suffixes) >= AliasMinSuffixes {
+			weights := make([]float64, len(ch.suffixes))
+			for j, suffix := range ch.suffixes {
+				weights[j] = suffix.Count
+			}
+			t := newAliasTable(weights)
+			c.aliasChains = append(c.aliasChains, uint32(i))
+			c.aliasProb = append(c.aliasProb, t.prob...)
+			c.aliasIdx = append(c.aliasIdx, t.alias...)
+			c.aliasEnd = append(c.aliasEnd, uint32(len(c.aliasProb)))
+		}
+	}
+
+	err = s.IterStarts(func(prefix []int, count float64) bool {
+		c.startKeys = appendPrefixKey(c.startKeys, prefix)
+		c.startEnd = append(c.startEnd, uint32(len(c.startKeys)))
+		c.startCounts = append(c.startCounts, count)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// GetChain returns the suffixes of the chain of a prefix, ordered by their word index
+func (c *Compiled) GetChain(prefix []int) ([]WordCount, bool) {
+	var buf [maxKeyDepth * binary.MaxVarintLen32]byte
+	i, found := c.find(appendPrefixKey(buf[:0], prefix))
+	if !found {
+		return nil, false
+	}
+	return c.chain(i), true
+}
+
+// PutChain fails with ErrReadOnly
+func (c *Compiled) PutChain(prefix []int, suffixes []WordCount) error {
+	return ErrReadOnly
+}
+
+// Update fails with ErrReadOnly
+func (c *Compiled) Update(prefix []int, suffix int, weight float64) error {
+	return ErrReadOnly
+}
+
+// UpdateStart fails with ErrReadOnly
+func (c *Compiled) UpdateStart(prefix []int, weight float64) error {
+	return ErrReadOnly
+}
+
+// IterStarts calls f for every start prefix and its count until f returns false
+func (c *Compiled) IterStarts(f func(prefix []int, count float64) bool) error {
+	for i := range c.startEnd {
+		start := uint32(0)
+		if i > 0 {
+			start = c.startEnd[i-1]
+		}
+		prefix, err := DecodePrefix(c.startKeys[start:c.startEnd[i]])
+		if err != nil {
+			return err
+		}
+		if !f(prefix, c.startCounts[i]) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Len returns the number of chains
+func (c *Compiled) Len() int {
+	return len(c.keyEnd)
+}
+
+// Range calls f for every chain, ordered by their prefix key, until f returns false
+func (c *Compiled) Range(f func(prefix []int, suffixes []WordCount) bool) error {
+	for i := range c.keyEnd {
+		prefix, err := DecodePrefix(c.key(i))
+		if err != nil {
+			return err
+		}
+		if !f(prefix, c.chain(i)) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Prune fails with ErrReadOnly
+func (c *Compiled) Prune(minCount int) (int, error) {
+	return 0, ErrReadOnly
+}
+
+// Snapshot returns the chains themselves, they never change
+func (c *Compiled) Snapshot() (Storage, error) {
+	return c, nil
+}
+
+// find returns the position of the chain of a prefix key
+func (c *Compiled) find(key []byte) (int, bool) {
+	i := sort.Search(len(c.keyEnd), func(i int) bool {
+		return bytes.Compare(c.key(i), key) >= 0
+	})
+	return i, i < len(c.keyEnd) && bytes.Equal(c.key(i), key)
+}
+
+// findWords returns the position of the chain of a prefix of words
+func (c *Compiled) findWords(prefix []dictionary.Word) (int, bool) {
+	var buf [maxKeyDepth * binary.MaxVarintLen32]byte
+	key := buf[:0]
+	for _, w := range prefix {
+		key = binary.AppendVarint(key, int64(w.Idx))
+	}
+	return c.find(key)
+}
+
+// key returns the prefix key of the chain at position i
+func (c *Compiled) key(i int) []byte {
+	start := uint32(0)
+	if i > 0 {
+		start = c.keyEnd[i-1]
+	}
+	return c.keys[start:c.keyEnd[i]]
+}
+
+// span returns the range of the suffixes of the chain at position i
+func (c *Compiled) span(i int) (int, int) {
+	start := uint32(0)
+	if i > 0 {
+		start = c.suffixEnd[i-1]
+	}
+	return int(start), int(c.suffixEnd[i])
+}
+
+// alias returns the alias table of the chain at position i, if it has one
+func (c *Compiled) alias(i int) (aliasTable, bool) {
+	j := sort.Search(len(c.aliasChains), func(j int) bool {
+		return c.aliasChains[j] >= uint32(i)
+	})
+	if j == len(c.aliasChains) || c.aliasChains[j] != uint32(i) {
+		return aliasTable{}, false
+	}
+
+	start := uint32(0)
+	if j > 0 {
+		start = c.aliasEnd[j-1]
+	}
+	return aliasTable{prob: c.aliasProb[start:c.aliasEnd[j]], alias: c.aliasIdx[start:c.aliasEnd[j]]}, true
+}
+
+// chain returns the suffixes of the chain at position i
+func (c *Compiled) chain(i int) []WordCount {
+	start, end := c.span(i)
+	suffixes := make([]WordCount, end-start)
+	previous := 0.0
+	for j := start; j < end; j++ {
+		suffixes[j-start] = WordCount{Idx: int(c.suffixes[j]), Count: c.cumulative[j] - previous}
+		previous = c.cumulative[j]
+	}
+	return suffixes
+}
+
+// draw returns the suffix of the chain at position i that covers r, a share of the total
+// count between 0 and 1
+func (c *Compiled) draw(i int, r float64) int {
+	start, end := c.span(i)
+	if t, found := c.alias(i); found {
+		return int(c.suffixes[start+t.draw(r)])
+	}
+
+	pos := r * c.cumulative[end-1]
+	j := sort.Search(end-start, func(j int) bool {
+		return pos < c.cumulative[start+j]
+	})
+	if j == end-start {
+		j = j - 1
+	}
+	return int(c.suffixes[start+j])
+}
+
+// sampleCompiled draws a suffix of the prefix in proportion to its count, from the chain of
+// the prefix or with backoff from the chain of its longest suffix
+func (m *Markov) sampleCompiled(c *Compiled, prefix []dictionary.Word) dictionary.Word {
+	for i := 0; i < len(prefix); i++ {
+		if i > 0 && !m.Backoff {
+			break
+		}
+		if chain, found := c.findWords(prefix[i:]); found {
+			word, _ := m.Dict.GetAt(c.draw(chain, m.float64()))
+			return word
+		}
+	}
+	return dictionary.Word{}
+}
@@ -0,0 +1,158 @@
+package garkov
+
+import (
+	"strings"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// Complete returns the n words most likely to follow a partial sentence, e.g. as the
+// suggestions of a keyboard. The last Depth words of the text are looked up in the chain,
+// and fewer if the model has no chain of them: with Backoff in its lower order chains,
+// otherwise in all chains whose prefixes end with the words. A sentence that has fewer than
+// Depth words yet is looked up among the start prefixes, an empty text gets the most likely
+// first words of sentences. Punctuation and the words the WordFilter of the model rejects
+// are not suggested. The result is empty if no chain matches.
+func (m *Markov) Complete(text string, n int) []string {
+	tokenizer, err := m.tokenizer()
+	if err != nil || n <= 0 {
+		return nil
+	}
+	tokens := tokenizer.Tokenize(text)
+
+	// the tokenizer terminates the text like any other sentence
+	if last := len(tokens) - 1; last >= 0 && tokens[last].Type == dictionary.SENTENCE_END && !strings.HasSuffix(strings.TrimSpace(text), tokens[last].Word) {
+		tokens = tokens[:last]
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	idx := make([]int, len(tokens))
+	sentence := 0
+	for i, t := range tokens {
+		idx[i] = -1
+		if word, found := m.Dict.Get(m.entry(t)); found {
+			idx[i] = word.Idx
+			if word.Type == dictionary.STOP {
+				sentence = i + 1
+			}
+		}
+	}
+
+	if len(idx)-sentence < m.Depth {
+		if counts := m.startCompletions(idx[sentence:]); len(counts) > 0 {
+			return m.completions(counts, n)
+		}
+	}
+
+	for k := m.Depth; k > 0; k-- {
+		if k > len(idx) {
+			continue
+		}
+		window := idx[len(idx)-k:]
+		if !known(window) {
+			continue
+		}
+
+		if k == m.Depth || m.Backoff {
+			if suffixes, found := m.Chain.GetChain(window); found {
+				return m.completions(suffixes, n)
+			}
+			continue
+		}
+		if counts := m.endingWith(window); len(counts) > 0 {
+			return m.completions(counts, n)
+		}
+	}
+	return nil
+}
+
+// startCompletions counts the words that follow the beginning of a sentence in the start
+// prefixes, by the counts of the prefixes
+func (m *Markov) startCompletions(beginning []int) []WordCount {
+	counts := make(map[int]float64)
+	for i, prefix := range m.Start {
+		if len(prefix) <= len(beginning) {
+			continue
+		}
+		matches := true
+		for j, idx := range beginning {
+			if prefix[j] != idx {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			counts[prefix[len(beginning)]] = counts[prefix[len(beginning)]] + m.startCount(i)
+		}
+	}
+	return wordCounts(counts)
+}
+
+// endingWith adds up the suffixes of the chains whose prefixes end with the words
+func (m *Markov) endingWith(words []int) []WordCount {
+	counts := make(map[int]float64)
+	m.Chain.Range(func(prefix []int, suffixes []WordCount) bool {
+		if len(prefix) != m.Depth {
+			return true
+		}
+		for j, idx := range words {
+			if prefix[len(prefix)-len(words)+j] != idx {
+				return true
+			}
+		}
+		for _, s := range suffixes {
+			counts[s.Idx] = counts[s.Idx] + s.Count
+		}
+		return true
+	})
+	return wordCounts(counts)
+}
+
+// completions returns the n most frequent of the words that may be suggested, in their most
+// frequent spelling
+func (m *Markov) completions(counts []WordCount, n int) []string {
+	allow := m.allowFunc(nil, GenOptions{})
+
+	candidates := make([]WordCount, 0, len(counts))
+	for _, c := range counts {
+		word, _ := m.Dict.GetAt(c.Idx)
+		if word.Type != dictionary.WORD || strings.IndexFunc(word.Word, isAlnum) < 0 || (allow != nil && !allow(c.Idx)) {
+			continue
+		}
+		candidates = append(candidates, c)
+	}
+
+	var words []string
+	seen := make(map[string]bool)
+	for _, w := range m.wordProbs(candidates) {
+		if len(words) == n {
+			break
+		}
+		if !seen[w.Word] {
+			seen[w.Word] = true
+			words = append(words, w.Word)
+		}
+	}
+	return words
+}
+
+// wordCounts returns the counts of the words, given by their index
+func wordCounts(counts map[int]float64) []WordCount {
+	words := make([]WordCount, 0, len(counts))
+	for idx, count := range counts {
+		words = append(words, WordCount{Idx: idx, Count: count})
+	}
+	return words
+}
+
+// known is true if all words of the window are in the dictionary
+func known(window []int) bool {
+	for _, idx := range window {
+		if idx < 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+package garkov
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression selects how a model file is compressed
+type Compression int
+
+const (
+	// NoCompression writes the model as it is
+	NoCompression Compression = iota
+	// Gzip compresses the model with gzip
+	Gzip
+	// Zstd compresses the model with Zstandard, which is faster and smaller than gzip
+	Zstd
+)
+
+// String returns the name of the compression
+func (c Compression) String() string {
+	switch c {
+	case NoCompression:
+		return "none"
+	case Gzip:
+		return "gzip"
+	case Zstd:
+		return "zstd"
+	}
+	return fmt.Sprintf("Compression(%d)", int(c))
+}
+
+const (
+	// gzipMagic starts a gzip stream
+	gzipMagic string = "\x1f\x8b"
+	// zstdMagic starts a Zstandard frame
+	zstdMagic string = "\x28\xb5\x2f\xfd"
+)
+
+// CompressionFor returns the compression selected by the extension of a file name: Gzip
+// for ".gz", Zstd for ".zst" and NoCompression otherwise
+func CompressionFor(path string) Compression {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gz":
+		return Gzip
+	case ".zst":
+		return Zstd
+	}
+	return NoCompression
+}
+
+// compressor returns a writer that compresses into w. Closing it completes the compressed
+// stream, but does not close w.
+func compressor(w io.Writer, c Compression) (io.WriteCloser, error) {
+	switch c {
+	case NoCompression:
+		return nopWriteCloser{w}, nil
+	case Gzip:
+		return gzip.NewWriter(w), nil
+	case Zstd:
+		return zstd.NewWriter(w)
+	}
+	return nil, fmt.Errorf("unknown compression %v", c)
+}
+
+// decompressor returns a reader of the decompressed data of r if it starts with the magic
+// bytes of gzip or Zstandard, otherwise r itself, and a function that releases the decompressor
+func decompressor(r *bufio.Reader) (*bufio.Reader, func(), error) {
+	header, _ := r.Peek(len(zstdMagic))
+
+	switch {
+	case strings.HasPrefix(string(header), gzipMagic):
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		return bufio.NewReader(gz), func() { gz.Close() }, nil
+
+	case string(header) == zstdMagic:
+		zr, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, nil, err
+		}
+		return bufio.NewReader(zr), zr.Close, nil
+	}
+
+	return r, func() {}, nil
+}
+
+// nopWriteCloser is a writer whose Close does nothing
+type nopWriteCloser struct {
+	io.Writer
+}
+
+// Close does nothing
+func (nopWriteCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,141 @@
+package garkov
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// containTries is the number of random sentences SentenceContaining generates before it
+// falls back to continuing the word
+const containTries int = 100
+
+// SentenceContaining creates a sentence that contains a word or phrase. A model with a backward
+// chain extends the word to the left and to the right. Otherwise random sentences are
+// generated until one contains the word. If none does, the sentence continues the word like
+// SentenceFrom. The result is empty if the word can not be found in the model.
+func (m *Markov) SentenceContaining(word string) string {
+	return m.SentenceContainingWithOptions(word, GenOptions{})
+}
+
+// SentenceContainingWithOptions creates a sentence that contains a word or phrase, see
+// SentenceContaining. opts.StartWith is ignored.
+func (m *Markov) SentenceContainingWithOptions(word string, opts GenOptions) string {
+	seed, err := m.tokenize(word)
+	if err != nil {
+		return ""
+	}
+
+	// the tokenizer terminates the word like any other sentence
+	for len(seed) > 0 && seed[len(seed)-1].Type == dictionary.SENTENCE_END {
+		seed = seed[:len(seed)-1]
+	}
+	if len(seed) == 0 {
+		return ""
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// words that are not in the dictionary can not be generated
+	for _, t := range seed {
+		if !m.knownWord(t.Word) {
+			return ""
+		}
+	}
+
+	if m.Backward && m.Reverse.Len() > 0 {
+		if middle := m.prefixContaining(seed, opts); middle != nil {
+			return m.fit(func() []dictionary.Word {
+				return m.extendLeft(m.prefixContaining(seed, opts), opts)
+			}, opts)
+		}
+	}
+
+	for i := 0; i < containTries; i++ {
+		start := m.randomStart(opts)
+		if start == nil {
+			return ""
+		}
+
+		sentence := m.generate(start, opts)
+		if contains(sentence, seed) {
+			if s := m.text(sentence, opts); fits(s, opts) && longEnough(s, opts) {
+				return s
+			}
+		}
+	}
+
+	return m.fit(func() []dictionary.Word {
+		return m.seedStart(seed)
+	}, opts)
+}
+
+// prefixContaining selects a random prefix of the chain that contains the tokens and passes
+// the word filters, or nil
+func (m *Markov) prefixContaining(tokens []Token, opts GenOptions) []dictionary.Word {
+	filter := m.wordFilter(opts)
+
+	if len(tokens) > m.Depth {
+		return nil
+	}
+
+	var candidates [][]int
+	m.Chain.Range(func(prefix []int, suffixes []WordCount) bool {
+		if len(prefix) != m.Depth || !m.withinSentence(prefix) {
+			return true
+		}
+		if !m.allowedPrefix(prefix, filter) {
+			return true
+		}
+		for offset := 0; offset+len(tokens) <= m.Depth; offset++ {
+			if m.prefixMatches(prefix[offset:], tokens) {
+				candidates = append(candidates, prefix)
+				break
+			}
+		}
+		return true
+	})
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	// the order of the storage is random, choose from a stable order
+	sort.Slice(candidates, func(i, j int) bool {
+		return prefixKey(candidates[i]) < prefixKey(candidates[j])
+	})
+	return m.prefixWords(candidates[m.intn(len(candidates))])
+}
+
+// withinSentence is true if no word of the prefix ends a sentence
+func (m *Markov) withinSentence(prefix []int) bool {
+	for _, idx := range prefix {
+		if word, _ := m.Dict.GetAt(idx); word.Type == dictionary.STOP {
+			return false
+		}
+	}
+	return true
+}
+
+// contains is true if the tokens appear in the sentence, ignoring their case
+func contains(sentence []dictionary.Word, tokens []Token) bool {
+	for i := 0; i+len(tokens) <= len(sentence); i++ {
+		found := true
+		for j := range tokens {
+			if !strings.EqualFold(sentence[i+j].Word, tokens[j].Word) {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// knownWord is true if the dictionary contains the word, in lower case or capitalized
+func (m *Markov) knownWord(w string) bool {
+	return m.Dict.Exists(w) || m.Dict.Exists(strings.ToLower(w)) || m.Dict.Exists(capitalize(strings.ToLower(w)))
+}
@@ -0,0 +1,46 @@
+package garkov
+
+import "strings"
+
+// contractionSuffixes are the parts of contractions the Penn Treebank conventions split off
+// the word before them, in lower case
+var contractionSuffixes = map[string]bool{
+	"n't": true, "'s": true, "'re": true, "'ve": true, "'ll": true, "'d": true, "'m": true,
+}
+
+// contractionPairs are the words the Penn Treebank conventions split in two, in lower case
+var contractionPairs = map[[2]string]bool{
+	{"gon", "na"}: true,
+	{"wan", "na"}: true,
+	{"got", "ta"}: true,
+	{"lem", "me"}: true,
+	{"gim", "me"}: true,
+	{"'t", "is"}:  true,
+	{"'t", "was"}: true,
+	{"d", "'ye"}:  true,
+}
+
+// joinContractions joins the parts of the contractions that the word tokenizer split, e.g.
+// "do" and "n't" to "don't", and the apostrophe of a possessive left by unquote to its word
+func joinContractions(words []string) []string {
+	result := make([]string, 0, len(words))
+
+	for _, w := range words {
+		if len(result) == 0 {
+			result = append(result, w)
+			continue
+		}
+
+		previous := result[len(result)-1]
+		lower := strings.ToLower(w)
+		pair := [2]string{strings.ToLower(previous), lower}
+
+		if (isWord(previous) && (contractionSuffixes[lower] || w == "'")) || contractionPairs[pair] {
+			result[len(result)-1] = previous + w
+			continue
+		}
+		result = append(result, w)
+	}
+
+	return result
+}
@@ -0,0 +1,197 @@
+package garkov
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mickuehl/garkov/corpus"
+)
+
+// BuildGutenberg reads a Project Gutenberg e-text from r and updates the markov model with its
+// body, without the license boilerplate and the chapter headings. See corpus.OpenGutenberg
+// to download an e-text.
+func (m *Markov) BuildGutenberg(r io.Reader) error {
+	text, err := corpus.Gutenberg(r)
+	if err != nil {
+		return err
+	}
+
+	return m.BuildReader(strings.NewReader(text))
+}
+
+// BuildSubtitles reads a SubRip or WebVTT subtitle file from r and updates the markov model
+// with its dialogue.
+func (m *Markov) BuildSubtitles(r io.Reader) error {
+	text, err := corpus.Subtitles(r)
+	if err != nil {
+		return err
+	}
+
+	return m.BuildReader(strings.NewReader(text))
+}
+
+// BuildMessages updates the markov model with the text of chat messages. Every message is a
+// paragraph of its own, the chain does not continue from one message to the next.
+func (m *Markov) BuildMessages(msgs []corpus.Message) error {
+	var b strings.Builder
+	for _, msg := range msgs {
+		b.WriteString(msg.Text)
+		b.WriteString("\n\n")
+	}
+
+	return m.BuildReader(strings.NewReader(b.String()))
+}
+
+// BuildByAuthor partitions chat messages by their author and builds a model of each author's
+// messages. The models are created by calling create with the name of the author.
+func BuildByAuthor(msgs []corpus.Message, create func(author string) *Markov) (map[string]*Markov, error) {
+	models := make(map[string]*Markov)
+	for author, m := range corpus.ByAuthor(msgs) {
+		model := create(author)
+		if err := model.BuildMessages(m); err != nil {
+			return nil, fmt.Errorf("%v: %w", author, err)
+		}
+		models[author] = model
+	}
+
+	return models, nil
+}
+
+// BuildCSV reads comma separated records from r and updates the markov model with the text
+// of the given column, counting from 0. Every record is a text of its own. Records without
+// the column are skipped, a header row has to be removed beforehand.
+func (m *Markov) BuildCSV(r io.Reader, column int) error {
+	return m.buildColumn(r, ',', column)
+}
+
+// BuildTSV reads tab separated records from r and updates the markov model with the text
+// of the given column, see BuildCSV.
+func (m *Markov) BuildTSV(r io.Reader, column int) error {
+	return m.buildColumn(r, '\t', column)
+}
+
+func (m *Markov) buildColumn(r io.Reader, comma rune, column int) error {
+	if column < 0 {
+		return fmt.Errorf("invalid column %v", column)
+	}
+
+	reader := csv.NewReader(r)
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.ReuseRecord = true
+
+	return m.buildTexts(func() (string, error) {
+		for {
+			record, err := reader.Read()
+			if err != nil {
+				return "", err
+			}
+			if column < len(record) {
+				return record[column], nil
+			}
+		}
+	})
+}
+
+// buildTexts updates the markov model with every text returned by next, until next returns
+// io.EOF. Every text is a chain of its own.
+func (m *Markov) buildTexts(next func() (string, error)) error {
+	s := stream{weight: 1}
+	for {
+		text, err := next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		text = m.filter(text)
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		if _, err := m.feed(context.Background(), &s, text); err != nil {
+			return err
+		}
+
+		m.mu.Lock()
+		m.finalize(&s)
+		m.mu.Unlock()
+	}
+}
+
+// BuildJSONL reads newline delimited JSON objects from r and updates the markov model with
+// the string value of the given field. Nested fields are selected by a path separated by
+// dots, e.g. "tweet.full_text". Every record is a text of its own, records without the field
+// are skipped.
+func (m *Markov) BuildJSONL(r io.Reader, field string) error {
+	path := strings.Split(field, ".")
+	reader := bufio.NewReader(r)
+
+	line := 0
+	return m.buildTexts(func() (string, error) {
+		for {
+			data, err := reader.ReadBytes('\n')
+			if err != nil && (err != io.EOF || len(data) == 0) {
+				return "", err
+			}
+			line = line + 1
+
+			if len(bytes.TrimSpace(data)) == 0 {
+				continue
+			}
+
+			var record map[string]interface{}
+			if err := json.Unmarshal(data, &record); err != nil {
+				return "", fmt.Errorf("line %v: %w", line, err)
+			}
+
+			if text, ok := lookup(record, path); ok {
+				return text, nil
+			}
+		}
+	})
+}
+
+// lookup returns the string at the path of nested fields of a JSON object
+func lookup(record map[string]interface{}, path []string) (string, bool) {
+	for i, name := range path {
+		value, found := record[name]
+		if !found {
+			return "", false
+		}
+
+		if i == len(path)-1 {
+			text, ok := value.(string)
+			return text, ok
+		}
+
+		if record, found = value.(map[string]interface{}); !found {
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// BuildURL downloads a text with corpus.DefaultFetcher and updates the markov model with
+// it. Set the fetcher's CacheDir to keep the downloaded texts.
+func (m *Markov) BuildURL(ctx context.Context, url string) error {
+	body, err := corpus.Open(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	if err := m.BuildContext(ctx, body); err != nil {
+		return fmt.Errorf("%v: %w", url, err)
+	}
+	return nil
+}
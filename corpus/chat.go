@@ -0,0 +1,156 @@
+package corpus
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Message is a message of a chat log
+type Message struct {
+	Author string
+	Text   string
+}
+
+var (
+	// "[12:34] <nick> text", "2020-01-02 12:34:56 <@nick> text" or "<nick> text"
+	ircMessage = regexp.MustCompile(`^(?:\[[^\]]*\]\s*|[\d][\d:.\-/T]*(?:\s+[\d][\d:.]*)?\s+)?<[ @+%&~]?([^>\s]+)>\s?(.*)$`)
+
+	// mentions, channels, custom emoji and links of Slack and Discord
+	chatLink   = regexp.MustCompile(`<(?:https?|mailto):[^|>]*\|([^>]*)>`)
+	chatMarkup = regexp.MustCompile(`<[@#!:][^>]*>|<a?:\w+:\d+>|<(?:https?|mailto):[^>]*>|https?://\S+`)
+	chatSpaces = regexp.MustCompile(`\s+`)
+
+	// "!roll", "/me" or ".weather", but not "...anyway" or ".5 is enough"
+	botCommand = regexp.MustCompile(`^(?:[!/]|\.\pL)`)
+)
+
+// IRC reads an IRC log and returns its messages. Timestamps are removed and all lines that
+// are not messages, like joins, parts or nick changes, are dropped, as well as bot commands.
+func IRC(r io.Reader) ([]Message, error) {
+	var msgs []Message
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		match := ircMessage.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if match == nil {
+			continue
+		}
+
+		if msg, ok := message(match[1], match[2]); ok {
+			msgs = append(msgs, msg)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return msgs, nil
+}
+
+// discordExport is the JSON written by DiscordChatExporter
+type discordExport struct {
+	Messages []struct {
+		Type    string `json:"type"`
+		Content string `json:"content"`
+		Author  struct {
+			Name     string `json:"name"`
+			Nickname string `json:"nickname"`
+			IsBot    bool   `json:"isBot"`
+		} `json:"author"`
+	} `json:"messages"`
+}
+
+// Discord reads a channel exported as JSON by DiscordChatExporter and returns its messages.
+// Messages of bots, system messages like joins and bot commands are dropped.
+func Discord(r io.Reader) ([]Message, error) {
+	var export discordExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, err
+	}
+
+	var msgs []Message
+	for _, m := range export.Messages {
+		if m.Author.IsBot || (m.Type != "" && m.Type != "Default" && m.Type != "Reply") {
+			continue
+		}
+
+		if msg, ok := message(m.Author.Name, m.Content); ok {
+			msgs = append(msgs, msg)
+		}
+	}
+
+	return msgs, nil
+}
+
+// slackMessage is a message of the daily JSON files of a Slack export
+type slackMessage struct {
+	Type        string `json:"type"`
+	Subtype     string `json:"subtype"`
+	User        string `json:"user"`
+	Text        string `json:"text"`
+	BotID       string `json:"bot_id"`
+	UserProfile struct {
+		Name     string `json:"name"`
+		RealName string `json:"real_name"`
+	} `json:"user_profile"`
+}
+
+// Slack reads one of the daily JSON files of a Slack channel export and returns its
+// messages. Messages of bots, joins and other events and bot commands are dropped. The
+// author is the user's name if the export contains it, the user ID otherwise.
+func Slack(r io.Reader) ([]Message, error) {
+	var export []slackMessage
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, err
+	}
+
+	var msgs []Message
+	for _, m := range export {
+		// messages of users have no subtype, except for replies broadcast to the channel
+		if m.Type != "message" || m.BotID != "" || (m.Subtype != "" && m.Subtype != "thread_broadcast") {
+			continue
+		}
+
+		author := m.UserProfile.Name
+		if author == "" {
+			author = m.User
+		}
+
+		if msg, ok := message(author, m.Text); ok {
+			msgs = append(msgs, msg)
+		}
+	}
+
+	return msgs, nil
+}
+
+// ByAuthor partitions messages by their author
+func ByAuthor(msgs []Message) map[string][]Message {
+	authors := make(map[string][]Message)
+	for _, m := range msgs {
+		authors[m.Author] = append(authors[m.Author], m)
+	}
+	return authors
+}
+
+// message cleans up the text of a message. The result is false for bot commands and
+// messages without text.
+func message(author, text string) (Message, bool) {
+	text = strings.TrimSpace(text)
+	if text == "" || botCommand.MatchString(text) {
+		return Message{}, false
+	}
+
+	text = chatLink.ReplaceAllString(text, "$1")
+	text = chatMarkup.ReplaceAllString(text, "")
+	text = strings.TrimSpace(chatSpaces.ReplaceAllString(text, " "))
+	if text == "" {
+		return Message{}, false
+	}
+
+	return Message{Author: author, Text: text}, true
+}
@@ -0,0 +1,31 @@
+package corpus
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIRCBotCommands(t *testing.T) {
+	log := strings.Join([]string{
+		"[12:00] <alice> !roll 2d6",
+		"[12:01] <bob> /me waves",
+		"[12:02] <alice> .weather Berlin",
+		"[12:03] <bob> ...anyway",
+		"[12:04] <alice> .5 is enough",
+		"[12:05] <bob> . is a period",
+	}, "\n")
+
+	msgs, err := IRC(strings.NewReader(log))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Message{
+		{Author: "bob", Text: "...anyway"},
+		{Author: "alice", Text: ".5 is enough"},
+		{Author: "bob", Text: ". is a period"},
+	}
+	if !reflect.DeepEqual(msgs, want) {
+		t.Errorf("IRC = %v, want %v", msgs, want)
+	}
+}
@@ -0,0 +1,90 @@
+package corpus
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// Item is an item of a RSS feed or an entry of an Atom feed
+type Item struct {
+	GUID        string // the guid or id of the item, its link if it has none
+	Title       string
+	Description string // the description, summary or content, which may contain HTML
+}
+
+// feedXML covers RSS 2.0, RSS 1.0 and Atom. The items of RSS 1.0 are not nested in the channel.
+type feedXML struct {
+	Channel struct {
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Items   []rssItem   `xml:"item"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type rssItem struct {
+	GUID        string `xml:"guid"`
+	About       string `xml:"about,attr"`
+	Link        string `xml:"link"`
+	Title       string `xml:"title"`
+	Description string `xml:"description"`
+	Content     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+}
+
+type atomEntry struct {
+	ID   string `xml:"id"`
+	Link []struct {
+		Href string `xml:"href,attr"`
+	} `xml:"link"`
+	Title   string `xml:"title"`
+	Summary string `xml:"summary"`
+	Content string `xml:"content"`
+}
+
+// Feed reads a RSS or Atom feed and returns its items
+func Feed(r io.Reader) ([]Item, error) {
+	var feed feedXML
+
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	if err := decoder.Decode(&feed); err != nil {
+		return nil, err
+	}
+
+	var items []Item
+	for _, i := range append(feed.Channel.Items, feed.Items...) {
+		item := Item{
+			GUID:        first(i.GUID, i.About, i.Link),
+			Title:       strings.TrimSpace(i.Title),
+			Description: strings.TrimSpace(first(i.Description, i.Content)),
+		}
+		items = append(items, item)
+	}
+
+	for _, e := range feed.Entries {
+		item := Item{
+			GUID:        strings.TrimSpace(e.ID),
+			Title:       strings.TrimSpace(e.Title),
+			Description: strings.TrimSpace(first(e.Summary, e.Content)),
+		}
+		if item.GUID == "" && len(e.Link) > 0 {
+			item.GUID = e.Link[0].Href
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// first returns the first of the strings that is not empty
+func first(s ...string) string {
+	for _, v := range s {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,148 @@
+package corpus
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// Fetcher downloads texts over HTTP. With a cache directory, downloaded texts are kept and
+// revalidated with the server using their ETag and Last-Modified headers.
+type Fetcher struct {
+	Client   *http.Client // nil selects http.DefaultClient
+	CacheDir string       // the directory of cached texts, empty disables the cache
+}
+
+// DefaultFetcher is used by Open and OpenGutenberg
+var DefaultFetcher = &Fetcher{}
+
+// cacheEntry is the metadata of a cached text
+type cacheEntry struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// Open downloads a text with the DefaultFetcher. The caller must close the result.
+func Open(ctx context.Context, url string) (io.ReadCloser, error) {
+	return DefaultFetcher.Open(ctx, url)
+}
+
+// Open downloads a text, or opens the cached copy if the server reports that it did not
+// change. The caller must close the result.
+func (f *Fetcher) Open(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// revalidate a cached copy
+	var entry cacheEntry
+	cached := false
+	if f.CacheDir != "" {
+		entry, cached = f.cached(url)
+		if cached {
+			if entry.ETag != "" {
+				req.Header.Set("If-None-Match", entry.ETag)
+			}
+			if entry.LastModified != "" {
+				req.Header.Set("If-Modified-Since", entry.LastModified)
+			}
+		}
+	}
+
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached {
+		resp.Body.Close()
+		return os.Open(f.path(url))
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%v: %v", url, resp.Status)
+	}
+
+	if f.CacheDir == "" {
+		return resp.Body, nil
+	}
+	defer resp.Body.Close()
+
+	entry = cacheEntry{
+		URL:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	if err := f.store(entry, resp.Body); err != nil {
+		return nil, err
+	}
+
+	return os.Open(f.path(url))
+}
+
+// cached returns the metadata of the cached copy of a text
+func (f *Fetcher) cached(url string) (cacheEntry, bool) {
+	var entry cacheEntry
+
+	data, err := ioutil.ReadFile(f.path(url) + ".json")
+	if err != nil || json.Unmarshal(data, &entry) != nil || entry.URL != url {
+		return entry, false
+	}
+	if _, err := os.Stat(f.path(url)); err != nil {
+		return entry, false
+	}
+
+	return entry, true
+}
+
+// store writes a text and its metadata to the cache. The text is written to a temporary
+// file first, an interrupted download does not replace the cached copy.
+func (f *Fetcher) store(entry cacheEntry, body io.Reader) error {
+	if err := os.MkdirAll(f.CacheDir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(f.CacheDir, ".download-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), f.path(entry.URL)); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(&entry)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(f.path(entry.URL)+".json", data, 0644)
+}
+
+// path returns the name of the cached copy of a text
+func (f *Fetcher) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(f.CacheDir, hex.EncodeToString(sum[:]))
+}
@@ -0,0 +1,128 @@
+// Package corpus reads and cleans up texts from common sources before a model is built from them.
+package corpus
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// GutenbergURL is the address of the plain text of a Project Gutenberg e-text, by its number
+const GutenbergURL string = "https://www.gutenberg.org/cache/epub/%d/pg%d.txt"
+
+var (
+	gutenbergStart = []string{
+		"*** START OF THE PROJECT GUTENBERG",
+		"*** START OF THIS PROJECT GUTENBERG",
+		"***START OF THE PROJECT GUTENBERG",
+		"*END*THE SMALL PRINT!",
+	}
+	gutenbergEnd = []string{
+		"*** END OF THE PROJECT GUTENBERG",
+		"*** END OF THIS PROJECT GUTENBERG",
+		"***END OF THE PROJECT GUTENBERG",
+		"END OF THE PROJECT GUTENBERG EBOOK",
+		"END OF THIS PROJECT GUTENBERG EBOOK",
+		"End of the Project Gutenberg EBook",
+		"End of Project Gutenberg's",
+		"End of the Project Gutenberg",
+	}
+
+	// the credits at the beginning of the text
+	gutenbergCredits = regexp.MustCompile(`(?i)^(produced|transcribed|prepared|e-?text prepared) by\b`)
+
+	// a chapter heading on a line of its own, e.g. "CHAPTER I." or "Chapter 12"
+	chapterHeading = regexp.MustCompile(`^(?i:(chapter|book|part|volume|stave|canto|act|scene|section)\s+` +
+		`([ivxlcdm]+|\d+|the last|(first|second|third|fourth|fifth|sixth|seventh|eighth|ninth|tenth)|` +
+		`(one|two|three|four|five|six|seven|eight|nine|ten|eleven|twelve)|[a-z]+-(one|two|three|four|five|six|seven|eight|nine))` +
+		`([.:]|[.:]?\s+[-—–].*|[.:]\s+.*)?)$`)
+
+	// a chapter number on a line of its own, e.g. "XIV." or "12". It is a heading only after
+	// an empty line, as a wrapped line of the text may be a single word like "I" or "MIX."
+	chapterNumber = regexp.MustCompile(`^([IVXLCDM]+|\d+)\.?$`)
+)
+
+// OpenGutenberg downloads the plain text of the e-text with the given number, using the
+// DefaultFetcher. The caller must close the result.
+func OpenGutenberg(ctx context.Context, id int) (io.ReadCloser, error) {
+	return Open(ctx, fmt.Sprintf(GutenbergURL, id, id))
+}
+
+// Gutenberg reads a Project Gutenberg e-text and returns its body. The license header and
+// footer and the credits are removed, chapter headings are replaced by paragraph breaks.
+// Texts without the Project Gutenberg markers are returned in full.
+func Gutenberg(r io.Reader) (string, error) {
+	var lines []string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if len(lines) == 0 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	// the body is between the start and the end marker
+	start, end := 0, len(lines)
+	for i, line := range lines {
+		if hasPrefix(line, gutenbergStart) {
+			start = i + 1
+			break
+		}
+	}
+	for i := start; i < len(lines); i++ {
+		if hasPrefix(lines[i], gutenbergEnd) {
+			end = i
+			break
+		}
+	}
+
+	var b strings.Builder
+	first, credits, blank := true, false, true
+	for _, line := range lines[start:end] {
+		text := strings.TrimSpace(line)
+		afterBlank := blank
+		blank = text == ""
+
+		// the credits are the first paragraph of the body
+		if first && text != "" {
+			first = false
+			credits = gutenbergCredits.MatchString(text)
+		}
+		if credits {
+			credits = text != ""
+			continue
+		}
+
+		if chapterHeading.MatchString(text) || (afterBlank && chapterNumber.MatchString(text)) {
+			b.WriteString("\n\n")
+			continue
+		}
+
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+
+	return strings.TrimSpace(blankLines.ReplaceAllString(b.String(), "\n\n")), nil
+}
+
+// blankLines matches runs of empty lines
+var blankLines = regexp.MustCompile(`\n\s*\n`)
+
+// hasPrefix is true if s starts with one of the prefixes
+func hasPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package corpus
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGutenberg(t *testing.T) {
+	text := strings.Join([]string{
+		"The Project Gutenberg eBook of a Test",
+		"*** START OF THE PROJECT GUTENBERG EBOOK A TEST ***",
+		"Produced by a volunteer.",
+		"",
+		"CHAPTER I.",
+		"",
+		"The cat sat down and it",
+		"did.",
+		"The weather was",
+		"mild.",
+		"The talk was",
+		"civil.",
+		"Then",
+		"I",
+		"left.",
+		"",
+		"II.",
+		"",
+		"Chapter 3: The End",
+		"",
+		"It ended.",
+		"*** END OF THE PROJECT GUTENBERG EBOOK A TEST ***",
+		"The license.",
+	}, "\n")
+
+	got, err := Gutenberg(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "The cat sat down and it\ndid.\nThe weather was\nmild.\nThe talk was\ncivil.\nThen\nI\nleft.\n\nIt ended."
+	if got != want {
+		t.Errorf("Gutenberg = %q, want %q", got, want)
+	}
+}
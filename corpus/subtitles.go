@@ -0,0 +1,74 @@
+package corpus
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	subtitleTags     = regexp.MustCompile(`</?[a-zA-Z][^>]*>|\{\\[^}]*\}`)
+	subtitleNotes    = regexp.MustCompile(`\[[^\]]*\]|\([^)]*\)`)
+	subtitleSpeaker  = regexp.MustCompile(`^[A-Z][A-Z0-9 .'-]*:\s*`)
+	subtitleDialogue = regexp.MustCompile(`^-+\s*`)
+)
+
+// Subtitles reads a SubRip (.srt) or WebVTT (.vtt) file and returns its dialogue, one line
+// of a cue per line. Cue numbers, timestamps, formatting tags, speaker labels and
+// descriptions of sounds like "[laughs]" are removed.
+func Subtitles(r io.Reader) (string, error) {
+	var b strings.Builder
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	// the cues are blocks of lines separated by empty lines. The lines after the
+	// timestamps are the dialogue, blocks without timestamps are headers or comments.
+	var block []string
+	cue := func() {
+		for i, line := range block {
+			if strings.Contains(line, "-->") {
+				for _, text := range block[i+1:] {
+					if text = dialogue(text); text != "" {
+						b.WriteString(text)
+						b.WriteString("\n")
+					}
+				}
+				break
+			}
+		}
+		block = block[:0]
+	}
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" {
+			cue()
+			continue
+		}
+		block = append(block, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	cue()
+
+	return strings.TrimSpace(b.String()), nil
+}
+
+// dialogue removes the formatting and everything that is not spoken from a line of a cue
+func dialogue(line string) string {
+	line = subtitleTags.ReplaceAllString(line, "")
+	line = subtitleNotes.ReplaceAllString(line, "")
+	line = strings.TrimSpace(line)
+	line = subtitleDialogue.ReplaceAllString(line, "")
+	line = subtitleSpeaker.ReplaceAllString(line, "")
+
+	// lyrics
+	if strings.ContainsAny(line, "♪♫") {
+		return ""
+	}
+
+	return strings.TrimSpace(line)
+}
@@ -0,0 +1,152 @@
+package garkov
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// Detokenizer joins generated tokens into text
+type Detokenizer interface {
+	Detokenize(tokens []Token) string
+}
+
+// EnglishDetokenizer is the default detokenizer of word level models. It puts no space before
+// punctuation and contractions, none after opening brackets and quotes, capitalizes the
+// beginning of each sentence and closes quotes that were left open.
+type EnglishDetokenizer struct{}
+
+// CharDetokenizer is the default detokenizer of character level models, it simply concatenates the tokens
+type CharDetokenizer struct{}
+
+var (
+	// tokens that attach to the previous token
+	closing = map[string]bool{
+		",": true, ".": true, "!": true, "?": true, ";": true, ":": true, "%": true, "…": true,
+		")": true, "]": true, "}": true, "»": true,
+		"n't": true, "'s": true, "'re": true, "'ve": true, "'ll": true, "'d": true, "'m": true,
+		"N'T": true, "'S": true, "'RE": true, "'VE": true, "'LL": true, "'D": true, "'M": true,
+	}
+	// tokens that attach to the next token
+	opening = map[string]bool{
+		"(": true, "[": true, "{": true, "$": true, "#": true, "«": true, "„": true, "¿": true, "¡": true,
+	}
+	// opening quotes and the quotes that close them, in the Penn Treebank notation and plain
+	quotePairs = map[string]string{"``": "''", "\"": "\"", "“": "”", "„": "“"}
+	// quotes that are written differently than they are tokenized
+	quoteText = map[string]string{"``": "\"", "''": "\""}
+)
+
+// Detokenize joins the tokens into a sentence
+func (d *EnglishDetokenizer) Detokenize(tokens []Token) string {
+	var b strings.Builder
+
+	quote := ""     // the open quote
+	attach := true  // no space before the next token
+	capital := true // capitalize the next word
+
+	for _, t := range tokens {
+		w := t.Word
+		if w == "" {
+			continue
+		}
+
+		// quotes
+		if quote != "" && w == quotePairs[quote] {
+			b.WriteString(quoteString(w))
+			quote = ""
+			attach = false
+			continue
+		}
+		if _, opens := quotePairs[w]; opens && quote == "" {
+			if !attach {
+				b.WriteString(" ")
+			}
+			b.WriteString(quoteString(w))
+			quote = w
+			attach = true
+			continue
+		}
+		if isQuote(w) {
+			continue // a closing quote without an opening one, or a nested quote
+		}
+
+		if !attach && !closing[w] && t.Type < dictionary.STOP {
+			b.WriteString(" ")
+		}
+
+		if capital && t.Type == dictionary.WORD {
+			w = capitalize(w)
+			capital = false
+		}
+		b.WriteString(w)
+
+		attach = opening[w]
+		if t.Type == dictionary.SENTENCE_END {
+			capital = true
+		}
+	}
+
+	// close a quote that was left open
+	if quote != "" {
+		b.WriteString(quoteString(quotePairs[quote]))
+	}
+
+	return b.String()
+}
+
+// Detokenize concatenates the tokens
+func (d *CharDetokenizer) Detokenize(tokens []Token) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteString(t.Word)
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+// isQuote is true for opening and closing quotes
+func isQuote(w string) bool {
+	if _, found := quotePairs[w]; found {
+		return true
+	}
+	for _, closer := range quotePairs {
+		if w == closer {
+			return true
+		}
+	}
+	return false
+}
+
+// quoteString returns the text of a quote token
+func quoteString(w string) string {
+	if s, found := quoteText[w]; found {
+		return s
+	}
+	return w
+}
+
+// capitalize returns the word with its first letter in upper case
+func capitalize(w string) string {
+	r, size := utf8.DecodeRuneInString(w)
+	if r == utf8.RuneError || unicode.IsUpper(r) {
+		return w
+	}
+	return string(unicode.ToUpper(r)) + w[size:]
+}
+
+// detokenizer returns the detokenizer of the model, or the default one for its mode
+func (m *Markov) detokenizer() Detokenizer {
+	if m.Detokenizer != nil {
+		return m.Detokenizer
+	}
+	if m.Mode == CharLevel {
+		return &CharDetokenizer{}
+	}
+	if isCJKLanguage(m.Language) {
+		return &CJKDetokenizer{}
+	}
+	return &EnglishDetokenizer{}
+}
@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 const (
@@ -14,13 +16,49 @@ const (
 
 	PUNCTUATION int = 20 // .!?
 	STOP        int = 20
+	MARK        int = 21 // punctuation that does not end a sentence
 	COLON       int = 22 // ,
 	SEMICOLON   int = 23 // ;
 
 	SENTENCE_END_TOKEN string = "."
 	SENTENCE_END       int    = STOP
+
+	UNKNOWN_TOKEN string = "<unk>" // replaces the words beyond the size limit of a model
+
+	NUMBER_TOKEN  string = "<number>"  // replaces numbers if the tokenizer uses placeholders
+	URL_TOKEN     string = "<url>"     // replaces URLs if the tokenizer uses placeholders
+	EMAIL_TOKEN   string = "<email>"   // replaces email addresses if the tokenizer uses placeholders
+	MENTION_TOKEN string = "<mention>" // replaces @mentions if the tokenizer uses placeholders
+	HASHTAG_TOKEN string = "<hashtag>" // replaces hashtags if the tokenizer uses placeholders
+
+	ESCAPE_PREFIX string = "\x1b" // prefixes the words of a text that spell a control token, see Escape
 )
 
+// controlTokens are the words reserved for the tokens a model or tokenizer substitutes
+var controlTokens = map[string]bool{
+	UNKNOWN_TOKEN: true,
+	NUMBER_TOKEN:  true,
+	URL_TOKEN:     true,
+	EMAIL_TOKEN:   true,
+	MENTION_TOKEN: true,
+	HASHTAG_TOKEN: true,
+}
+
+// IsControl is true if w is one of the reserved control tokens, e.g. UNKNOWN_TOKEN
+func IsControl(w string) bool {
+	return controlTokens[w]
+}
+
+// Escape returns the entry of a word of a text. A word that spells a control token, or
+// starts with ESCAPE_PREFIX, is prefixed with ESCAPE_PREFIX so that it never shares an
+// entry with a control token. Other words are their own entries.
+func Escape(w string) string {
+	if controlTokens[w] || strings.HasPrefix(w, ESCAPE_PREFIX) {
+		return ESCAPE_PREFIX + w
+	}
+	return w
+}
+
 // Word the basic dictionary structure
 type Word struct {
 	Word  string
@@ -32,12 +70,20 @@ type Word struct {
 type WordMap map[string]Word
 type WordVector []string
 
+// Normalizer maps the surface form of a word to its canonical entry, e.g. a stemmer. A model
+// only normalizes tokens of type WORD.
+type Normalizer func(w string) string
+
 // Dictionary the collection of words
 type Dictionary struct {
 	Name  string     // name of the dictionary
 	Size  int        // number of words in the dictionary
 	Words WordMap    // map of words and their stats
 	V     WordVector // the word vector
+
+	Forms      map[string]map[string]int // the surface forms of case-folded or normalized words and their counts
+	Tags       map[string]map[string]int // the tags of words and their counts, e.g. their parts of speech
+	Normalizer Normalizer                // maps words to their canonical entries, nil keeps them. It is not persisted.
 }
 
 // New creates and initialize a new dictionary
@@ -57,59 +103,95 @@ func New(name string) *Dictionary {
 
 }
 
-// Open creates a new dictionary and reads a persisted version from disc if available.
-func Open(name string) *Dictionary {
-
-	// new, empty dictionary
-	dict := New(name)
+// Open creates a new dictionary and reads a persisted version from disc if available. The
+// words keep the indices they had when the dictionary was written by Close, so chains that
+// refer to them by index, e.g. in a database, stay valid.
+func Open(name string) (*Dictionary, error) {
 
 	// try to open dictionary
 	fileName := name + ".dict"
 	file, err := os.Open(fileName)
+	if os.IsNotExist(err) {
+		return New(name), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
 
-	if err == nil {
-		defer file.Close()
-		// read an existing dictionary
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			// parse a single line into a word
-			w, word, _ := parseWord(scanner.Text())
-
-			// update the dictionary
-			dict.Words[w] = word
-			dict.Size = dict.Size + 1
+	dict := Dictionary{
+		Name:  name,
+		Words: make(WordMap),
+	}
+
+	// read an existing dictionary
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// parse a single line into a word
+		w, word, err := parseWord(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", fileName, err)
+		}
+		if _, found := dict.Words[w]; found {
+			return nil, fmt.Errorf("%v: duplicate word '%v'", fileName, w)
 		}
 
-		// initialize the word vector
-		dict.V = make([]string, dict.Size)
-		for _, word := range dict.Words {
-			dict.V[word.Idx] = word.Word
+		// update the dictionary
+		dict.Words[w] = word
+		dict.Size = dict.Size + 1
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// initialize the word vector, every index is taken by exactly one word
+	dict.V = make([]string, dict.Size)
+	taken := make([]bool, dict.Size)
+	for _, word := range dict.Words {
+		if word.Idx < 0 || word.Idx >= dict.Size || taken[word.Idx] {
+			return nil, fmt.Errorf("%v: invalid index %v for word '%v'", fileName, word.Idx, word.Word)
 		}
+		dict.V[word.Idx] = word.Word
+		taken[word.Idx] = true
 	}
 
-	return dict
+	return &dict, nil
 
 }
 
-// Close persists the dictionary to disc.
-func (d *Dictionary) Close() {
+// Close persists the dictionary to disc, to the file Name.dict that Open reads. The words
+// are written in the order of their index, one per line. The forms of the words are not
+// written, a model written by Save keeps them.
+func (d *Dictionary) Close() error {
 
 	fileName := d.Name + ".dict"
 	f, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
 
-	if err == nil {
-		for _, word := range d.Words {
-			f.Write([]byte(word.ToS() + "\n"))
+	w := bufio.NewWriter(f)
+	for _, v := range d.V {
+		word := d.Words[v]
+		if _, err := w.WriteString(word.ToS() + "\n"); err != nil {
+			f.Close()
+			return err
 		}
+	}
+	if err := w.Flush(); err != nil {
 		f.Close()
+		return err
 	}
+
+	return f.Close()
 }
 
 // Add add a word to the dictionary
 func (d *Dictionary) Add(w string) Word {
-	return d.AddWithType(w, tokenType(w))
+	return d.AddWithType(w, TokenType(w))
 }
 
+// AddWithType adds a word of a given type to the dictionary
 func (d *Dictionary) AddWithType(w string, t int) Word {
 
 	word, found := d.Words[w]
@@ -140,6 +222,123 @@ func (d *Dictionary) AddWithType(w string, t int) Word {
 
 }
 
+// Remove decreases the count of a word. The word stays in the dictionary to keep the
+// indices of all words stable.
+func (d *Dictionary) Remove(w string) (Word, bool) {
+	word, found := d.Words[w]
+	if !found {
+		return word, false
+	}
+
+	if word.Count > 0 {
+		word.Count = word.Count - 1
+		d.Words[w] = word
+	}
+
+	return word, true
+}
+
+// Compact removes the words keep rejects and renumbers the others without gaps, in the
+// order of their index. It returns the new index of each old index, -1 for removed words.
+// Everything that refers to the words by index must be renumbered with it.
+func (d *Dictionary) Compact(keep func(w Word) bool) []int {
+	index := make([]int, len(d.V))
+	v := make(WordVector, 0, len(d.V))
+
+	for i, w := range d.V {
+		word := d.Words[w]
+		if !keep(word) {
+			index[i] = -1
+			delete(d.Words, w)
+			delete(d.Forms, w)
+			delete(d.Tags, w)
+			continue
+		}
+
+		index[i] = len(v)
+		word.Idx = len(v)
+		d.Words[w] = word
+		v = append(v, w)
+	}
+
+	d.V = v
+	d.Size = len(v)
+	return index
+}
+
+// Normalize returns the canonical entry of the word w
+func (d *Dictionary) Normalize(w string) string {
+	if d.Normalizer == nil {
+		return w
+	}
+	return d.Normalizer(w)
+}
+
+// AddForm counts a surface form of the case-folded or normalized word w
+func (d *Dictionary) AddForm(w, form string) {
+	if d.Forms == nil {
+		d.Forms = make(map[string]map[string]int)
+	}
+
+	forms, found := d.Forms[w]
+	if !found {
+		forms = make(map[string]int)
+		d.Forms[w] = forms
+	}
+	forms[form] = forms[form] + 1
+}
+
+// Form returns the most frequent surface form of the case-folded or normalized word w,
+// or w without the escape of Escape if no form was counted
+func (d *Dictionary) Form(w string) string {
+	form := strings.TrimPrefix(w, ESCAPE_PREFIX)
+	max := 0
+	for f, count := range d.Forms[w] {
+		// ties go to the lexically smaller spelling, the order of a map is random
+		if count > max || (count == max && f < form) {
+			form = f
+			max = count
+		}
+	}
+	return form
+}
+
+// AddTag counts a tag of the word w, e.g. its part of speech in a sentence
+func (d *Dictionary) AddTag(w, tag string) {
+	if d.Tags == nil {
+		d.Tags = make(map[string]map[string]int)
+	}
+
+	tags, found := d.Tags[w]
+	if !found {
+		tags = make(map[string]int)
+		d.Tags[w] = tags
+	}
+	tags[tag] = tags[tag] + 1
+}
+
+// Tag returns the most frequent tag of the word w, or "" if it has none
+func (d *Dictionary) Tag(w string) string {
+	tag := ""
+	max := 0
+	for t, count := range d.Tags[w] {
+		// ties go to the lexically smaller tag, the order of a map is random
+		if count > max || (count == max && t < tag) {
+			tag = t
+			max = count
+		}
+	}
+	return tag
+}
+
+// SetType changes the type of the word w, e.g. of the period when it does not end a sentence
+func (d *Dictionary) SetType(w string, t int) {
+	if word, found := d.Words[w]; found && word.Type != t {
+		word.Type = t
+		d.Words[w] = word
+	}
+}
+
 // Exists returns true if a word exists in the dictionary
 func (d *Dictionary) Exists(w string) bool {
 	_, found := d.Words[w]
@@ -154,34 +353,93 @@ func (d *Dictionary) Get(w string) (Word, bool) {
 
 // GetAt returns the word at word vector index idx
 func (d *Dictionary) GetAt(idx int) (Word, bool) {
-	if idx < 0 || idx > len(d.V) {
+	if idx < 0 || idx >= len(d.V) {
 		return Word{}, false
 	}
 	return d.Get(d.V[idx])
 }
 
+// WordAt returns the word at word vector index idx, or "" if there is none
+func (d *Dictionary) WordAt(idx int) string {
+	if idx < 0 || idx >= len(d.V) {
+		return ""
+	}
+	return d.V[idx]
+}
+
+// IndexOf returns the index of the word w, or -1 if it is not in the dictionary
+func (d *Dictionary) IndexOf(w string) int {
+	word, found := d.Words[w]
+	if !found {
+		return -1
+	}
+	return word.Idx
+}
+
+// Len returns the number of words in the dictionary
+func (d *Dictionary) Len() int {
+	return len(d.V)
+}
+
+// Range calls f for each word in the order of their index, until f returns false
+func (d *Dictionary) Range(f func(w Word) bool) {
+	for _, v := range d.V {
+		if !f(d.Words[v]) {
+			return
+		}
+	}
+}
+
+// Ranked returns the words ordered by their count, the most frequent first. Words with the
+// same count are ordered lexically.
+func (d *Dictionary) Ranked() []Word {
+	words := make([]Word, 0, len(d.Words))
+	for _, word := range d.Words {
+		words = append(words, word)
+	}
+
+	sort.Slice(words, func(i, j int) bool {
+		if words[i].Count != words[j].Count {
+			return words[i].Count > words[j].Count
+		}
+		return words[i].Word < words[j].Word
+	})
+	return words
+}
+
 // ToS dumps a word into a string
 func (w *Word) ToS() string {
 	return fmt.Sprintf("%v,%v,%v,%v", w.Word, w.Type, w.Count, w.Idx)
 }
 
-// parseWord parses a comma separated string into a word
+// parseWord parses a comma separated string into a word. The word itself may contain
+// commas, the numbers are the last three fields.
 func parseWord(s string) (string, Word, error) {
 	// Format: word, type, count, ix
 	// Example: one,1,1,1
 
 	parts := strings.Split(s, ",")
-	if len(parts) != 4 {
+	if len(parts) < 4 {
 		return "", Word{}, errors.New("Insufficient number of parts")
 	}
+	n := len(parts) - 3
 
 	// extract the parts
-	t, _ := strconv.Atoi(parts[1])
-	count, _ := strconv.Atoi(parts[2])
-	idx, _ := strconv.Atoi(parts[3])
+	t, err := strconv.Atoi(parts[n])
+	if err != nil {
+		return "", Word{}, err
+	}
+	count, err := strconv.Atoi(parts[n+1])
+	if err != nil {
+		return "", Word{}, err
+	}
+	idx, err := strconv.Atoi(parts[n+2])
+	if err != nil {
+		return "", Word{}, err
+	}
 
 	w := Word{
-		Word:  parts[0],
+		Word:  strings.Join(parts[:n], ","),
 		Type:  t,
 		Count: count,
 		Idx:   idx,
@@ -190,30 +448,31 @@ func parseWord(s string) (string, Word, error) {
 	return w.Word, w, nil
 }
 
-func tokenType(t string) int {
+// TokenType classifies a token as WORD or one of the punctuation types.
+func TokenType(t string) int {
 
 	// most common case ...
-	if len(t) > 1 {
+	if utf8.RuneCountInString(t) > 1 {
 		return WORD
 	}
 
-	if t == "." {
+	if t == "." || t == "。" {
 		return PUNCTUATION
 	}
 
-	if t == "," {
+	if t == "," || t == "，" || t == "、" {
 		return COLON
 	}
 
-	if t == "!" {
+	if t == "!" || t == "！" {
 		return PUNCTUATION
 	}
 
-	if t == "?" {
+	if t == "?" || t == "？" {
 		return PUNCTUATION
 	}
 
-	if t == ";" {
+	if t == ";" || t == "；" {
 		return SEMICOLON
 	}
 
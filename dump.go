@@ -8,8 +8,11 @@ import (
 
 // Debug prints the model for debugging
 func (m *Markov) Debug() {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 
-	fmt.Println("\nDumping model ...\n")
+	fmt.Println("\nDumping model ...")
+	fmt.Println("")
 
 	// the word vector
 	fmt.Println("Words:")
@@ -25,12 +28,18 @@ func (m *Markov) Debug() {
 
 	fmt.Println("Start prefixes:")
 	fmt.Println(m.Start)
+	fmt.Println(m.StartCounts)
 	fmt.Println("")
 
 	fmt.Println("Word Chains:")
-	for _, suffix := range m.Chain {
-		fmt.Println(suffix.PrettyPrintChain(m.Dict))
-	}
+	m.Chain.Range(func(prefix []int, suffixes []WordCount) bool {
+		chain := WordChain{Prefix: prefix, Words: make(map[string]WordCount, len(suffixes)), State: m.chainState(prefix)}
+		for _, suffix := range suffixes {
+			chain.Words[m.Dict.WordAt(suffix.Idx)] = suffix
+		}
+		fmt.Println(chain.PrettyPrintChain(m.Dict))
+		return true
+	})
 
 	fmt.Println("")
 }
@@ -42,12 +51,12 @@ func prettyPrintWord(w dictionary.Word) string {
 func (c *WordChain) PrettyPrintChain(d *dictionary.Dictionary) string {
 	_prefix := ""
 	for i := range c.Prefix {
-		_prefix = _prefix + d.V[c.Prefix[i]] + " "
+		_prefix = _prefix + d.WordAt(c.Prefix[i]) + " "
 	}
 
 	_suffix := ""
 	for w := range c.Words {
 		_suffix = _suffix + w + " "
 	}
-	return fmt.Sprintf("%v -> %v", _prefix, _suffix)
+	return fmt.Sprintf("%v -> %v[%v]", _prefix, _suffix, c.State)
 }
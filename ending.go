@@ -0,0 +1,123 @@
+package garkov
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// endingTries is the number of sentences generated to find one whose last word passes
+// GenOptions.LastWord, if the model has no backward chain
+const endingTries int = 100
+
+// ending is a prefix that ended a sentence with one of the STOP words
+type ending struct {
+	prefix []int
+	stop   int     // the index of the STOP word
+	count  float64 // the count of the STOP word after the prefix
+}
+
+// generateEnding creates a sentence whose last word passes opts.LastWord. A model with a
+// backward chain draws one of the prefixes that ended a sentence with such a word, in
+// proportion to their counts, and extends it to the left. Otherwise, or if opts.StartWith is
+// set, sentences are generated until one ends with such a word.
+func (m *Markov) generateEnding(seed []Token, opts GenOptions) (string, error) {
+	begin := time.Now()
+
+	s := ""
+	if m.Backward && m.Reverse.Len() > 0 && opts.StartWith == "" {
+		endings := m.endings(opts)
+		weights := make([]float64, len(endings))
+		for i, e := range endings {
+			weights[i] = e.count
+		}
+
+		for i, j := range m.weightedOrder(weights) {
+			if i >= charLimitTries {
+				break
+			}
+			sentence := m.extendLeft(m.prefixWords(endings[j].prefix), opts)
+			stop, _ := m.Dict.GetAt(endings[j].stop)
+			if text := m.text(append(sentence, stop), opts); fits(text, opts) && longEnough(text, opts) {
+				s = text
+				break
+			}
+		}
+	}
+
+	for i := 0; s == "" && i < endingTries; i++ {
+		var start []dictionary.Word
+		if opts.StartWith != "" {
+			if start = m.seedStart(seed); start == nil {
+				return "", fmt.Errorf("%w '%v'", ErrUnknownPrefix, opts.StartWith)
+			}
+		} else if start = m.randomStart(opts); start == nil {
+			return "", fmt.Errorf("%w: no start prefix passes the word filter", ErrEmptyModel)
+		}
+
+		sentence := m.generate(start, opts)
+		if last := lastWord(sentence); last != "" && opts.LastWord(last) {
+			if text := m.text(sentence, opts); fits(text, opts) && longEnough(text, opts) {
+				s = text
+			}
+		}
+	}
+	if s == "" {
+		return "", ErrNoEnding
+	}
+
+	if m.Metrics != nil {
+		m.Metrics.Generated(m.Name, time.Since(begin))
+	}
+	return s, nil
+}
+
+// endings returns the prefixes of the chain whose last word passes opts.LastWord and that
+// ended a sentence, without the prefixes the word filters reject
+func (m *Markov) endings(opts GenOptions) []ending {
+	filter := m.wordFilter(opts)
+
+	var endings []ending
+	m.Chain.Range(func(prefix []int, suffixes []WordCount) bool {
+		if len(prefix) != m.Depth || !m.withinSentence(prefix) || !m.allowedPrefix(prefix, filter) {
+			return true
+		}
+		if last := m.Dict.WordAt(prefix[len(prefix)-1]); strings.IndexFunc(last, isAlnum) < 0 || !opts.LastWord(last) {
+			return true
+		}
+		for _, w := range suffixes {
+			if word, _ := m.Dict.GetAt(w.Idx); word.Type == dictionary.STOP {
+				endings = append(endings, ending{prefix: prefix, stop: w.Idx, count: w.Count})
+			}
+		}
+		return true
+	})
+
+	// the order of the storage is random, draw from a stable order
+	sort.Slice(endings, func(i, j int) bool {
+		if ki, kj := prefixKey(endings[i].prefix), prefixKey(endings[j].prefix); ki != kj {
+			return ki < kj
+		}
+		return endings[i].stop < endings[j].stop
+	})
+	return endings
+}
+
+// lastWord returns the last word of a sentence before its STOP word and closing quotes or
+// parentheses, or "" if that is not a word with a letter or digit
+func lastWord(sentence []dictionary.Word) string {
+	for i := len(sentence) - 1; i >= 0; i-- {
+		w := sentence[i]
+		if w.Type == dictionary.STOP || closers[w.Word] {
+			continue
+		}
+		if w.Type == dictionary.WORD && strings.IndexFunc(w.Word, isAlnum) >= 0 {
+			return w.Word
+		}
+		return ""
+	}
+	return ""
+}
@@ -0,0 +1,216 @@
+package garkov
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// Ensemble generates sentences from a weighted mixture of models. Each word is sampled from
+// the suffixes of all models that know the words preceding it, the probabilities of each
+// model scaled by its weight. The models may differ in depth and dictionary, but should
+// tokenize text alike. It is safe for concurrent use.
+type Ensemble struct {
+	Models  []*Markov
+	Weights []float64 // the weights of the models, they sum to 1
+}
+
+// NewEnsemble creates the mixture of the models, each with the weight of the same index. The
+// weights are normalized, e.g. 7 and 3 blend the models 70/30. The random numbers are drawn
+// from the first model, seeding it makes the ensemble reproducible.
+func NewEnsemble(models []*Markov, weights []float64) (*Ensemble, error) {
+	if len(models) == 0 {
+		return nil, fmt.Errorf("%w: an ensemble without models", ErrEmptyModel)
+	}
+	if len(weights) != len(models) {
+		return nil, fmt.Errorf("%w: %v weights for %v models", ErrInvalidWeight, len(weights), len(models))
+	}
+
+	total := 0.0
+	for _, w := range weights {
+		if w < 0 || math.IsInf(w, 0) || math.IsNaN(w) {
+			return nil, fmt.Errorf("%w %v", ErrInvalidWeight, w)
+		}
+		total = total + w
+	}
+	if total <= 0 {
+		return nil, fmt.Errorf("%w: the weights sum to %v", ErrInvalidWeight, total)
+	}
+
+	normalized := make([]float64, len(weights))
+	for i, w := range weights {
+		normalized[i] = w / total
+	}
+
+	return &Ensemble{Models: models, Weights: normalized}, nil
+}
+
+// Sentence creates a new sentence from the mixture of the models
+func (e *Ensemble) Sentence(minWords, maxWords int) string {
+	s, _ := e.Generate(GenOptions{MinWords: minWords, MaxTokens: maxWords})
+	return s
+}
+
+// Generate creates a new sentence from the mixture of the models. The sentence starts with a
+// start prefix of one of the models, chosen by their weights. Of the options, MinWords,
+// MaxTokens and Temperature apply. It returns ErrEmptyModel if no model has start prefixes.
+func (e *Ensemble) Generate(opts GenOptions) (string, error) {
+	maxTokens := opts.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = DefaultMaxTokens
+	}
+
+	sentence := e.start(opts)
+	if sentence == nil {
+		return "", ErrEmptyModel
+	}
+
+	for n := 0; ; n++ {
+		// stop if the token budget is used up, leaving room for the closing STOP word
+		if len(sentence)+1 >= maxTokens {
+			break
+		}
+
+		word, found := e.next(sentence, opts)
+		if !found {
+			break
+		}
+		sentence = append(sentence, word)
+		if word.Type == dictionary.STOP && n >= opts.MinWords {
+			break
+		}
+	}
+
+	first := e.Models[0]
+	if last := sentence[len(sentence)-1]; last.Type != dictionary.STOP {
+		sentence = append(sentence, Token{Word: first.endToken(), Type: dictionary.SENTENCE_END})
+	}
+	return first.detokenizer().Detokenize(sentence), nil
+}
+
+// start returns the start prefix of one of the models, chosen by their weights among the
+// models with start prefixes
+func (e *Ensemble) start(opts GenOptions) []Token {
+	weights := make([]float64, len(e.Models))
+	total := 0.0
+	for i, m := range e.Models {
+		m.mu.RLock()
+		if len(m.Start) > 0 {
+			weights[i] = e.Weights[i]
+			total = total + weights[i]
+		}
+		m.mu.RUnlock()
+	}
+	if total <= 0 {
+		return nil
+	}
+
+	m := e.Models[e.pick(weights, total)]
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var tokens []Token
+	for _, w := range m.randomStart(opts) {
+		tokens = append(tokens, Token{Word: m.Dict.Form(w.Word), Type: w.Type, Control: dictionary.IsControl(w.Word)})
+	}
+	return tokens
+}
+
+// next samples the word following the sentence from the mixture of the models, and false if
+// no model knows a word that may follow it
+func (e *Ensemble) next(sentence []Token, opts GenOptions) (Token, bool) {
+	var candidates []Token
+	var weights []float64
+	index := make(map[string]int)
+	total := 0.0
+
+	for i, m := range e.Models {
+		if e.Weights[i] <= 0 {
+			continue
+		}
+
+		m.mu.RLock()
+		suffixes, _ := m.ensembleSuffixes(sentence)
+		sum := 0.0
+		for _, w := range suffixes {
+			sum = sum + weight(w.Count, opts.Temperature)
+		}
+		for _, w := range suffixes {
+			word, _ := m.Dict.GetAt(w.Idx)
+			p := e.Weights[i] * weight(w.Count, opts.Temperature) / sum
+
+			// the models share the probability of the words they both know, a control token
+			// is not the word of a text that spells it
+			form := m.Dict.Form(word.Word)
+			control := dictionary.IsControl(word.Word)
+			key := form
+			if !control {
+				key = dictionary.Escape(form)
+			}
+			j, known := index[key]
+			if !known {
+				j = len(candidates)
+				index[key] = j
+				candidates = append(candidates, Token{Word: form, Type: word.Type, Control: control})
+				weights = append(weights, 0)
+			}
+			weights[j] = weights[j] + p
+			total = total + p
+		}
+		m.mu.RUnlock()
+	}
+
+	if len(candidates) == 0 {
+		return Token{}, false
+	}
+	return candidates[e.pick(weights, total)], true
+}
+
+// pick returns the index of a weight, drawn in proportion to it
+func (e *Ensemble) pick(weights []float64, total float64) int {
+	pos := e.Models[0].float64() * total
+	last := 0
+	for i, w := range weights {
+		if w <= 0 {
+			continue
+		}
+		if pos < w {
+			return i
+		}
+		pos = pos - w
+		last = i
+	}
+	return last
+}
+
+// ensembleSuffixes returns the suffixes the model knows for the last Depth words of the
+// sentence, without those the WordFilter rejects, and false if it knows none
+func (m *Markov) ensembleSuffixes(sentence []Token) ([]WordCount, bool) {
+	if len(sentence) < m.Depth {
+		return nil, false
+	}
+
+	prefix := make([]dictionary.Word, 0, m.Depth)
+	for _, t := range sentence[len(sentence)-m.Depth:] {
+		word, found := m.Dict.Get(m.entry(t))
+		if !found {
+			return nil, false
+		}
+		prefix = append(prefix, word)
+	}
+
+	allow := m.allowFunc(nil, GenOptions{})
+	all, found := m.suffixesFor(prefix, allow)
+	if !found || allow == nil {
+		return all, found
+	}
+
+	suffixes := make([]WordCount, 0, len(all))
+	for _, w := range all {
+		if allow(w.Idx) {
+			suffixes = append(suffixes, w)
+		}
+	}
+	return suffixes, len(suffixes) > 0
+}
@@ -0,0 +1,32 @@
+package garkov
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestNewEnsembleWeights(t *testing.T) {
+	a, b := New("a"), New("b")
+	tests := []struct {
+		weights []float64
+		err     error
+	}{
+		{[]float64{7, 3}, nil},
+		{[]float64{1, 0}, nil},
+		{[]float64{1}, ErrInvalidWeight},
+		{[]float64{1, -1}, ErrInvalidWeight},
+		{[]float64{1, math.Inf(1)}, ErrInvalidWeight},
+		{[]float64{1, math.NaN()}, ErrInvalidWeight},
+		{[]float64{0, 0}, ErrInvalidWeight},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewEnsemble([]*Markov{a, b}, tt.weights); !errors.Is(err, tt.err) {
+			t.Errorf("NewEnsemble with weights %v: got %v, want %v", tt.weights, err, tt.err)
+		}
+	}
+	if _, err := NewEnsemble(nil, nil); !errors.Is(err, ErrEmptyModel) {
+		t.Errorf("NewEnsemble without models: got %v, want %v", err, ErrEmptyModel)
+	}
+}
@@ -0,0 +1,52 @@
+package garkov
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrEmptyModel is returned when a model without start prefixes is asked to generate
+	ErrEmptyModel = errors.New("the model is empty")
+	// ErrUnknownPrefix is returned when the seed of a sentence is not found in the model
+	ErrUnknownPrefix = errors.New("unknown prefix")
+	// ErrCorruptModel is returned by Load for model files with invalid data
+	ErrCorruptModel = errors.New("corrupt model")
+	// ErrDepthMismatch is returned for prefixes whose length does not match the depth of
+	// the model
+	ErrDepthMismatch = errors.New("depth mismatch")
+	// ErrInvalidDepth is returned when a model whose depth is not within 1 and MaxDepth is
+	// trained
+	ErrInvalidDepth = errors.New("invalid depth")
+	// ErrShortText is returned when a text has too few tokens to fill a single prefix of the
+	// model. Its words are added to the dictionary, but it adds no chains.
+	ErrShortText = errors.New("text shorter than the depth")
+	// ErrIncompatibleVersion is returned by Load for model files of a format version that
+	// this version of the library can not read, usually written by a newer version
+	ErrIncompatibleVersion = errors.New("incompatible format version")
+	// ErrChecksum is returned by Load for model files whose data does not match their
+	// checksum. It is an ErrCorruptModel.
+	ErrChecksum = fmt.Errorf("%w: checksum mismatch", ErrCorruptModel)
+	// ErrNoFill is returned when no sentence of a model fits a template, see Markov.Fill
+	ErrNoFill = errors.New("no sentence fits the template")
+	// ErrNoEnding is returned when no sentence ends with a word GenOptions.LastWord accepts
+	ErrNoEnding = errors.New("no sentence ends with a matching word")
+	// ErrNoMeter is returned when no sentence has the syllables of GenOptions.Syllables
+	ErrNoMeter = errors.New("no sentence has the number of syllables")
+	// ErrInvalidOptions is returned when the generation options contradict each other, e.g.
+	// a MinChars above the CharLimit
+	ErrInvalidOptions = errors.New("invalid generation options")
+	// ErrInvalidWeight is returned by BuildWeighted and NewEnsemble for weights that are
+	// negative, infinite or NaN, and for the weights of an Ensemble that do not fit its models
+	ErrInvalidWeight = errors.New("invalid weight")
+	// ErrReadOnly is returned when a compiled model is trained or pruned
+	ErrReadOnly = errors.New("the chains are compiled and read-only")
+	// ErrMissingModel is returned by Registry.Lookup for an empty name if the registry does
+	// not hold a single model
+	ErrMissingModel = errors.New("missing model name")
+	// ErrUnknownModel is returned by Registry.Lookup for a name without a model
+	ErrUnknownModel = errors.New("unknown model")
+	// ErrSharedDictionary is returned when a model of a MultiModel is asked to change the
+	// dictionary it shares with the other models, see MultiModel.Compact
+	ErrSharedDictionary = errors.New("the dictionary is shared with other models")
+)
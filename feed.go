@@ -0,0 +1,84 @@
+package garkov
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/mickuehl/garkov/corpus"
+)
+
+// FeedBuilder updates a markov model with the titles and descriptions of the items of RSS or
+// Atom feeds. Items that were added before are skipped by their GUID, so a feed can be
+// polled repeatedly.
+type FeedBuilder struct {
+	Model *Markov
+
+	seen map[string]bool // the GUIDs of the items added to the model
+	mu   sync.Mutex
+}
+
+// NewFeedBuilder creates a FeedBuilder for the model m
+func NewFeedBuilder(m *Markov) *FeedBuilder {
+	return &FeedBuilder{
+		Model: m,
+		seen:  make(map[string]bool),
+	}
+}
+
+// Build reads a feed from r and updates the model with the items that were not added
+// before. The result is the number of new items.
+func (f *FeedBuilder) Build(r io.Reader) (int, error) {
+	items, err := corpus.Feed(r)
+	if err != nil {
+		return 0, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var texts []string
+	n := 0
+	for _, item := range items {
+		guid := item.GUID
+		if guid == "" {
+			guid = item.Title + "\n" + item.Description
+		}
+		if f.seen[guid] {
+			continue
+		}
+		f.seen[guid] = true
+		n = n + 1
+
+		// the title and the description are texts of their own, descriptions are HTML
+		texts = append(texts, item.Title, StripHTML(item.Description))
+	}
+
+	i := 0
+	err = f.Model.buildTexts(func() (string, error) {
+		if i == len(texts) {
+			return "", io.EOF
+		}
+		i = i + 1
+		return texts[i-1], nil
+	})
+
+	return n, err
+}
+
+// BuildURL downloads a feed with corpus.DefaultFetcher and updates the model with the
+// items that were not added before. The result is the number of new items.
+func (f *FeedBuilder) BuildURL(ctx context.Context, url string) (int, error) {
+	body, err := corpus.Open(ctx, url)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+
+	n, err := f.Build(body)
+	if err != nil {
+		return n, fmt.Errorf("%v: %w", url, err)
+	}
+	return n, nil
+}
@@ -0,0 +1,262 @@
+package garkov
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// fillAttempts is the number of words Fill tries in the slots of a template before it
+// gives up
+const fillAttempts int = 10000
+
+// slotMarker stands in for a slot of a template while it is tokenized, followed by the
+// index of the slot and slotMarkerEnd. A template that contains it gets a longer one.
+const (
+	slotMarker    string = "garkovslot"
+	slotMarkerEnd string = "x"
+)
+
+// slotPattern matches the slots of a template, e.g. {NOUN} or {*}
+var slotPattern = regexp.MustCompile(`\{([^{}\s]*)\}`)
+
+// templateToken is a word a filled template must contain, or a slot
+type templateToken struct {
+	word dictionary.Word // the word, unless it is a slot
+	slot bool
+	tag  string // the tag of the words that fill the slot, "" for any word
+}
+
+// filler searches the sentences that fit a template
+type filler struct {
+	m        *Markov
+	template []templateToken
+	allow    func(idx int) bool
+	attempts int // the words left to try
+}
+
+// Fill generates a sentence that follows a template, e.g. "The {NOUN} of {*}". A slot
+// {TAG} is filled by a word whose most frequent tag is TAG, see Tagger, and {*} by any
+// word. The sentence follows the chains of the model and begins with one of its start
+// prefixes. It ends after the template, with a STOP word if the template has none. Fill
+// returns ErrUnknownPrefix if the template has a word the model does not know, and
+// ErrNoFill if no sentence of the model fits the template.
+func (m *Markov) Fill(template string) (string, error) {
+	tokens, err := m.parseTemplate(template)
+	if err != nil {
+		return "", err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.Start) == 0 {
+		return "", ErrEmptyModel
+	}
+
+	// the sentence ends with the STOP word of the template, or the one of the model
+	endToken := Token{Word: m.endToken(), Type: dictionary.SENTENCE_END}
+	if last := tokens[len(tokens)-1]; last.Type == dictionary.STOP || (last.Type == 0 && dictionary.TokenType(last.Word) == dictionary.STOP) {
+		endToken = last
+		tokens = tokens[:len(tokens)-1]
+	}
+	if len(tokens) < m.Depth {
+		return "", fmt.Errorf("%w: a template of %v tokens in a model of depth %v", ErrShortText, len(tokens), m.Depth)
+	}
+
+	f := filler{m: m, template: make([]templateToken, len(tokens)), allow: m.allowFunc(nil, GenOptions{}), attempts: fillAttempts}
+	for i, t := range tokens {
+		if slot, ok := templateSlot(t); ok {
+			f.template[i] = slot
+			continue
+		}
+		word, found := m.Dict.Get(m.entry(t))
+		if !found {
+			return "", fmt.Errorf("%w '%v' in the template", ErrUnknownPrefix, t.Word)
+		}
+		f.template[i] = templateToken{word: word}
+	}
+
+	begin := time.Now()
+
+	// a sentence whose chain leads to the STOP word is preferred to one cut off after the
+	// template
+	var sentence []dictionary.Word
+	end, found := m.Dict.Get(m.entry(endToken))
+	if found {
+		f.template = append(f.template, templateToken{word: end})
+		sentence = f.start()
+		f.template = f.template[:len(f.template)-1]
+	} else {
+		end = dictionary.Word{Word: endToken.Word, Type: dictionary.STOP}
+	}
+	if sentence == nil {
+		f.attempts = fillAttempts
+		if sentence = f.start(); sentence == nil {
+			return "", ErrNoFill
+		}
+		sentence = append(sentence, end)
+	}
+
+	if m.Metrics != nil {
+		m.Metrics.Generated(m.Name, time.Since(begin))
+	}
+	return m.toString(sentence), nil
+}
+
+// parseTemplate tokenizes a template, its slots become tokens of the type -1 whose word is
+// the text between the braces
+func (m *Markov) parseTemplate(template string) ([]Token, error) {
+	marker := slotMarker
+	for strings.Contains(template, marker) {
+		marker = marker + slotMarkerEnd
+	}
+
+	var slots []string
+	text := slotPattern.ReplaceAllStringFunc(template, func(s string) string {
+		slots = append(slots, s[1:len(s)-1])
+		return " " + marker + strconv.Itoa(len(slots)-1) + slotMarkerEnd + " "
+	})
+
+	tokenizer, err := m.tokenizer()
+	if err != nil {
+		return nil, err
+	}
+	tokens := tokenizer.Tokenize(text)
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%w: an empty template", ErrShortText)
+	}
+
+	for i, t := range tokens {
+		if !strings.HasPrefix(t.Word, marker) || !strings.HasSuffix(t.Word, slotMarkerEnd) {
+			continue
+		}
+		n, err := strconv.Atoi(t.Word[len(marker) : len(t.Word)-len(slotMarkerEnd)])
+		if err != nil || n < 0 || n >= len(slots) {
+			continue
+		}
+		tokens[i] = Token{Word: slots[n], Type: -1}
+	}
+	return tokens, nil
+}
+
+// templateSlot returns the slot of a token parsed by parseTemplate, and false if the token
+// is a word
+func templateSlot(t Token) (templateToken, bool) {
+	if t.Type != -1 {
+		return templateToken{}, false
+	}
+	if t.Word == "*" {
+		return templateToken{slot: true}, true
+	}
+	return templateToken{slot: true, tag: t.Word}, true
+}
+
+// matches is true if the word may stand in the place of the template token
+func (f *filler) matches(t templateToken, w dictionary.Word) bool {
+	if !t.slot {
+		return w.Idx == t.word.Idx
+	}
+	// the dictionary types marks like ':' as words too
+	if w.Type != dictionary.WORD || strings.IndexFunc(w.Word, isAlnum) < 0 || (f.allow != nil && !f.allow(w.Idx)) {
+		return false
+	}
+	return t.tag == "" || f.m.Dict.Tag(w.Word) == t.tag
+}
+
+// start tries the start prefixes that fit the beginning of the template, drawn in
+// proportion to their counts, and returns the first sentence that fits all of it or nil
+func (f *filler) start() []dictionary.Word {
+	var candidates []int
+	var weights []float64
+	for i, prefix := range f.m.Start {
+		fits := true
+		for j, idx := range prefix {
+			word, _ := f.m.Dict.GetAt(idx)
+			if !f.matches(f.template[j], word) {
+				fits = false
+				break
+			}
+		}
+		if fits {
+			candidates = append(candidates, i)
+			weights = append(weights, f.m.startCount(i))
+		}
+	}
+
+	for _, i := range f.m.weightedOrder(weights) {
+		if sentence, ok := f.fill(f.m.prefixWords(f.m.Start[candidates[i]]), f.m.Depth); ok {
+			return sentence
+		}
+		if f.attempts <= 0 {
+			break
+		}
+	}
+	return nil
+}
+
+// fill appends the words of the template from its i-th token on to the sentence, each a
+// suffix of the words before it. The suffixes are tried in a random order weighted by
+// their counts, and the next one is tried if the rest of the template does not fit.
+func (f *filler) fill(sentence []dictionary.Word, i int) ([]dictionary.Word, bool) {
+	if i == len(f.template) {
+		return sentence, true
+	}
+
+	suffixes, found := f.m.suffixesFor(sentence[len(sentence)-f.m.Depth:], nil)
+	if !found {
+		return nil, false
+	}
+
+	var candidates []dictionary.Word
+	var weights []float64
+	for _, s := range suffixes {
+		word, _ := f.m.Dict.GetAt(s.Idx)
+		if f.matches(f.template[i], word) {
+			candidates = append(candidates, word)
+			weights = append(weights, s.Count)
+		}
+	}
+
+	for _, j := range f.m.weightedOrder(weights) {
+		if f.attempts <= 0 {
+			break
+		}
+		f.attempts = f.attempts - 1
+
+		if filled, ok := f.fill(append(sentence, candidates[j]), i+1); ok {
+			return filled, true
+		}
+	}
+	return nil, false
+}
+
+// isAlnum is true for letters and digits
+func isAlnum(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// weightedOrder returns the indices of the weights in a random order, in which an index
+// comes first in proportion to its weight
+func (m *Markov) weightedOrder(weights []float64) []int {
+	keys := make([]float64, len(weights))
+	order := make([]int, len(weights))
+	for i, w := range weights {
+		order[i] = i
+		keys[i] = math.Inf(1)
+		if w > 0 {
+			keys[i] = -math.Log(1-m.float64()) / w
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return keys[order[i]] < keys[order[j]]
+	})
+	return order
+}
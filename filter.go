@@ -0,0 +1,107 @@
+package garkov
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Filter transforms a text before it is tokenized, e.g. to remove markup
+type Filter func(text string) string
+
+// AddFilter appends filters to the input filters of the model. The Build methods apply the
+// filters, in the order they were added, to the complete text they read before it is split
+// into paragraphs. Feed applies them to each piece of text.
+func (m *Markov) AddFilter(filters ...Filter) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.filters = append(m.filters, filters...)
+}
+
+// filter applies the input filters of the model to a text
+func (m *Markov) filter(text string) string {
+	m.mu.RLock()
+	filters := m.filters
+	m.mu.RUnlock()
+
+	for _, f := range filters {
+		text = f(text)
+	}
+	return text
+}
+
+// hasFilters is true if the model has input filters
+func (m *Markov) hasFilters() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.filters) > 0
+}
+
+var (
+	htmlHidden  = regexp.MustCompile(`(?is)<script\b.*?</script\s*>|<style\b.*?</style\s*>|<head\b.*?</head\s*>|<noscript\b.*?</noscript\s*>|<template\b.*?</template\s*>|<!--.*?-->|<!\[CDATA\[.*?\]\]>`)
+	htmlBlock   = regexp.MustCompile(`(?i)</?(p|div|br|hr|h[1-6]|li|ul|ol|dl|dt|dd|tr|table|blockquote|pre|section|article|header|footer|nav|aside|title|figure|figcaption)\b[^>]*>`)
+	htmlTag     = regexp.MustCompile(`(?s)<[^>]*>`)
+	blankLines  = regexp.MustCompile(`\n[ \t]*(\n[ \t]*)+`)
+	spaceRun    = regexp.MustCompile(`[ \t\r\f\v]+`)
+	mdFence     = regexp.MustCompile("(?ms)^[ \t]*(```|~~~).*?(^[ \t]*(```|~~~)[ \t]*$|\\z)")
+	mdCode      = regexp.MustCompile("`[^`\n]*`")
+	mdImage     = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLink      = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)|\[([^\]]*)\]\[[^\]]*\]`)
+	mdReference = regexp.MustCompile(`(?m)^[ \t]*\[[^\]]+\]:.*$`)
+	mdTable     = regexp.MustCompile(`(?m)^[ \t]*\|.*$`)
+	mdRule      = regexp.MustCompile(`(?m)^[ \t]*([-*_][ \t]*){3,}$`)
+	mdHeading   = regexp.MustCompile(`(?m)^[ \t]*#{1,6}[ \t]+(.*?)[ \t#]*$`)
+	mdUnderline = regexp.MustCompile(`(?m)^[ \t]*(=+|-+)[ \t]*$`)
+	mdQuote     = regexp.MustCompile(`(?m)^[ \t]*(>[ \t]?)+`)
+	mdList      = regexp.MustCompile(`(?m)^[ \t]*([-*+]|\d+[.)])[ \t]+(\[[ xX]\][ \t]+)?`)
+	mdEmphasis  = regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*|\*(\S(?:.*?\S)?)\*|~~(\S(?:.*?\S)?)~~`)
+	mdUnder     = regexp.MustCompile(`\b__(\S(?:.*?\S)?)__\b|\b_(\S(?:.*?\S)?)_\b`)
+)
+
+// StripHTML removes tags, comments, scripts and styles from a HTML document and decodes
+// its entities. Block level elements become line breaks.
+func StripHTML(text string) string {
+	text = htmlHidden.ReplaceAllString(text, " ")
+	text = htmlBlock.ReplaceAllString(text, "\n\n")
+	text = htmlTag.ReplaceAllString(text, " ")
+	text = html.UnescapeString(text)
+
+	return tidy(text)
+}
+
+// StripMarkdown removes the Markdown syntax from a text. Code blocks, inline code, images,
+// tables and link targets are removed, headings, quotes and list items are kept as text.
+func StripMarkdown(text string) string {
+	text = mdFence.ReplaceAllString(text, "\n")
+	text = mdCode.ReplaceAllString(text, "")
+	text = mdImage.ReplaceAllString(text, "")
+	text = mdLink.ReplaceAllString(text, "$1$2")
+	text = mdReference.ReplaceAllString(text, "")
+	text = mdTable.ReplaceAllString(text, "")
+	text = mdRule.ReplaceAllString(text, "")
+	text = mdUnderline.ReplaceAllString(text, "")
+
+	// headings are sentences of their own
+	text = mdHeading.ReplaceAllString(text, "\n\n$1\n\n")
+	text = mdQuote.ReplaceAllString(text, "")
+	text = mdList.ReplaceAllString(text, "")
+	text = mdEmphasis.ReplaceAllString(text, "${1}${2}${3}")
+	text = mdUnder.ReplaceAllString(text, "${1}${2}")
+
+	return tidy(text)
+}
+
+// tidy collapses runs of spaces and blank lines
+func tidy(text string) string {
+	text = spaceRun.ReplaceAllString(text, " ")
+	text = blankLines.ReplaceAllString(text, "\n\n")
+
+	lines := strings.Split(text, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,482 @@
+package garkov
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// DefaultMaxTokens limits the length of a sentence if GenOptions.MaxTokens is not set
+const DefaultMaxTokens int = 100
+
+// GenOptions controls the generation of sentences
+type GenOptions struct {
+	MinWords    int     // number of words before the sentence may end
+	MaxTokens   int     // maximum number of tokens in the sentence, 0 selects DefaultMaxTokens
+	Temperature float64 // < 1 favours frequent suffixes, > 1 flattens the distribution. 0 is the same as 1.
+	TopK        int     // sample from the k most frequent suffixes only, 0 samples from all
+	TopP        float64 // sample from the most frequent suffixes that cover this share of the weight, 0 samples from all
+	Novel       bool    // re-sample words that would repeat more than Markov.Novelty consecutive tokens of the training text
+	CharLimit   int     // maximum number of characters of the text, 0 is unlimited
+	MinChars    int     // minimum number of characters of the text, 0 is any. With CharLimit, e.g. 40 and 80 for a headline.
+	TitleCase   bool    // capitalize the text like a headline and drop its final period, see TitleCase
+	StartWith   string  // a word or phrase the sentence continues, see SentenceFrom
+
+	StopwordWeight     float64    // multiplies the weight of stopword suffixes, e.g. 0.2, 0 leaves it unchanged. See SetStopwords.
+	SkipStopwordStarts bool       // do not start sentences with a stopword, unless all start prefixes do
+	WordFilter         WordFilter // words it rejects are not generated, in addition to those of Markov.WordFilter. See Vocabulary.
+	TagWeight          TagWeight  // multiplies the weight of suffixes depending on their tags, nil leaves it unchanged. See Markov.Tagger.
+	LastWord           WordFilter // the last word of the sentence must pass it, e.g. WordSuffix("ight") for a rhyme. Works best with Markov.Backward.
+	Syllables          int        // the exact number of syllables of the sentence, 0 for any. See Markov.SyllableCounter and Verse.
+}
+
+// Sentence creates a new sentence based on the markov-chain
+func (m *Markov) Sentence(minWords, maxWords int) string {
+	return m.SentenceWithOptions(GenOptions{MinWords: minWords, MaxTokens: maxWords})
+}
+
+// SentenceWithOptions creates a new sentence based on the markov-chain
+func (m *Markov) SentenceWithOptions(opts GenOptions) string {
+	s, _ := m.Generate(opts)
+	return s
+}
+
+// Generate creates a new sentence like SentenceWithOptions. It returns ErrEmptyModel if the
+// model has no start prefixes, or none that generation may use, and ErrUnknownPrefix if
+// opts.StartWith can not be found in the model. With opts.LastWord, it returns ErrNoEnding
+// if no sentence ends with a word the filter accepts, and with opts.Syllables ErrNoMeter if
+// no sentence has as many syllables. Options that no sentence can satisfy, like a MinChars
+// above the CharLimit, return ErrInvalidOptions.
+func (m *Markov) Generate(opts GenOptions) (string, error) {
+	if err := checkLimits(opts); err != nil {
+		return "", err
+	}
+
+	var seed []Token
+	if opts.StartWith != "" {
+		tokens, err := m.tokenize(opts.StartWith)
+		if err != nil {
+			return "", err
+		}
+		seed = tokens
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.Start) == 0 {
+		return "", ErrEmptyModel
+	}
+	if opts.Syllables > 0 {
+		return m.generateMeter(seed, opts)
+	}
+	if opts.LastWord != nil {
+		return m.generateEnding(seed, opts)
+	}
+
+	var err error
+	s := m.fit(func() []dictionary.Word {
+		if opts.StartWith != "" {
+			start := m.seedStart(seed)
+			if start == nil {
+				err = fmt.Errorf("%w '%v'", ErrUnknownPrefix, opts.StartWith)
+			}
+			return start
+		}
+
+		start := m.randomStart(opts)
+		if start == nil {
+			err = fmt.Errorf("%w: no start prefix passes the word filter", ErrEmptyModel)
+		}
+		return start
+	}, opts)
+	return s, err
+}
+
+// randomStart returns one of the start prefixes, drawn in proportion to the number of
+// sentences it began, or nil if the model is empty. Prefixes with words the word filters reject, or unknown words, are skipped. So are prefixes starting
+// with a stopword if opts.SkipStopwordStarts is set, unless there are no others.
+func (m *Markov) randomStart(opts GenOptions) []dictionary.Word {
+	if len(m.Start) == 0 {
+		return nil
+	}
+
+	skipStopwords := opts.SkipStopwordStarts && len(m.stopwords) > 0
+	filter := m.wordFilter(opts)
+
+	// select a first prefix to start with
+	if filter == nil && !m.Dict.Exists(dictionary.UNKNOWN_TOKEN) && !skipStopwords {
+		return m.prefixWords(m.drawStart())
+	}
+
+	allowedStart := func(prefix []int) bool {
+		return m.allowedPrefix(prefix, filter) && !(skipStopwords && m.isStopword(m.Dict.WordAt(prefix[0])))
+	}
+
+	// a few random tries before looking for the allowed prefixes
+	for i := 0; i < 10; i++ {
+		prefix := m.drawStart()
+		if allowedStart(prefix) {
+			return m.prefixWords(prefix)
+		}
+	}
+
+	var allowed []int
+	for i, prefix := range m.Start {
+		if allowedStart(prefix) {
+			allowed = append(allowed, i)
+		}
+	}
+	if len(allowed) == 0 {
+		if skipStopwords {
+			opts.SkipStopwordStarts = false
+			return m.randomStart(opts)
+		}
+		return nil
+	}
+	return m.prefixWords(m.drawStartOf(allowed))
+}
+
+// allowedPrefix is true if the prefix has no unknown words and the filter, if any, accepts
+// all of its words
+func (m *Markov) allowedPrefix(prefix []int, filter WordFilter) bool {
+	for _, idx := range prefix {
+		w := m.Dict.WordAt(idx)
+		if w == dictionary.UNKNOWN_TOKEN || (filter != nil && !filter(w)) {
+			return false
+		}
+	}
+	return true
+}
+
+// wordFilter returns the filter of the model combined with the one of the options, or nil
+// if there is neither
+func (m *Markov) wordFilter(opts GenOptions) WordFilter {
+	if opts.WordFilter == nil {
+		return m.WordFilter
+	}
+	if m.WordFilter == nil {
+		return opts.WordFilter
+	}
+	return func(w string) bool {
+		return m.WordFilter(w) && opts.WordFilter(w)
+	}
+}
+
+// generate continues a sentence, that has at least Depth words, until it ends
+func (m *Markov) generate(sentence []dictionary.Word, opts GenOptions) []dictionary.Word {
+	n := 0
+	for {
+		word, done := m.nextWord(sentence, n, opts)
+		if word.Word != "" {
+			sentence = append(sentence, word)
+		}
+		if done {
+			return sentence
+		}
+		n = n + 1
+	}
+}
+
+// nextWord returns the word that continues a sentence after n generated words, and true if
+// the sentence is complete. The word is empty if a complete sentence needs no more words.
+// Quotes and parentheses opened in the sentence are closed before it ends.
+func (m *Markov) nextWord(sentence []dictionary.Word, n int, opts GenOptions) (dictionary.Word, bool) {
+	maxTokens := opts.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = DefaultMaxTokens
+	}
+	open := openBrackets(sentence)
+
+	// stop if the token budget is used up, leaving room for the closing quotes, parentheses
+	// and STOP word
+	if len(sentence)+len(open)+1 >= maxTokens {
+		if len(open) > 0 {
+			return m.bracketWord(open[len(open)-1]), false
+		}
+		return m.endWord(sentence), true
+	}
+
+	// get the next word, until we get a STOP word. While quotes or parentheses are open, the
+	// sentence does not end.
+	prefix := sentence[len(sentence)-m.Depth:]
+	allow := m.allowFunc(sentence, opts)
+	if len(open) > 0 {
+		allow = m.allowBalanced(allow, open)
+	}
+
+	// close to the token limit, continue with a word after which the sentence can end
+	// rather than cut it off at the limit
+	if len(open) == 0 && n >= opts.MinWords && len(sentence)+1+endingTokens >= maxTokens {
+		if suffix := m.suffixFor(prefix, opts, m.allowEnding(allow, prefix)); suffix.Word != "" && !closers[suffix.Word] {
+			return suffix, suffix.Type == dictionary.STOP
+		}
+	}
+
+	suffix := m.suffixFor(prefix, opts, allow)
+	if len(open) == 0 && closers[suffix.Word] {
+		// nothing to close, sample again without the closing tokens
+		suffix = m.suffixFor(prefix, opts, m.allowBalanced(allow, nil))
+	}
+	if suffix.Word == "" {
+		// dead end, close the quotes and parentheses and then the sentence
+		if len(open) > 0 {
+			return m.bracketWord(open[len(open)-1]), false
+		}
+		return m.endWord(sentence), true
+	}
+
+	return suffix, suffix.Type == dictionary.STOP && n >= opts.MinWords
+}
+
+// toString joins the words of a sentence, in their most frequent surface form if the
+// model folds their case or normalizes them
+func (m *Markov) toString(sentence []dictionary.Word) string {
+	tokens := wordsToTokens(sentence)
+	for i := range tokens {
+		tokens[i].Word = m.Dict.Form(tokens[i].Word)
+	}
+
+	return m.detokenizer().Detokenize(tokens)
+}
+
+// endWord returns the STOP word that terminates a sentence, or an empty word if the
+// sentence already ends with one
+func (m *Markov) endWord(sentence []dictionary.Word) dictionary.Word {
+	if len(sentence) > 0 && sentence[len(sentence)-1].Type == dictionary.STOP {
+		return dictionary.Word{}
+	}
+
+	end, found := m.Dict.Get(m.endToken())
+	if !found {
+		end = dictionary.Word{Word: m.endToken(), Type: dictionary.SENTENCE_END}
+	}
+	return end
+}
+
+// SuffixFor returns a word that succeedes a given prefix. The suffix is
+// sampled randomly, weighted by the number of times it followed the prefix.
+func (m *Markov) SuffixFor(prefix []dictionary.Word) dictionary.Word {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.suffixFor(prefix, GenOptions{}, m.allowFunc(prefix, GenOptions{}))
+}
+
+// suffixFor samples a suffix of the prefix. Only the suffixes for which allow is true are
+// sampled, a nil allow accepts all suffixes.
+func (m *Markov) suffixFor(prefix []dictionary.Word, opts GenOptions, allow func(idx int) bool) dictionary.Word {
+
+	k := m.Smoothing.K
+	temperature := opts.Temperature
+
+	// compiled chains draw a suffix weighted by its count alone without copying the chain
+	if c, ok := m.Chain.(*Compiled); ok && allow == nil && k <= 0 && (temperature <= 0 || temperature == 1) &&
+		opts.StopwordWeight <= 0 && opts.TagWeight == nil && opts.TopK <= 0 && (opts.TopP <= 0 || opts.TopP >= 1) {
+		return m.sampleCompiled(c, prefix)
+	}
+
+	// lookup the word chain
+	all, found := m.suffixesFor(prefix, allow)
+	if !found {
+		if k > 0 {
+			return m.unseenWord(nil, allow)
+		}
+		return dictionary.Word{}
+	}
+
+	suffixes := all
+	if allow != nil {
+		suffixes = make([]WordCount, 0, len(all))
+		for _, w := range all {
+			if allow(w.Idx) {
+				suffixes = append(suffixes, w)
+			}
+		}
+	}
+
+	var prev, prevTag string
+	if opts.TagWeight != nil && len(prefix) > 0 {
+		prev = prefix[len(prefix)-1].Word
+		prevTag = m.Dict.Tag(prev)
+	}
+
+	weights := make([]float64, len(suffixes))
+	total := 0.0
+	for i, w := range suffixes {
+		weights[i] = weight(w.Count+k, temperature)
+		if opts.StopwordWeight > 0 && m.isStopword(m.Dict.WordAt(w.Idx)) {
+			weights[i] = weights[i] * opts.StopwordWeight
+		}
+		if opts.TagWeight != nil {
+			if f := opts.TagWeight(prev, prevTag, m.Dict.Tag(m.Dict.WordAt(w.Idx))); f > 0 {
+				weights[i] = weights[i] * f
+			}
+		}
+		total = total + weights[i]
+	}
+
+	// restrict the sampling to the most frequent suffixes
+	truncated := opts.TopK > 0 || (opts.TopP > 0 && opts.TopP < 1)
+	if truncated {
+		suffixes, weights = mostFrequent(suffixes, weights, opts.TopK, opts.TopP)
+		total = 0.0
+		for _, w := range weights {
+			total = total + w
+		}
+	}
+
+	// with smoothing, the words of the dictionary that never followed the prefix share the rest
+	unseen := 0.0
+	if k > 0 && !truncated {
+		unseen = float64(m.Dict.Len()-len(all)) * weight(k, temperature)
+	}
+
+	// pick a position within the accumulated weights and find the suffix covering it
+	pos := m.float64() * (total + unseen)
+	if pos >= total {
+		return m.unseenWord(all, allow)
+	}
+
+	idx := suffixes[len(suffixes)-1].Idx
+	for i, w := range suffixes {
+		if pos < weights[i] {
+			idx = w.Idx
+			break
+		}
+		pos = pos - weights[i]
+	}
+
+	word, _ := m.Dict.GetAt(idx)
+	return word
+}
+
+// suffixesFor returns the suffixes of the chain of a prefix, ordered by their word index.
+// With backoff enabled, shorter prefixes are tried if there are no suffixes for the complete
+// prefix, or none for which allow is true.
+func (m *Markov) suffixesFor(prefix []dictionary.Word, allow func(idx int) bool) ([]WordCount, bool) {
+	suffixes, found := findSuffixes(m.Chain, prefix)
+	if found && hasAllowed(suffixes, allow) {
+		return suffixes, true
+	}
+
+	if m.Backoff {
+		for i := 1; i < len(prefix); i++ {
+			suffixes, found = findSuffixes(m.Chain, prefix[i:])
+			if found && hasAllowed(suffixes, allow) {
+				return suffixes, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// hasAllowed is true if there is a suffix for which allow is true
+func hasAllowed(suffixes []WordCount, allow func(idx int) bool) bool {
+	if allow == nil {
+		return len(suffixes) > 0
+	}
+
+	for _, w := range suffixes {
+		if allow(w.Idx) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowFunc returns the function that decides which suffixes may continue a sentence, or
+// nil if all may
+func (m *Markov) allowFunc(sentence []dictionary.Word, opts GenOptions) func(idx int) bool {
+	filter := m.wordFilter(opts)
+
+	// the words replaced by UNKNOWN_TOKEN are never generated
+	if unknown, found := m.Dict.Get(dictionary.UNKNOWN_TOKEN); found {
+		next := filter
+		filter = func(w string) bool {
+			return w != unknown.Word && (next == nil || next(w))
+		}
+	}
+
+	if !opts.Novel || m.Novelty <= m.Depth || len(sentence) < m.Novelty || len(m.ngrams) == 0 {
+		if filter == nil {
+			return nil
+		}
+		return func(idx int) bool {
+			return filter(m.Dict.WordAt(idx))
+		}
+	}
+
+	tail := wordsToIndexArray(sentence[len(sentence)-m.Novelty:])
+	return func(idx int) bool {
+		if filter != nil && !filter(m.Dict.WordAt(idx)) {
+			return false
+		}
+		return m.ngrams[ngramHash(append(tail, idx))] <= 0
+	}
+}
+
+// mostFrequent returns the k suffixes with the highest weights, and of those the ones that
+// cover the share p of the total weight. Suffixes of equal weight keep their order.
+func mostFrequent(suffixes []WordCount, weights []float64, k int, p float64) ([]WordCount, []float64) {
+	order := make([]int, len(suffixes))
+	total := 0.0
+	for i := range order {
+		order[i] = i
+		total = total + weights[i]
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return weights[order[i]] > weights[order[j]]
+	})
+
+	if k > 0 && k < len(order) {
+		order = order[:k]
+	}
+
+	if p > 0 && p < 1 {
+		sum := 0.0
+		for i, idx := range order {
+			sum = sum + weights[idx]
+			if sum >= p*total {
+				order = order[:i+1]
+				break
+			}
+		}
+	}
+
+	topSuffixes := make([]WordCount, len(order))
+	topWeights := make([]float64, len(order))
+	for i, idx := range order {
+		topSuffixes[i] = suffixes[idx]
+		topWeights[i] = weights[idx]
+	}
+
+	return topSuffixes, topWeights
+}
+
+// weight scales a suffix count by the temperature
+func weight(count float64, temperature float64) float64 {
+	if temperature <= 0 || temperature == 1 {
+		return count
+	}
+	return math.Pow(count, 1/temperature)
+}
+
+// intn returns a random number in [0,n)
+func (m *Markov) intn(n int) int {
+	m.rmu.Lock()
+	defer m.rmu.Unlock()
+
+	return m.Random.Intn(n)
+}
+
+// float64 returns a random number in [0.0,1.0)
+func (m *Markov) float64() float64 {
+	m.rmu.Lock()
+	defer m.rmu.Unlock()
+
+	return m.Random.Float64()
+}
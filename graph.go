@@ -0,0 +1,145 @@
+package garkov
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GraphFormat selects the format of ExportGraph
+type GraphFormat int
+
+const (
+	// GraphDOT writes the graph in the Graphviz DOT language
+	GraphDOT GraphFormat = iota
+	// GraphJSON writes the graph as JSON lists of nodes and edges, e.g. for d3 or Gephi
+	GraphJSON
+)
+
+// GraphNode is a state of the chain, a prefix of Depth words
+type GraphNode struct {
+	ID    int    `json:"id"`
+	Label string `json:"label"` // the words of the prefix
+}
+
+// GraphEdge is a transition from one prefix to the next, i.e. the prefix shifted by the suffix
+type GraphEdge struct {
+	Source int     `json:"source"`
+	Target int     `json:"target"`
+	Word   string  `json:"word"`   // the suffix
+	Weight float64 `json:"weight"` // the count of the suffix
+}
+
+// Graph is the transition graph of a model
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// ExportGraph writes the transition graph of the model to w. If maxEdges > 0, only the
+// maxEdges most frequent transitions and the nodes they connect are written. The lower
+// order chains of backoff are left out.
+func (m *Markov) ExportGraph(w io.Writer, format GraphFormat, maxEdges int) error {
+	g := m.Graph(maxEdges)
+
+	switch format {
+	case GraphDOT:
+		return writeDOT(w, m.Name, g)
+	case GraphJSON:
+		return json.NewEncoder(w).Encode(g)
+	}
+
+	return fmt.Errorf("unknown graph format %v", format)
+}
+
+// Graph returns the transition graph of the model, limited to the maxEdges most frequent
+// transitions if maxEdges > 0
+func (m *Markov) Graph(maxEdges int) Graph {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	type edge struct {
+		source, target []int
+		word           string
+		weight         float64
+	}
+
+	var edges []edge
+	m.Chain.Range(func(prefix []int, suffixes []WordCount) bool {
+		if len(prefix) != m.Depth {
+			return true
+		}
+
+		for _, suffix := range suffixes {
+			target := make([]int, 0, m.Depth)
+			target = append(target, prefix[1:]...)
+			target = append(target, suffix.Idx)
+			edges = append(edges, edge{source: prefix, target: target, word: m.Dict.WordAt(suffix.Idx), weight: suffix.Count})
+		}
+		return true
+	})
+
+	// the most frequent first, the order of the storage is random
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].weight != edges[j].weight {
+			return edges[i].weight > edges[j].weight
+		}
+		ki, kj := prefixKey(edges[i].source), prefixKey(edges[j].source)
+		if ki != kj {
+			return ki < kj
+		}
+		return edges[i].word < edges[j].word
+	})
+	if maxEdges > 0 && maxEdges < len(edges) {
+		edges = edges[:maxEdges]
+	}
+
+	var g Graph
+	ids := make(map[string]int)
+	node := func(prefix []int) int {
+		key := prefixKey(prefix)
+		if id, found := ids[key]; found {
+			return id
+		}
+
+		id := len(g.Nodes)
+		ids[key] = id
+		g.Nodes = append(g.Nodes, GraphNode{ID: id, Label: strings.Join(indexToWords(prefix, m.Dict), " ")})
+		return id
+	}
+
+	for _, e := range edges {
+		g.Edges = append(g.Edges, GraphEdge{Source: node(e.source), Target: node(e.target), Word: e.word, Weight: e.weight})
+	}
+
+	return g
+}
+
+// writeDOT writes a graph in the DOT language
+func writeDOT(w io.Writer, name string, g Graph) error {
+	b := bufio.NewWriter(w)
+
+	fmt.Fprintf(b, "digraph %v {\n", dotQuote(name))
+	for _, n := range g.Nodes {
+		fmt.Fprintf(b, "\tn%v [label=%v];\n", n.ID, dotQuote(n.Label))
+	}
+	for _, e := range g.Edges {
+		// dot only accepts integer weights
+		weight := math.Max(1, math.Round(e.Weight))
+		fmt.Fprintf(b, "\tn%v -> n%v [label=%v, weight=%v];\n", e.Source, e.Target, dotQuote(e.Word), weight)
+	}
+	fmt.Fprintf(b, "}\n")
+
+	return b.Flush()
+}
+
+// dotQuote quotes an ID of the DOT language. Line breaks, e.g. the end token of a character
+// level model, are escaped like in Go.
+func dotQuote(s string) string {
+	return strconv.Quote(s)
+}
@@ -0,0 +1,680 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: garkov.proto
+
+package grpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GenerateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Model         string                 `protobuf:"bytes,1,opt,name=model,proto3" json:"model,omitempty"`                            // the name of the model, can be empty if the server has a single model
+	Seed          string                 `protobuf:"bytes,2,opt,name=seed,proto3" json:"seed,omitempty"`                              // a word or phrase the sentences continue
+	Temperature   float64                `protobuf:"fixed64,3,opt,name=temperature,proto3" json:"temperature,omitempty"`              // < 1 favours frequent suffixes, > 1 flattens the distribution
+	MinWords      int32                  `protobuf:"varint,4,opt,name=min_words,json=minWords,proto3" json:"min_words,omitempty"`     // number of words before a sentence may end
+	MaxTokens     int32                  `protobuf:"varint,5,opt,name=max_tokens,json=maxTokens,proto3" json:"max_tokens,omitempty"`  // maximum number of tokens of a sentence
+	Count         int32                  `protobuf:"varint,6,opt,name=count,proto3" json:"count,omitempty"`                           // number of sentences, 0 is the same as 1
+	TopK          int32                  `protobuf:"varint,7,opt,name=top_k,json=topK,proto3" json:"top_k,omitempty"`                 // sample from the k most frequent suffixes only
+	TopP          float64                `protobuf:"fixed64,8,opt,name=top_p,json=topP,proto3" json:"top_p,omitempty"`                // sample from the most frequent suffixes that cover this share of the weight
+	Novel         bool                   `protobuf:"varint,9,opt,name=novel,proto3" json:"novel,omitempty"`                           // avoid repeating runs of the training text
+	CharLimit     int32                  `protobuf:"varint,10,opt,name=char_limit,json=charLimit,proto3" json:"char_limit,omitempty"` // maximum number of characters of a sentence
+	MinChars      int32                  `protobuf:"varint,11,opt,name=min_chars,json=minChars,proto3" json:"min_chars,omitempty"`    // minimum number of characters of a sentence
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenerateRequest) Reset() {
+	*x = GenerateRequest{}
+	mi := &file_garkov_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateRequest) ProtoMessage() {}
+
+func (x *GenerateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_garkov_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateRequest.ProtoReflect.Descriptor instead.
+func (*GenerateRequest) Descriptor() ([]byte, []int) {
+	return file_garkov_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GenerateRequest) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *GenerateRequest) GetSeed() string {
+	if x != nil {
+		return x.Seed
+	}
+	return ""
+}
+
+func (x *GenerateRequest) GetTemperature() float64 {
+	if x != nil {
+		return x.Temperature
+	}
+	return 0
+}
+
+func (x *GenerateRequest) GetMinWords() int32 {
+	if x != nil {
+		return x.MinWords
+	}
+	return 0
+}
+
+func (x *GenerateRequest) GetMaxTokens() int32 {
+	if x != nil {
+		return x.MaxTokens
+	}
+	return 0
+}
+
+func (x *GenerateRequest) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *GenerateRequest) GetTopK() int32 {
+	if x != nil {
+		return x.TopK
+	}
+	return 0
+}
+
+func (x *GenerateRequest) GetTopP() float64 {
+	if x != nil {
+		return x.TopP
+	}
+	return 0
+}
+
+func (x *GenerateRequest) GetNovel() bool {
+	if x != nil {
+		return x.Novel
+	}
+	return false
+}
+
+func (x *GenerateRequest) GetCharLimit() int32 {
+	if x != nil {
+		return x.CharLimit
+	}
+	return 0
+}
+
+func (x *GenerateRequest) GetMinChars() int32 {
+	if x != nil {
+		return x.MinChars
+	}
+	return 0
+}
+
+type GenerateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sentences     []string               `protobuf:"bytes,1,rep,name=sentences,proto3" json:"sentences,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenerateResponse) Reset() {
+	*x = GenerateResponse{}
+	mi := &file_garkov_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateResponse) ProtoMessage() {}
+
+func (x *GenerateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_garkov_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateResponse.ProtoReflect.Descriptor instead.
+func (*GenerateResponse) Descriptor() ([]byte, []int) {
+	return file_garkov_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GenerateResponse) GetSentences() []string {
+	if x != nil {
+		return x.Sentences
+	}
+	return nil
+}
+
+type TrainRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Model         string                 `protobuf:"bytes,1,opt,name=model,proto3" json:"model,omitempty"`
+	Text          string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrainRequest) Reset() {
+	*x = TrainRequest{}
+	mi := &file_garkov_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrainRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrainRequest) ProtoMessage() {}
+
+func (x *TrainRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_garkov_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrainRequest.ProtoReflect.Descriptor instead.
+func (*TrainRequest) Descriptor() ([]byte, []int) {
+	return file_garkov_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *TrainRequest) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *TrainRequest) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+type TrainResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stats         *Stats                 `protobuf:"bytes,1,opt,name=stats,proto3" json:"stats,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrainResponse) Reset() {
+	*x = TrainResponse{}
+	mi := &file_garkov_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrainResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrainResponse) ProtoMessage() {}
+
+func (x *TrainResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_garkov_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrainResponse.ProtoReflect.Descriptor instead.
+func (*TrainResponse) Descriptor() ([]byte, []int) {
+	return file_garkov_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *TrainResponse) GetStats() *Stats {
+	if x != nil {
+		return x.Stats
+	}
+	return nil
+}
+
+type ScoreRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Model         string                 `protobuf:"bytes,1,opt,name=model,proto3" json:"model,omitempty"`
+	Text          string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScoreRequest) Reset() {
+	*x = ScoreRequest{}
+	mi := &file_garkov_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScoreRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScoreRequest) ProtoMessage() {}
+
+func (x *ScoreRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_garkov_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScoreRequest.ProtoReflect.Descriptor instead.
+func (*ScoreRequest) Descriptor() ([]byte, []int) {
+	return file_garkov_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ScoreRequest) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *ScoreRequest) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+type ScoreResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LogLikelihood float64                `protobuf:"fixed64,1,opt,name=log_likelihood,json=logLikelihood,proto3" json:"log_likelihood,omitempty"`
+	Perplexity    float64                `protobuf:"fixed64,2,opt,name=perplexity,proto3" json:"perplexity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScoreResponse) Reset() {
+	*x = ScoreResponse{}
+	mi := &file_garkov_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScoreResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScoreResponse) ProtoMessage() {}
+
+func (x *ScoreResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_garkov_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScoreResponse.ProtoReflect.Descriptor instead.
+func (*ScoreResponse) Descriptor() ([]byte, []int) {
+	return file_garkov_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ScoreResponse) GetLogLikelihood() float64 {
+	if x != nil {
+		return x.LogLikelihood
+	}
+	return 0
+}
+
+func (x *ScoreResponse) GetPerplexity() float64 {
+	if x != nil {
+		return x.Perplexity
+	}
+	return 0
+}
+
+type StatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Model         string                 `protobuf:"bytes,1,opt,name=model,proto3" json:"model,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatsRequest) Reset() {
+	*x = StatsRequest{}
+	mi := &file_garkov_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsRequest) ProtoMessage() {}
+
+func (x *StatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_garkov_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsRequest.ProtoReflect.Descriptor instead.
+func (*StatsRequest) Descriptor() ([]byte, []int) {
+	return file_garkov_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *StatsRequest) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+type StatsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stats         *Stats                 `protobuf:"bytes,1,opt,name=stats,proto3" json:"stats,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatsResponse) Reset() {
+	*x = StatsResponse{}
+	mi := &file_garkov_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsResponse) ProtoMessage() {}
+
+func (x *StatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_garkov_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsResponse.ProtoReflect.Descriptor instead.
+func (*StatsResponse) Descriptor() ([]byte, []int) {
+	return file_garkov_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *StatsResponse) GetStats() *Stats {
+	if x != nil {
+		return x.Stats
+	}
+	return nil
+}
+
+type Stats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Depth         int32                  `protobuf:"varint,2,opt,name=depth,proto3" json:"depth,omitempty"`
+	Words         int64                  `protobuf:"varint,3,opt,name=words,proto3" json:"words,omitempty"`
+	Chains        int64                  `protobuf:"varint,4,opt,name=chains,proto3" json:"chains,omitempty"`
+	Transitions   float64                `protobuf:"fixed64,5,opt,name=transitions,proto3" json:"transitions,omitempty"`
+	Starts        int64                  `protobuf:"varint,6,opt,name=starts,proto3" json:"starts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Stats) Reset() {
+	*x = Stats{}
+	mi := &file_garkov_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Stats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Stats) ProtoMessage() {}
+
+func (x *Stats) ProtoReflect() protoreflect.Message {
+	mi := &file_garkov_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Stats.ProtoReflect.Descriptor instead.
+func (*Stats) Descriptor() ([]byte, []int) {
+	return file_garkov_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Stats) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Stats) GetDepth() int32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+func (x *Stats) GetWords() int64 {
+	if x != nil {
+		return x.Words
+	}
+	return 0
+}
+
+func (x *Stats) GetChains() int64 {
+	if x != nil {
+		return x.Chains
+	}
+	return 0
+}
+
+func (x *Stats) GetTransitions() float64 {
+	if x != nil {
+		return x.Transitions
+	}
+	return 0
+}
+
+func (x *Stats) GetStarts() int64 {
+	if x != nil {
+		return x.Starts
+	}
+	return 0
+}
+
+var File_garkov_proto protoreflect.FileDescriptor
+
+const file_garkov_proto_rawDesc = "" +
+	"\n" +
+	"\fgarkov.proto\x12\x06garkov\"\xab\x02\n" +
+	"\x0fGenerateRequest\x12\x14\n" +
+	"\x05model\x18\x01 \x01(\tR\x05model\x12\x12\n" +
+	"\x04seed\x18\x02 \x01(\tR\x04seed\x12 \n" +
+	"\vtemperature\x18\x03 \x01(\x01R\vtemperature\x12\x1b\n" +
+	"\tmin_words\x18\x04 \x01(\x05R\bminWords\x12\x1d\n" +
+	"\n" +
+	"max_tokens\x18\x05 \x01(\x05R\tmaxTokens\x12\x14\n" +
+	"\x05count\x18\x06 \x01(\x05R\x05count\x12\x13\n" +
+	"\x05top_k\x18\a \x01(\x05R\x04topK\x12\x13\n" +
+	"\x05top_p\x18\b \x01(\x01R\x04topP\x12\x14\n" +
+	"\x05novel\x18\t \x01(\bR\x05novel\x12\x1d\n" +
+	"\n" +
+	"char_limit\x18\n" +
+	" \x01(\x05R\tcharLimit\x12\x1b\n" +
+	"\tmin_chars\x18\v \x01(\x05R\bminChars\"0\n" +
+	"\x10GenerateResponse\x12\x1c\n" +
+	"\tsentences\x18\x01 \x03(\tR\tsentences\"8\n" +
+	"\fTrainRequest\x12\x14\n" +
+	"\x05model\x18\x01 \x01(\tR\x05model\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\"4\n" +
+	"\rTrainResponse\x12#\n" +
+	"\x05stats\x18\x01 \x01(\v2\r.garkov.StatsR\x05stats\"8\n" +
+	"\fScoreRequest\x12\x14\n" +
+	"\x05model\x18\x01 \x01(\tR\x05model\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\"V\n" +
+	"\rScoreResponse\x12%\n" +
+	"\x0elog_likelihood\x18\x01 \x01(\x01R\rlogLikelihood\x12\x1e\n" +
+	"\n" +
+	"perplexity\x18\x02 \x01(\x01R\n" +
+	"perplexity\"$\n" +
+	"\fStatsRequest\x12\x14\n" +
+	"\x05model\x18\x01 \x01(\tR\x05model\"4\n" +
+	"\rStatsResponse\x12#\n" +
+	"\x05stats\x18\x01 \x01(\v2\r.garkov.StatsR\x05stats\"\x99\x01\n" +
+	"\x05Stats\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05depth\x18\x02 \x01(\x05R\x05depth\x12\x14\n" +
+	"\x05words\x18\x03 \x01(\x03R\x05words\x12\x16\n" +
+	"\x06chains\x18\x04 \x01(\x03R\x06chains\x12 \n" +
+	"\vtransitions\x18\x05 \x01(\x01R\vtransitions\x12\x16\n" +
+	"\x06starts\x18\x06 \x01(\x03R\x06starts2\xe9\x01\n" +
+	"\x06Garkov\x12=\n" +
+	"\bGenerate\x12\x17.garkov.GenerateRequest\x1a\x18.garkov.GenerateResponse\x124\n" +
+	"\x05Train\x12\x14.garkov.TrainRequest\x1a\x15.garkov.TrainResponse\x124\n" +
+	"\x05Score\x12\x14.garkov.ScoreRequest\x1a\x15.garkov.ScoreResponse\x124\n" +
+	"\x05Stats\x12\x14.garkov.StatsRequest\x1a\x15.garkov.StatsResponseB&Z$github.com/mickuehl/garkov/grpc;grpcb\x06proto3"
+
+var (
+	file_garkov_proto_rawDescOnce sync.Once
+	file_garkov_proto_rawDescData []byte
+)
+
+func file_garkov_proto_rawDescGZIP() []byte {
+	file_garkov_proto_rawDescOnce.Do(func() {
+		file_garkov_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_garkov_proto_rawDesc), len(file_garkov_proto_rawDesc)))
+	})
+	return file_garkov_proto_rawDescData
+}
+
+var file_garkov_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_garkov_proto_goTypes = []any{
+	(*GenerateRequest)(nil),  // 0: garkov.GenerateRequest
+	(*GenerateResponse)(nil), // 1: garkov.GenerateResponse
+	(*TrainRequest)(nil),     // 2: garkov.TrainRequest
+	(*TrainResponse)(nil),    // 3: garkov.TrainResponse
+	(*ScoreRequest)(nil),     // 4: garkov.ScoreRequest
+	(*ScoreResponse)(nil),    // 5: garkov.ScoreResponse
+	(*StatsRequest)(nil),     // 6: garkov.StatsRequest
+	(*StatsResponse)(nil),    // 7: garkov.StatsResponse
+	(*Stats)(nil),            // 8: garkov.Stats
+}
+var file_garkov_proto_depIdxs = []int32{
+	8, // 0: garkov.TrainResponse.stats:type_name -> garkov.Stats
+	8, // 1: garkov.StatsResponse.stats:type_name -> garkov.Stats
+	0, // 2: garkov.Garkov.Generate:input_type -> garkov.GenerateRequest
+	2, // 3: garkov.Garkov.Train:input_type -> garkov.TrainRequest
+	4, // 4: garkov.Garkov.Score:input_type -> garkov.ScoreRequest
+	6, // 5: garkov.Garkov.Stats:input_type -> garkov.StatsRequest
+	1, // 6: garkov.Garkov.Generate:output_type -> garkov.GenerateResponse
+	3, // 7: garkov.Garkov.Train:output_type -> garkov.TrainResponse
+	5, // 8: garkov.Garkov.Score:output_type -> garkov.ScoreResponse
+	7, // 9: garkov.Garkov.Stats:output_type -> garkov.StatsResponse
+	6, // [6:10] is the sub-list for method output_type
+	2, // [2:6] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_garkov_proto_init() }
+func file_garkov_proto_init() {
+	if File_garkov_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_garkov_proto_rawDesc), len(file_garkov_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_garkov_proto_goTypes,
+		DependencyIndexes: file_garkov_proto_depIdxs,
+		MessageInfos:      file_garkov_proto_msgTypes,
+	}.Build()
+	File_garkov_proto = out.File
+	file_garkov_proto_goTypes = nil
+	file_garkov_proto_depIdxs = nil
+}
@@ -0,0 +1,164 @@
+// Package grpc implements the Garkov gRPC service, defined in garkov.proto, for a collection
+// of named markov models.
+package grpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative garkov.proto
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/mickuehl/garkov"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MaxCount limits the number of sentences of a Generate request
+const MaxCount int32 = 1000
+
+// Server implements GarkovServer for the models of a registry
+type Server struct {
+	UnimplementedGarkovServer
+
+	Registry *garkov.Registry
+	NewModel func(name string) *garkov.Markov // creates the models Train adds to, nil rejects unknown models
+}
+
+// NewServer creates a server for the models of a registry. A nil registry is replaced by
+// an empty one. Register the server with RegisterGarkovServer.
+func NewServer(registry *garkov.Registry) *Server {
+	if registry == nil {
+		registry = garkov.NewRegistry()
+	}
+
+	return &Server{
+		Registry: registry,
+	}
+}
+
+// model returns the named model. The name can be empty if the server has a single model.
+func (s *Server) model(name string) (*garkov.Markov, error) {
+	m, err := s.Registry.Lookup(name)
+	if errors.Is(err, garkov.ErrMissingModel) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return m, nil
+}
+
+// Generate creates sentences
+func (s *Server) Generate(ctx context.Context, req *GenerateRequest) (*GenerateResponse, error) {
+	m, err := s.model(req.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Temperature < 0 || req.Count < 0 || req.Count > MaxCount || req.TopK < 0 || req.TopP < 0 ||
+		req.CharLimit < 0 || req.MinChars < 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid generation options")
+	}
+
+	opts := garkov.GenOptions{
+		MinWords:    int(req.MinWords),
+		MaxTokens:   int(req.MaxTokens),
+		Temperature: req.Temperature,
+		TopK:        int(req.TopK),
+		TopP:        req.TopP,
+		Novel:       req.Novel,
+		StartWith:   req.Seed,
+		CharLimit:   int(req.CharLimit),
+		MinChars:    int(req.MinChars),
+	}
+
+	count := int(req.Count)
+	if count == 0 {
+		count = 1
+	}
+
+	resp := GenerateResponse{}
+	for i := 0; i < count; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, status.FromContextError(err).Err()
+		}
+		sentence, err := m.Generate(opts)
+		if err != nil {
+			return nil, generateError(err)
+		}
+		resp.Sentences = append(resp.Sentences, sentence)
+	}
+
+	return &resp, nil
+}
+
+// generateError converts a generation error to its status: a seed the model does not know
+// and contradicting options are invalid arguments, a model without sentences fails a
+// precondition
+func generateError(err error) error {
+	switch {
+	case errors.Is(err, garkov.ErrUnknownPrefix), errors.Is(err, garkov.ErrInvalidOptions):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, garkov.ErrEmptyModel):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// Train updates a model with a text
+func (s *Server) Train(ctx context.Context, req *TrainRequest) (*TrainResponse, error) {
+	m, err := s.model(req.Model)
+	if err != nil {
+		if s.NewModel == nil || req.Model == "" {
+			return nil, err
+		}
+		m = s.Registry.GetOrCreate(req.Model, s.NewModel)
+	}
+
+	if err := m.BuildContext(ctx, strings.NewReader(req.Text)); err != nil {
+		if ctx.Err() != nil {
+			return nil, status.FromContextError(err).Err()
+		}
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return &TrainResponse{Stats: stats(m)}, nil
+}
+
+// Score rates how likely a text is under a model
+func (s *Server) Score(ctx context.Context, req *ScoreRequest) (*ScoreResponse, error) {
+	m, err := s.model(req.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ScoreResponse{
+		LogLikelihood: m.Score(req.Text),
+		Perplexity:    m.Perplexity(req.Text),
+	}, nil
+}
+
+// Stats returns the size of a model
+func (s *Server) Stats(ctx context.Context, req *StatsRequest) (*StatsResponse, error) {
+	m, err := s.model(req.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StatsResponse{Stats: stats(m)}, nil
+}
+
+// stats converts the statistics of a model to their message
+func stats(m *garkov.Markov) *Stats {
+	st := m.Stats()
+	return &Stats{
+		Name:        st.Name,
+		Depth:       int32(st.Depth),
+		Words:       int64(st.Words),
+		Chains:      int64(st.Chains),
+		Transitions: st.Transitions,
+		Starts:      int64(st.Starts),
+	}
+}
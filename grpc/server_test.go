@@ -0,0 +1,54 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mickuehl/garkov"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGenerate(t *testing.T) {
+	m := garkov.New("cats")
+	if err := m.BuildReader(strings.NewReader("The cat sat on the mat.")); err != nil {
+		t.Fatal(err)
+	}
+	registry := garkov.NewRegistry()
+	registry.Add("cats", m)
+	registry.Add("empty", garkov.New("empty"))
+	s := NewServer(registry)
+
+	tests := []struct {
+		req  *GenerateRequest
+		code codes.Code
+	}{
+		{&GenerateRequest{Model: "cats"}, codes.OK},
+		{&GenerateRequest{Model: "cats", Seed: "The cat", Count: 2}, codes.OK},
+		{&GenerateRequest{Model: "cats", CharLimit: 30, MinChars: 10}, codes.OK},
+		{&GenerateRequest{Model: "cats", Seed: "dragon"}, codes.InvalidArgument},
+		{&GenerateRequest{Model: "cats", CharLimit: 10, MinChars: 20}, codes.InvalidArgument},
+		{&GenerateRequest{Model: "cats", CharLimit: -1}, codes.InvalidArgument},
+		{&GenerateRequest{Model: "empty"}, codes.FailedPrecondition},
+		{&GenerateRequest{Model: "dogs"}, codes.NotFound},
+		{&GenerateRequest{}, codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		resp, err := s.Generate(context.Background(), tt.req)
+		if code := status.Code(err); code != tt.code {
+			t.Errorf("Generate(%v): code %v, want %v: %v", tt.req, code, tt.code, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		for _, sentence := range resp.Sentences {
+			if sentence != "The cat sat on the mat." {
+				t.Errorf("Generate(%v): sentence %q", tt.req, sentence)
+			}
+		}
+	}
+}
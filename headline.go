@@ -0,0 +1,83 @@
+package garkov
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// smallWords stay in lower case in title case, unless they begin or end the title or follow
+// a colon
+var smallWords = map[string]bool{
+	"a": true, "an": true, "and": true, "as": true, "at": true, "but": true, "by": true,
+	"for": true, "in": true, "nor": true, "of": true, "on": true, "or": true, "per": true,
+	"the": true, "to": true, "vs": true, "via": true,
+}
+
+// TitleCase capitalizes the words of a text like a headline, e.g. "the return of the king"
+// becomes "The Return of the King". Small words like "of" stay in lower case unless they
+// begin or end the text or follow a colon. Words with digits like 3D, acronyms like NASA or
+// U.S. and words with capitals inside like iPhone are kept as they are. The parts of words
+// joined by hyphens are capitalized each.
+func TitleCase(text string) string {
+	words := strings.Split(text, " ")
+
+	first, last := -1, -1
+	for i, w := range words {
+		if strings.IndexFunc(w, unicode.IsLetter) >= 0 {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+
+	for i, w := range words {
+		if i > first && i < last && !strings.HasSuffix(words[i-1], ":") && smallWords[strings.ToLower(w)] {
+			words[i] = strings.ToLower(w)
+			continue
+		}
+
+		parts := strings.Split(w, "-")
+		for j, part := range parts {
+			parts[j] = titleWord(part)
+		}
+		words[i] = strings.Join(parts, "-")
+	}
+	return strings.Join(words, " ")
+}
+
+// titleWord capitalizes the first letter of a word, after quotes or parentheses, unless the
+// word has digits or capitals after its first letter
+func titleWord(w string) string {
+	start := strings.IndexFunc(w, unicode.IsLetter)
+	if start < 0 || strings.IndexFunc(w, unicode.IsDigit) >= 0 {
+		return w
+	}
+
+	_, size := utf8.DecodeRuneInString(w[start:])
+	if strings.IndexFunc(w[start+size:], unicode.IsUpper) >= 0 {
+		return w
+	}
+	return w[:start] + capitalize(w[start:])
+}
+
+// text joins the words of a sentence like toString, and in title case without its final
+// period if opts.TitleCase is set
+func (m *Markov) text(sentence []dictionary.Word, opts GenOptions) string {
+	s := m.toString(sentence)
+	if !opts.TitleCase {
+		return s
+	}
+	if strings.HasSuffix(s, ".") && !strings.HasSuffix(s, "..") {
+		s = s[:len(s)-1]
+	}
+	return TitleCase(s)
+}
+
+// longEnough is true if the text has at least the MinChars of the options
+func longEnough(text string, opts GenOptions) bool {
+	return opts.MinChars <= 0 || utf8.RuneCountInString(text) >= opts.MinChars
+}
@@ -0,0 +1,234 @@
+package garkov
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// jsonModel is the JSON representation of a markov model. Prefixes and suffixes
+// are spelled out as words so that the model can be read and edited by hand.
+type jsonModel struct {
+	Name     string      `json:"name"`
+	Depth    int         `json:"depth"`
+	Mode     Mode        `json:"mode"`
+	Backoff  bool        `json:"backoff"`
+	Backward bool        `json:"backward,omitempty"`
+	K        float64     `json:"smoothing,omitempty"`
+	FoldCase bool        `json:"fold_case,omitempty"`
+	Novelty  int         `json:"novelty,omitempty"`
+	MaxWords int         `json:"max_words,omitempty"`
+	Language string      `json:"language"`
+	Words    []jsonWord  `json:"words"`
+	Start    [][]string  `json:"start"`
+	Counts   []float64   `json:"start_counts,omitempty"` // the count of each start prefix, without them each counts once
+	Chains   []jsonChain `json:"chains"`
+	Reverse  []jsonChain `json:"reverse,omitempty"`
+
+	Ngrams map[uint64]int `json:"ngrams,omitempty"` // hashes of the runs of novelty+1 words
+}
+
+// jsonWord is an entry of the dictionary. Its position in the list is the word index.
+type jsonWord struct {
+	Word  string `json:"word"`
+	Type  int    `json:"type"`
+	Count int    `json:"count"`
+
+	Forms map[string]int `json:"forms,omitempty"` // spellings of a case-folded word
+	Tags  map[string]int `json:"tags,omitempty"`  // tags of the word, e.g. its parts of speech
+}
+
+type jsonChain struct {
+	Prefix   []string           `json:"prefix"`
+	Suffixes map[string]float64 `json:"suffixes"`
+}
+
+// MarshalJSON encodes the complete model as JSON.
+func (m *Markov) MarshalJSON() ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	mdl := jsonModel{
+		Name:     m.Name,
+		Depth:    m.Depth,
+		Mode:     m.Mode,
+		Backoff:  m.Backoff,
+		Backward: m.Backward,
+		K:        m.Smoothing.K,
+		FoldCase: m.FoldCase,
+		Novelty:  m.Novelty,
+		MaxWords: m.MaxWords,
+		Ngrams:   m.ngrams,
+		Language: m.Language,
+		Words:    make([]jsonWord, m.Dict.Len()),
+		Start:    make([][]string, len(m.Start)),
+		Counts:   m.StartCounts,
+	}
+
+	var err error
+	if mdl.Chains, err = toJSONChains(m.Chain, m.Dict); err != nil {
+		return nil, err
+	}
+	if mdl.Reverse, err = toJSONChains(m.Reverse, m.Dict); err != nil {
+		return nil, err
+	}
+
+	m.Dict.Range(func(word dictionary.Word) bool {
+		mdl.Words[word.Idx] = jsonWord{Word: word.Word, Type: word.Type, Count: word.Count, Forms: m.Dict.Forms[word.Word], Tags: m.Dict.Tags[word.Word]}
+		return true
+	})
+
+	for i, prefix := range m.Start {
+		mdl.Start[i] = indexToWords(prefix, m.Dict)
+	}
+
+	return json.Marshal(&mdl)
+}
+
+// toJSONChains spells out the prefixes and suffixes of the chains
+func toJSONChains(chains Storage, dict *dictionary.Dictionary) ([]jsonChain, error) {
+	list := make([]jsonChain, 0, chains.Len())
+	err := chains.Range(func(prefix []int, suffixes []WordCount) bool {
+		c := jsonChain{
+			Prefix:   indexToWords(prefix, dict),
+			Suffixes: make(map[string]float64, len(suffixes)),
+		}
+		for _, suffix := range suffixes {
+			c.Suffixes[dict.WordAt(suffix.Idx)] = suffix.Count
+		}
+		list = append(list, c)
+		return true
+	})
+	return list, err
+}
+
+// fromJSONChains looks up the words of the chains in the dictionary
+func fromJSONChains(list []jsonChain, dict *dictionary.Dictionary) (*Chains, error) {
+	chains := NewChains()
+	for _, c := range list {
+		idx, err := wordsToIndex(c.Prefix, dict)
+		if err != nil {
+			return nil, err
+		}
+
+		suffixes := make([]WordCount, 0, len(c.Suffixes))
+		for w, count := range c.Suffixes {
+			word, found := dict.Get(w)
+			if !found {
+				return nil, fmt.Errorf("unknown word '%v'", w)
+			}
+			suffixes = append(suffixes, WordCount{Idx: word.Idx, Count: count})
+		}
+		chains.PutChain(idx, suffixes)
+	}
+	return chains, nil
+}
+
+// UnmarshalJSON replaces the model with one decoded from JSON. The dictionary is replaced
+// too, so the models of a MultiModel, which share theirs, return ErrSharedDictionary.
+func (m *Markov) UnmarshalJSON(data []byte) error {
+	if m.shared {
+		return ErrSharedDictionary
+	}
+
+	var mdl jsonModel
+	if err := json.Unmarshal(data, &mdl); err != nil {
+		return err
+	}
+	if err := checkDepth(mdl.Depth); err != nil {
+		return err
+	}
+
+	dict := &dictionary.Dictionary{
+		Name:  mdl.Name,
+		Words: make(dictionary.WordMap),
+		V:     make(dictionary.WordVector, len(mdl.Words)),
+	}
+	for i, w := range mdl.Words {
+		if _, found := dict.Words[w.Word]; found {
+			return fmt.Errorf("duplicate word '%v'", w.Word)
+		}
+		dict.Words[w.Word] = dictionary.Word{Word: w.Word, Idx: i, Type: w.Type, Count: w.Count}
+		dict.V[i] = w.Word
+
+		if len(w.Forms) > 0 {
+			if dict.Forms == nil {
+				dict.Forms = make(map[string]map[string]int)
+			}
+			dict.Forms[w.Word] = w.Forms
+		}
+		if len(w.Tags) > 0 {
+			if dict.Tags == nil {
+				dict.Tags = make(map[string]map[string]int)
+			}
+			dict.Tags[w.Word] = w.Tags
+		}
+	}
+	dict.Size = len(dict.V)
+
+	start := make([][]int, len(mdl.Start))
+	for i, prefix := range mdl.Start {
+		idx, err := wordsToIndex(prefix, dict)
+		if err != nil {
+			return err
+		}
+		start[i] = idx
+	}
+
+	if mdl.Counts == nil {
+		mdl.Counts = make([]float64, len(start))
+		for i := range mdl.Counts {
+			mdl.Counts[i] = 1
+		}
+	}
+	if len(mdl.Counts) != len(start) {
+		return fmt.Errorf("%v start counts for %v start prefixes", len(mdl.Counts), len(start))
+	}
+
+	chains, err := fromJSONChains(mdl.Chains, dict)
+	if err != nil {
+		return err
+	}
+	if err := addStarts(chains, start, mdl.Counts); err != nil {
+		return err
+	}
+	reverse, err := fromJSONChains(mdl.Reverse, dict)
+	if err != nil {
+		return err
+	}
+
+	// a model decoded into its zero value has no lock yet
+	if m.mu == nil {
+		m.mu = new(sync.RWMutex)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Name = mdl.Name
+	m.Depth = mdl.Depth
+	m.Mode = mdl.Mode
+	m.Backoff = mdl.Backoff
+	m.Backward = mdl.Backward
+	m.Smoothing = AddK(mdl.K)
+	m.FoldCase = mdl.FoldCase
+	m.Novelty = mdl.Novelty
+	m.MaxWords = mdl.MaxWords
+	m.ngrams = mdl.Ngrams
+	m.Language = mdl.Language
+	m.Dict = dict
+	m.setStarts(start, mdl.Counts)
+	m.Chain = chains
+	m.Reverse = reverse
+
+	m.rmu.Lock()
+	if m.Random == nil {
+		m.Random = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	m.rmu.Unlock()
+
+	return nil
+}
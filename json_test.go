@@ -0,0 +1,51 @@
+package garkov
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	m := New("json")
+	if err := m.BuildReader(strings.NewReader(benchText(50))); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Markov
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Dict.Len() != m.Dict.Len() || decoded.Chain.Len() != m.Chain.Len() {
+		t.Errorf("decoded %d words and %d chains, want %d and %d",
+			decoded.Dict.Len(), decoded.Chain.Len(), m.Dict.Len(), m.Chain.Len())
+	}
+	if err := decoded.Validate(); err != nil {
+		t.Errorf("invalid model after UnmarshalJSON: %v", err)
+	}
+}
+
+func TestJSONMultiModel(t *testing.T) {
+	m := New("json")
+	if err := m.BuildReader(strings.NewReader("The cat sat on the mat.")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mm := NewMultiModel("multi")
+	a := mm.Model("a")
+	if err := json.Unmarshal(data, a); !errors.Is(err, ErrSharedDictionary) {
+		t.Errorf("UnmarshalJSON of a shared model: got %v, want ErrSharedDictionary", err)
+	}
+	if a.Dict != mm.Dict {
+		t.Error("the model no longer shares the dictionary")
+	}
+}
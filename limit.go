@@ -0,0 +1,92 @@
+package garkov
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// charLimitTries is the number of sentences generated to find one within GenOptions.CharLimit,
+// before the shortest one is trimmed
+const charLimitTries int = 100
+
+// checkLimits returns ErrInvalidOptions if no text is long enough for MinChars and fits
+// the CharLimit of the options
+func checkLimits(opts GenOptions) error {
+	if opts.CharLimit > 0 && opts.MinChars > opts.CharLimit {
+		return fmt.Errorf("%w: MinChars %v exceeds the CharLimit %v", ErrInvalidOptions, opts.MinChars, opts.CharLimit)
+	}
+	return nil
+}
+
+// fit generates a sentence from the beginnings returned by start. With a CharLimit or
+// MinChars, sentences are re-sampled until one fits. If none does, the shortest of those
+// long enough is cut after the last word that fits and closed with a STOP word. The
+// beginning itself is never cut, the result is empty if it does not fit, the cut sentence is
+// too short or start returns nil. The sentence is counted by the Metrics of the model.
+func (m *Markov) fit(start func() []dictionary.Word, opts GenOptions) (s string) {
+	if m.Metrics != nil {
+		defer func(begin time.Time) {
+			if s != "" {
+				m.Metrics.Generated(m.Name, time.Since(begin))
+			}
+		}(time.Now())
+	}
+
+	if opts.CharLimit <= 0 && opts.MinChars <= 0 {
+		begin := start()
+		if begin == nil {
+			return ""
+		}
+		return m.text(m.generate(begin, opts), opts)
+	}
+
+	var shortest []dictionary.Word
+	prefix := 0
+	for i := 0; i < charLimitTries; i++ {
+		begin := start()
+		if begin == nil {
+			return ""
+		}
+
+		sentence := m.generate(append([]dictionary.Word(nil), begin...), opts)
+		s := m.text(sentence, opts)
+		if !longEnough(s, opts) {
+			continue
+		}
+		if fits(s, opts) {
+			return s
+		}
+		if shortest == nil || len(sentence) < len(shortest) {
+			shortest = sentence
+			prefix = len(begin)
+		}
+	}
+
+	for n := len(shortest) - 1; n >= prefix; n-- {
+		// do not end on a comma or similar
+		if n > prefix && shortest[n-1].Type != dictionary.WORD && shortest[n-1].Type != dictionary.STOP {
+			continue
+		}
+
+		sentence := append([]dictionary.Word(nil), shortest[:n]...)
+		if end := m.endWord(sentence); end.Word != "" {
+			sentence = append(sentence, end)
+		}
+		if s := m.text(sentence, opts); fits(s, opts) {
+			if longEnough(s, opts) {
+				return s
+			}
+			return ""
+		}
+	}
+
+	return ""
+}
+
+// fits is true if the text is within the CharLimit of the options
+func fits(text string, opts GenOptions) bool {
+	return opts.CharLimit <= 0 || utf8.RuneCountInString(text) <= opts.CharLimit
+}
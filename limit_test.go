@@ -0,0 +1,45 @@
+package garkov
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestGenerateLimits(t *testing.T) {
+	m := New("limits")
+	text := "The cat sat on the mat. The dog slept all day long in the warm sun by the door."
+	if err := m.BuildReader(strings.NewReader(text)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts GenOptions
+		err  error
+	}{
+		{"no limits", GenOptions{}, nil},
+		{"limit", GenOptions{CharLimit: 30}, nil},
+		{"minimum", GenOptions{MinChars: 30}, nil},
+		{"both", GenOptions{MinChars: 20, CharLimit: 100}, nil},
+		{"equal", GenOptions{MinChars: 23, CharLimit: 23}, nil},
+		{"contradicting", GenOptions{MinChars: 100, CharLimit: 10}, ErrInvalidOptions},
+	}
+
+	for _, tt := range tests {
+		s, err := m.Generate(tt.opts)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: Generate error %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		n := utf8.RuneCountInString(s)
+		if s == "" || (tt.opts.CharLimit > 0 && n > tt.opts.CharLimit) || n < tt.opts.MinChars {
+			t.Errorf("%s: Generate = %q, %d characters", tt.name, s, n)
+		}
+	}
+}
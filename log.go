@@ -0,0 +1,23 @@
+package garkov
+
+import (
+	"context"
+	"log/slog"
+)
+
+// log writes a record to the logger of the model, if it has one. Every record names the
+// model.
+func (m *Markov) log(level slog.Level, msg string, args ...interface{}) {
+	if m.Logger == nil || !m.Logger.Enabled(context.Background(), level) {
+		return
+	}
+	m.Logger.Log(context.Background(), level, msg, append([]interface{}{"model", m.Name}, args...)...)
+}
+
+// log writes a record to the logger of the registry, if it has one
+func (r *Registry) log(level slog.Level, msg string, args ...interface{}) {
+	if r.Logger == nil {
+		return
+	}
+	r.Logger.Log(context.Background(), level, msg, args...)
+}
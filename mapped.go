@@ -0,0 +1,401 @@
+package garkov
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"unsafe"
+)
+
+const (
+	// mappedMagic identifies files written by SaveMapped
+	mappedMagic string = "GKMAP"
+	// mappedVersion is the version of the file format written by SaveMapped
+	mappedVersion byte = 3
+	// sectionAlign is the alignment of the sections of a mapped file
+	sectionAlign int = 8
+)
+
+// littleEndian is true if the sections of a mapped file, which are little endian, can be
+// used in place
+var littleEndian = func() bool {
+	x := uint16(1)
+	return *(*byte)(unsafe.Pointer(&x)) == 1
+}()
+
+// SaveMapped writes the model to a file that OpenMapped uses without decoding the chains.
+// The chains are written in the form of Compiled, as sections of flat arrays at aligned
+// offsets, and never compressed. Load reads these files too.
+func (m *Markov) SaveMapped(path string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	// the permissions of a file created by os.Create
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	if err := m.encodeMapped(w); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+	m.log(slog.LevelInfo, "saved mapped", "file", path)
+	return nil
+}
+
+// OpenMapped opens a model written by SaveMapped. The file is memory-mapped where the
+// platform supports it, and the chains are used in place: only the dictionary and the
+// settings are decoded, and the pages of the chains are read when generation needs them.
+// The checksum is not verified, that would read the whole file, but Load verifies it.
+// The chains are Compiled, so the model can not be trained. Unmap releases the file.
+func OpenMapped(path string) (*Markov, error) {
+	data, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := decodeMapped(data, false)
+	if err != nil {
+		unmapFile(data)
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+	m.mapped = data
+	return m, nil
+}
+
+// Unmap releases the file of a model opened by OpenMapped. The model must not be used
+// afterwards.
+func (m *Markov) Unmap() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.mapped == nil {
+		return nil
+	}
+	err := unmapFile(m.mapped)
+	m.mapped = nil
+	return err
+}
+
+// encodeMapped writes the header, the model without its chains and then the sections of
+// the compiled chains to w
+func (m *Markov) encodeMapped(w io.Writer) error {
+	chain, err := Compile(m.Chain)
+	if err != nil {
+		return err
+	}
+	reverse, err := Compile(m.Reverse)
+	if err != nil {
+		return err
+	}
+
+	mdl := m.binaryModel()
+
+	var header bytes.Buffer
+	if err := gob.NewEncoder(&header).Encode(&mdl); err != nil {
+		return err
+	}
+
+	s := sectionWriter{w: w}
+	s.write([]byte(mappedMagic + string([]byte{mappedVersion, 0, 0})))
+	s.section(header.Bytes())
+	for _, c := range []*Compiled{chain, reverse} {
+		s.section(c.keys)
+		s.section(c.keyEnd)
+		s.section(c.suffixEnd)
+		s.section(c.suffixes)
+		s.section(c.cumulative)
+		s.section(c.aliasChains)
+		s.section(c.aliasEnd)
+		s.section(c.aliasProb)
+		s.section(c.aliasIdx)
+		s.section(c.startKeys)
+		s.section(c.startEnd)
+		s.section(c.startCounts)
+	}
+	s.section(binary.LittleEndian.AppendUint32(nil, s.crc))
+	return s.err
+}
+
+// decodeMapped returns the model of a file written by SaveMapped, and verifies its checksum
+// if verify is set. The chains refer to data.
+func decodeMapped(data []byte, verify bool) (*Markov, error) {
+	if len(data) < sectionAlign || string(data[:len(mappedMagic)]) != mappedMagic {
+		return nil, fmt.Errorf("%w: not a mapped model", ErrCorruptModel)
+	}
+	version := data[len(mappedMagic)]
+	if version < 1 || version > mappedVersion {
+		return nil, fmt.Errorf("%w %v, version %v of the library reads mapped files up to version %v",
+			ErrIncompatibleVersion, version, Version, mappedVersion)
+	}
+
+	s := sectionReader{data: data[sectionAlign:]}
+	header := s.next()
+
+	var chains [2]*Compiled
+	for i := range chains {
+		chains[i] = &Compiled{
+			keys:        s.next(),
+			keyEnd:      s.uint32s(),
+			suffixEnd:   s.uint32s(),
+			suffixes:    s.int32s(),
+			cumulative:  s.float64s(),
+			aliasChains: s.uint32s(),
+			aliasEnd:    s.uint32s(),
+			aliasProb:   s.float64s(),
+			aliasIdx:    s.int32s(),
+			startKeys:   s.next(),
+			startEnd:    s.uint32s(),
+			startCounts: s.float64s(),
+		}
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	// version 2 added the checksum of the file before it
+	if version >= 2 {
+		end := len(data) - len(s.data)
+		stored := s.next()
+		if s.err != nil || len(stored) != 4 {
+			return nil, ErrChecksum
+		}
+		if verify && binary.LittleEndian.Uint32(stored) != crc32.Checksum(data[:end], castagnoli) {
+			return nil, ErrChecksum
+		}
+	}
+
+	var mdl binaryModel
+	if err := gob.NewDecoder(bytes.NewReader(header)).Decode(&mdl); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptModel, err)
+	}
+	m, err := mdl.model()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range chains {
+		if err := c.check(); err != nil {
+			return nil, err
+		}
+	}
+	m.Chain = chains[0]
+	m.Reverse = chains[1]
+	return m, nil
+}
+
+// check verifies that the offsets of the chains are within their arrays, without reading
+// the suffixes. The word indices of the suffixes and the aliases are not checked, that
+// would read most of the file.
+func (c *Compiled) check() error {
+	if len(c.suffixEnd) != len(c.keyEnd) || len(c.cumulative) != len(c.suffixes) ||
+		!increasing(c.keyEnd, 0, len(c.keys)) || !increasing(c.suffixEnd, 0, len(c.suffixes)) {
+		return fmt.Errorf("%w: invalid chains", ErrCorruptModel)
+	}
+
+	if len(c.aliasEnd) != len(c.aliasChains) || len(c.aliasIdx) != len(c.aliasProb) ||
+		!increasing(c.aliasChains, -1, len(c.keyEnd)-1) || !increasing(c.aliasEnd, 0, len(c.aliasProb)) {
+		return fmt.Errorf("%w: invalid alias tables", ErrCorruptModel)
+	}
+	for _, i := range c.aliasChains {
+		start, end := c.span(int(i))
+		if t, _ := c.alias(int(i)); len(t.prob) != end-start {
+			return fmt.Errorf("%w: invalid alias tables", ErrCorruptModel)
+		}
+	}
+
+	if len(c.startCounts) != len(c.startEnd) || !increasing(c.startEnd, 0, len(c.startKeys)) {
+		return fmt.Errorf("%w: invalid start prefixes", ErrCorruptModel)
+	}
+	return nil
+}
+
+// increasing is true if each value is greater than the one before, the first greater than
+// previous, and none exceeds max
+func increasing(values []uint32, previous int64, max int) bool {
+	for _, v := range values {
+		if int64(v) <= previous || int64(v) > int64(max) {
+			return false
+		}
+		previous = int64(v)
+	}
+	return true
+}
+
+// sectionWriter writes sections: the length of the data as a uint64, the data in little
+// endian byte order and padding up to sectionAlign
+type sectionWriter struct {
+	w   io.Writer
+	crc uint32 // the checksum of the data written so far
+	err error
+}
+
+// write writes b unless an earlier write failed
+func (s *sectionWriter) write(b []byte) {
+	if s.err == nil {
+		_, s.err = s.w.Write(b)
+		s.crc = crc32.Update(s.crc, castagnoli, b)
+	}
+}
+
+// section writes the section of data, a []byte, []uint32, []int32 or []float64
+func (s *sectionWriter) section(data interface{}) {
+	var b []byte
+	switch v := data.(type) {
+	case []byte:
+		b = v
+	case []uint32:
+		b = make([]byte, 4*len(v))
+		for i, x := range v {
+			binary.LittleEndian.PutUint32(b[4*i:], x)
+		}
+	case []int32:
+		b = make([]byte, 4*len(v))
+		for i, x := range v {
+			binary.LittleEndian.PutUint32(b[4*i:], uint32(x))
+		}
+	case []float64:
+		b = make([]byte, 8*len(v))
+		for i, x := range v {
+			binary.LittleEndian.PutUint64(b[8*i:], math.Float64bits(x))
+		}
+	default:
+		s.err = fmt.Errorf("unsupported section type %T", data)
+		return
+	}
+
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(b)))
+	s.write(size[:])
+	s.write(b)
+	s.write(make([]byte, padding(len(b))))
+}
+
+// sectionReader reads the sections written by sectionWriter
+type sectionReader struct {
+	data []byte
+	err  error
+}
+
+// next returns the data of the next section
+func (s *sectionReader) next() []byte {
+	if s.err != nil {
+		return nil
+	}
+	if len(s.data) < 8 {
+		s.err = fmt.Errorf("%w: truncated file", ErrCorruptModel)
+		return nil
+	}
+
+	size := binary.LittleEndian.Uint64(s.data)
+	s.data = s.data[8:]
+	if size > uint64(len(s.data)) {
+		s.err = fmt.Errorf("%w: truncated file", ErrCorruptModel)
+		return nil
+	}
+
+	b := s.data[:size:size]
+	skip := int(size) + padding(int(size))
+	if skip > len(s.data) {
+		skip = len(s.data)
+	}
+	s.data = s.data[skip:]
+	return b
+}
+
+// uint32s returns the next section as uint32 values
+func (s *sectionReader) uint32s() []uint32 {
+	b := s.sized(4)
+	if len(b) == 0 {
+		return nil
+	}
+	if littleEndian && aligned(b, 4) {
+		return unsafe.Slice((*uint32)(unsafe.Pointer(&b[0])), len(b)/4)
+	}
+
+	v := make([]uint32, len(b)/4)
+	for i := range v {
+		v[i] = binary.LittleEndian.Uint32(b[4*i:])
+	}
+	return v
+}
+
+// int32s returns the next section as int32 values
+func (s *sectionReader) int32s() []int32 {
+	b := s.sized(4)
+	if len(b) == 0 {
+		return nil
+	}
+	if littleEndian && aligned(b, 4) {
+		return unsafe.Slice((*int32)(unsafe.Pointer(&b[0])), len(b)/4)
+	}
+
+	v := make([]int32, len(b)/4)
+	for i := range v {
+		v[i] = int32(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return v
+}
+
+// float64s returns the next section as float64 values
+func (s *sectionReader) float64s() []float64 {
+	b := s.sized(8)
+	if len(b) == 0 {
+		return nil
+	}
+	if littleEndian && aligned(b, 8) {
+		return unsafe.Slice((*float64)(unsafe.Pointer(&b[0])), len(b)/8)
+	}
+
+	v := make([]float64, len(b)/8)
+	for i := range v {
+		v[i] = math.Float64frombits(binary.LittleEndian.Uint64(b[8*i:]))
+	}
+	return v
+}
+
+// sized returns the next section, which must hold values of size bytes
+func (s *sectionReader) sized(size int) []byte {
+	b := s.next()
+	if len(b)%size != 0 && s.err == nil {
+		s.err = fmt.Errorf("%w: invalid section", ErrCorruptModel)
+	}
+	return b
+}
+
+// aligned is true if b starts at a multiple of size
+func aligned(b []byte, size int) bool {
+	return uintptr(unsafe.Pointer(&b[0]))%uintptr(size) == 0
+}
+
+// padding returns the number of bytes that align a section of n bytes
+func padding(n int) int {
+	return (sectionAlign - n%sectionAlign) % sectionAlign
+}
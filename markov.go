@@ -1,153 +1,275 @@
 package garkov
 
 import (
+	"fmt"
+	"log/slog"
 	"math/rand"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/mickuehl/garkov/dictionary"
 )
 
-// WordCount the number of occurences of a word from the word vector
+// WordCount the number of occurences of a word from the word vector. Weighted training
+// adds fractions of occurences.
 type WordCount struct {
 	Idx   int
-	Count int
+	Count float64
 }
 
 // WordChain is the main structure of the model. It represents a prefix and all its suffixes.
 type WordChain struct {
 	Prefix []int                // arrary of words forming the prefix. Index into the dictionaries word vector
 	Words  map[string]WordCount // the collection of suffixes and their count
+	State  ChainState           // where the prefix occurred in the sentences of the training text
 }
 
-// Markov wraps all data of a markov-chain into one
+// Mode selects the units a markov-chain is built of
+type Mode int
+
+const (
+	// WordLevel builds the chain over the words of a text
+	WordLevel Mode = iota
+	// CharLevel builds the chain over the characters of each line of a text, e.g. for name generators
+	CharLevel
+)
+
+// Markov wraps all data of a markov-chain into one. The methods of Markov can be called
+// from multiple goroutines, the exported fields must not be modified while the model is in use.
 type Markov struct {
-	Name     string                 // name of the model
-	Depth    int                    // prefix size
-	Chain    map[string]WordChain   // the prefixes mapped to the word chains
-	Dict     *dictionary.Dictionary // the dictionary used in the model
-	Start    [][]int                // array of start prefixes
-	Language string
-	Random   *rand.Rand
-}
+	Name        string                 // name of the model
+	Depth       int                    // prefix size
+	Mode        Mode                   // word or character level chain
+	Backoff     bool                   // also build chains of order 1..Depth-1 and fall back to them during generation
+	Backward    bool                   // also build the chain of the reversed text, to extend sentences to the left
+	Smoothing   Smoothing              // probability of unseen suffixes in generation and scoring
+	FoldCase    bool                   // lower case all words and restore their most frequent spelling in generation
+	Novelty     int                    // remember the runs of Novelty+1 tokens of the training text for GenOptions.Novel, must exceed Depth
+	MaxWords    int                    // limits the dictionary, further words are replaced by dictionary.UNKNOWN_TOKEN. 0 is unlimited.
+	Chain       Storage                // the prefixes mapped to the word chains
+	Reverse     Storage                // the chain of the reversed text: the words mapped to the words preceding them
+	Dict        *dictionary.Dictionary // the dictionary used in the model
+	Start       [][]int                // the distinct start prefixes
+	StartCounts []float64              // the number of sentences each start prefix began, by its index in Start
+	Language    string
+	Tokenizer   Tokenizer   // splits the input text into words, nil selects the default for the language
+	Detokenizer Detokenizer // joins generated words into text, nil selects the default for the mode
+	Random      *rand.Rand
+	WordFilter  WordFilter // words it rejects are never generated, nil allows all words
+	Tagger      Tagger     // tags the sentences of the training text, nil tags none. It is not persisted.
 
-// New creates an empty markov model.
-func New(name string, depth int) *Markov {
+	SyllableCounter SyllableCounter // counts the syllables of words for GenOptions.Syllables, nil selects CountSyllables. It is not persisted.
 
-	m := Markov{
-		Name:     name,
-		Depth:    depth,
-		Chain:    make(map[string]WordChain),
-		Dict:     dictionary.New(name),
-		Start:    make([][]int, 0),
-		Language: "en",
-		Random:   rand.New(rand.NewSource(time.Now().UnixNano())),
-	}
+	StopTokens    []string  // tokens that end a sentence in the default tokenizer, nil selects DefaultStopTokens
+	Abbreviations []string  // words whose period does not end a sentence in the default tokenizer, nil selects DefaultAbbreviations
+	LineBreaks    bool      // every line break ends a sentence in the default tokenizer
+	Placeholders  bool      // replace numbers, URLs, email addresses, @mentions and hashtags by placeholders in the default tokenizer
+	NoParagraphs  bool      // blank lines do not end a paragraph, a sentence that has not ended continues after them
+	Starts        StartMode // the sentences whose first words become start prefixes
 
-	return &m
-}
+	Logger     *slog.Logger // receives records of training, saving and pruning, nil discards them
+	OnProgress ProgressFunc // called periodically while the model is trained, nil reports nothing
+	Metrics    Metrics      // counts the sentences generated and the tokens trained, nil counts nothing
 
-// Sentence creates a new sentence based on the markov-chain
-func (m *Markov) Sentence(minWords, maxWords int) string {
+	stream  stream         // the state of the text passed to Feed
+	filters []Filter       // applied to the text before it is tokenized
+	ngrams  map[uint64]int // hashes of the runs of Novelty+1 tokens of the training text and their counts
 
-	sentence := make([]dictionary.Word, m.Depth)
+	starts    startIndex      // draws the start prefixes by their counts
+	stopwords map[string]bool // the lower case stopwords, see SetStopwords
+	mapped    []byte          // the file mapped by OpenMapped
+	shared    bool            // the dictionary is shared with the other models of a MultiModel
 
-	// select a first prefix to start with
-	_prefix := m.Start[m.Random.Intn(len(m.Start))]
-	for i := range _prefix {
-		w, _ := m.Dict.GetAt(_prefix[i])
-		sentence[i] = w
-	}
-	prefix := sentence
+	mu  *sync.RWMutex // guards Dict, Start, StartCounts, starts, stream, filters, ngrams and stopwords, shared by the models of a MultiModel. Chain and Reverse lock themselves.
+	rmu sync.Mutex    // guards Random
+}
 
-	n := 0
-	for {
-		// get the next word, until we get a STOP word
-		suffix := m.SuffixFor(prefix)
-		sentence = append(sentence, suffix)
+// stream is the state of a text while it is added to the model
+type stream struct {
+	window []dictionary.Word // the last Depth words of the text
+	start  []int             // the start prefix of the current sentence
+	run    []int             // the last Novelty+1 words of the text
+	weight float64           // the weight of each transition, 0 is the same as 1
 
-		if suffix.Type == dictionary.STOP && n >= minWords {
-			break
-		}
+	sentences int // the number of sentences of the text
+	tokens    int // the number of tokens of the text, see ErrShortText
+	paragraph int // the number of sentences of the current paragraph
 
-		// new prefix
-		prefix = sentence[len(sentence)-m.Depth:]
-		n = n + 1
+	pending       []int // the transitions not yet added to the chains, Depth+1 word indices each
+	pendingStarts []int // the start prefixes not yet added to the chains, Depth word indices each
+}
 
-		if n > maxWords {
-			break // emergency break
-		}
+// transitions are prefixes and their suffixes, and start prefixes, waiting to be added to
+// the chains
+type transitions struct {
+	words  []int // Depth+1 word indices for each transition
+	starts []int // Depth word indices for each start prefix
+	weight float64
+}
 
+// takePending removes the pending transitions from the stream and returns them
+func (s *stream) takePending() transitions {
+	weight := s.weight
+	if weight == 0 {
+		weight = 1
 	}
 
-	return wordsToSentence(sentence)
+	t := transitions{words: s.pending, starts: s.pendingStarts, weight: weight}
+	s.pending = nil
+	s.pendingStarts = nil
+	return t
 }
 
-// Update adds a prefix + suffix to the markov model
-func (m *Markov) Update(prefix []dictionary.Word, suffix dictionary.Word) {
+// DefaultDepth is the prefix size of a model created by New without WithDepth
+const DefaultDepth int = 2
 
-	_prefix := wordsToPrefixString(prefix)
-	chain, found := m.Chain[_prefix]
+// MaxDepth is the largest prefix size of a model. Chains that deep only repeat the training
+// text.
+const MaxDepth int = 16
 
-	if !found {
-		chain = WordChain{
-			Prefix: wordsToIndexArray(prefix),
-			Words:  make(map[string]WordCount),
-		}
+// checkDepth returns ErrInvalidDepth if the depth is not within 1 and MaxDepth
+func checkDepth(depth int) error {
+	if depth < 1 || depth > MaxDepth {
+		return fmt.Errorf("%w %v, the depth must be within 1 and %v", ErrInvalidDepth, depth, MaxDepth)
 	}
+	return nil
+}
 
-	// add the word to the sequence
-	chain.AddWord(suffix)
+// New creates an empty markov model, configured by the options. Without options it is a
+// WordLevel model of DefaultDepth with in-memory chains.
+//
+// The depth and mode as plain arguments, e.g. New(name, 2, CharLevel), are deprecated. They
+// are still accepted for the callers of the former New(name, depth, mode...), use WithDepth
+// and WithMode instead.
+func New(name string, opts ...Option) *Markov {
 
-	// update the model
-	m.Chain[_prefix] = chain
+	m := Markov{
+		Name:        name,
+		Depth:       DefaultDepth,
+		Mode:        WordLevel,
+		Chain:       NewChains(),
+		Reverse:     NewChains(),
+		Dict:        dictionary.New(name),
+		Start:       make([][]int, 0),
+		StartCounts: make([]float64, 0),
+		Language:    "en",
+		Random:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		mu:          new(sync.RWMutex),
+	}
+
+	for _, opt := range opts {
+		apply(&m, opt)
+	}
 
+	return &m
 }
 
-// Close writes the model to disc
-func (m *Markov) Close() {
-	m.Dict.Close()
+// SetRandom replaces the random number generator used for generation. Passing a
+// generator with a fixed seed makes the output of Sentence reproducible.
+func (m *Markov) SetRandom(r *rand.Rand) {
+	m.rmu.Lock()
+	defer m.rmu.Unlock()
 
+	m.Random = r
 }
 
-// SuffixFor returns a word that succeedes a given prefix
-func (m *Markov) SuffixFor(prefix []dictionary.Word) dictionary.Word {
+// Seed re-seeds the model's random number generator.
+func (m *Markov) Seed(seed int64) {
+	m.SetRandom(rand.New(rand.NewSource(seed)))
+}
 
-	// lookup the word chain
-	_prefix := wordsToPrefixString(prefix)
-	chain, found := m.Chain[_prefix]
+// Update adds a prefix + suffix to the markov model. The prefix must have Depth words.
+func (m *Markov) Update(prefix []dictionary.Word, suffix dictionary.Word) error {
+	if err := checkDepth(m.Depth); err != nil {
+		return err
+	}
+	if len(prefix) != m.Depth {
+		return fmt.Errorf("%w: a prefix of %v words in a model of depth %v", ErrDepthMismatch, len(prefix), m.Depth)
+	}
 
-	if found {
-		idx := 0
-		max := m.Random.Intn(len(chain.Words))
-		i := 0
-		// FIXME poor implementation of a random lookup ... need a better way
-		for p := range chain.Words {
-			if i == max {
-				idx = chain.Words[p].Idx
-				break
+	transition := make([]int, 0, len(prefix)+1)
+	for _, w := range prefix {
+		transition = append(transition, w.Idx)
+	}
+	return m.update(append(transition, suffix.Idx), 1)
+}
+
+// update adds a transition, the word indices of a prefix followed by its suffix
+func (m *Markov) update(transition []int, weight float64) error {
+	prefix, suffix := transition[:len(transition)-1], transition[len(transition)-1]
+	if err := m.Chain.Update(prefix, suffix, weight); err != nil {
+		return err
+	}
+
+	// the lower order chains, used when backing off during generation
+	if m.Backoff {
+		for i := 1; i < len(prefix); i++ {
+			if err := m.Chain.Update(prefix[i:], suffix, weight); err != nil {
+				return err
 			}
-			i = i + 1
 		}
+	}
 
-		word, _ := m.Dict.GetAt(idx)
-		return word
+	// the words following the first word of the prefix, mapped to it
+	if m.Backward && len(prefix) > 0 {
+		return m.Reverse.Update(transition[1:], prefix[0], weight)
 	}
+	return nil
+}
+
+// countEpsilon is the count below which a suffix is considered removed
+const countEpsilon float64 = 1e-9
 
-	// FIXME we should never get here ...
-	return dictionary.Word{}
+// Close writes the dictionary of the model to Name.dict, see dictionary.Open. The chains
+// and start prefixes are not written: Save writes the complete model, dictionary included,
+// and Load restores the words under the indices the chains refer to.
+func (m *Markov) Close() error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.Dict.Close()
 }
 
 // AddWord updates a word chain
 func (s *WordChain) AddWord(w dictionary.Word) {
+	s.AddWeighted(w, 1)
+}
+
+// AddWeighted updates a word chain, counting the word weight times
+func (s *WordChain) AddWeighted(w dictionary.Word, weight float64) {
 	words, found := s.Words[w.Word]
 	if found {
-		words.Count = words.Count + 1
+		words.Count = words.Count + weight
 	} else {
 		words = WordCount{
 			Idx:   w.Idx,
-			Count: 1,
+			Count: weight,
 		}
 	}
 	// update
 	s.Words[w.Word] = words
 }
+
+// Total returns the sum of the counts of all suffixes of the chain
+func (s *WordChain) Total() float64 {
+	total := 0.0
+	for _, w := range s.Words {
+		total = total + w.Count
+	}
+	return total
+}
+
+// Suffixes returns the suffixes of the chain ordered by their word index. The
+// order is stable, which keeps seeded generation reproducible.
+func (s *WordChain) Suffixes() []WordCount {
+	suffixes := make([]WordCount, 0, len(s.Words))
+	for _, w := range s.Words {
+		suffixes = append(suffixes, w)
+	}
+	sort.Slice(suffixes, func(i, j int) bool {
+		return suffixes[i].Idx < suffixes[j].Idx
+	})
+
+	return suffixes
+}
@@ -0,0 +1,12 @@
+package garkov
+
+import "time"
+
+// Metrics receives measurements of a model, see WithMetrics. The methods are called by
+// the goroutines that use the model and must be safe for concurrent use.
+type Metrics interface {
+	// Generated counts a sentence and the time it took to generate it
+	Generated(model string, latency time.Duration)
+	// Trained counts the tokens of a piece of text added to the model
+	Trained(model string, tokens int)
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package garkov
+
+import (
+	"io/ioutil"
+)
+
+// mapFile reads a file into memory, where files can not be mapped
+func mapFile(path string) ([]byte, error) {
+	return ioutil.ReadFile(path)
+}
+
+// unmapFile releases a file read by mapFile
+func unmapFile(data []byte) error {
+	return nil
+}
@@ -0,0 +1,120 @@
+package garkov
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// MultiModel keeps a model per author, or channel, and one dictionary the models share, so
+// each word is stored once however many models use it. The models are created on first use
+// with the options of NewMultiModel. It is safe for concurrent use.
+type MultiModel struct {
+	Name string                 // name of the collection
+	Dict *dictionary.Dictionary // the dictionary shared by the models
+
+	opts   []Option
+	models map[string]*Markov
+	mu     *sync.RWMutex // guards Dict, shared with the models
+	mmu    sync.RWMutex  // guards models
+}
+
+// NewMultiModel creates a collection without models. The models of the authors are
+// configured by the options, which must not set a storage: each model needs chains of its
+// own.
+func NewMultiModel(name string, opts ...Option) *MultiModel {
+	return &MultiModel{
+		Name:   name,
+		Dict:   dictionary.New(name),
+		opts:   opts,
+		models: make(map[string]*Markov),
+		mu:     new(sync.RWMutex),
+	}
+}
+
+// Model returns the model of the author, and creates it if there is none. The model is named
+// after the author and uses the dictionary of the collection.
+func (mm *MultiModel) Model(author string) *Markov {
+	mm.mmu.Lock()
+	defer mm.mmu.Unlock()
+
+	m, found := mm.models[author]
+	if !found {
+		m = New(author, mm.opts...)
+		m.Dict = mm.Dict
+		m.mu = mm.mu
+		m.shared = true
+		mm.models[author] = m
+	}
+	return m
+}
+
+// Get returns the model of the author
+func (mm *MultiModel) Get(author string) (*Markov, bool) {
+	mm.mmu.RLock()
+	defer mm.mmu.RUnlock()
+
+	m, found := mm.models[author]
+	return m, found
+}
+
+// Remove removes the model of the author. Its words stay in the dictionary.
+func (mm *MultiModel) Remove(author string) {
+	mm.mmu.Lock()
+	defer mm.mmu.Unlock()
+
+	delete(mm.models, author)
+}
+
+// Authors returns the sorted names of the authors with a model
+func (mm *MultiModel) Authors() []string {
+	mm.mmu.RLock()
+	defer mm.mmu.RUnlock()
+
+	authors := make([]string, 0, len(mm.models))
+	for author := range mm.models {
+		authors = append(authors, author)
+	}
+	sort.Strings(authors)
+
+	return authors
+}
+
+// Compact removes the words none of the models refers to from the dictionary, and renumbers
+// the models, see Markov.Compact. It returns the number of removed words. Models that were
+// removed from the collection are not renumbered and must not be used afterwards.
+func (mm *MultiModel) Compact() (int, error) {
+	mm.mmu.RLock()
+	defer mm.mmu.RUnlock()
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	models := make([]*Markov, 0, len(mm.models))
+	for _, m := range mm.models {
+		models = append(models, m)
+	}
+	return compact(mm.Dict, models)
+}
+
+// Build reads all text from r and updates the model of the author with it
+func (mm *MultiModel) Build(author string, r io.Reader) error {
+	return mm.Model(author).BuildReader(r)
+}
+
+// Feed updates the model of the author with a message, see Markov.Feed
+func (mm *MultiModel) Feed(author, text string) error {
+	return mm.Model(author).Feed(text)
+}
+
+// Generate generates a sentence in the style of the author. It returns ErrEmptyModel if
+// there is no model of the author.
+func (mm *MultiModel) Generate(author string, opts GenOptions) (string, error) {
+	m, found := mm.Get(author)
+	if !found {
+		return "", fmt.Errorf("%w: no model of '%v'", ErrEmptyModel, author)
+	}
+	return m.Generate(opts)
+}
@@ -0,0 +1,145 @@
+package garkov
+
+import (
+	"fmt"
+	"log/slog"
+	"math/rand"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// Option configures a model created by New, see the With functions. For the callers of the
+// former New(name, depth, mode), an int sets the depth and a Mode the mode of the model.
+type Option interface{}
+
+// apply configures the model with an option, it panics for a value that is not an option
+func apply(m *Markov, opt Option) {
+	switch opt := opt.(type) {
+	case func(m *Markov):
+		opt(m)
+	case int:
+		m.Depth = opt
+	case Mode:
+		m.Mode = opt
+	default:
+		panic(fmt.Sprintf("garkov: invalid option %T", opt))
+	}
+}
+
+// WithDepth sets the prefix size of the model. It must be within 1 and MaxDepth, training
+// a model of another depth fails with ErrInvalidDepth.
+func WithDepth(depth int) Option {
+	return func(m *Markov) {
+		m.Depth = depth
+	}
+}
+
+// WithMode builds the chains over words or characters
+func WithMode(mode Mode) Option {
+	return func(m *Markov) {
+		m.Mode = mode
+	}
+}
+
+// WithTokenizer replaces the default tokenizer of the language
+func WithTokenizer(t Tokenizer) Option {
+	return func(m *Markov) {
+		m.Tokenizer = t
+	}
+}
+
+// WithStopTokens sets the tokens that end a sentence in the default tokenizer
+func WithStopTokens(tokens ...string) Option {
+	return func(m *Markov) {
+		m.StopTokens = tokens
+	}
+}
+
+// WithRandom makes the model generate with random numbers of src, e.g. a source with a
+// fixed seed for reproducible output
+func WithRandom(src rand.Source) Option {
+	return func(m *Markov) {
+		m.Random = rand.New(src)
+	}
+}
+
+// WithStorage keeps the chains in chain, and the chain of the reversed text in reverse
+// unless it is nil. The start prefixes of chain are not read, use UseStorage for a storage
+// that already holds a model.
+func WithStorage(chain, reverse Storage) Option {
+	return func(m *Markov) {
+		m.Chain = chain
+		if reverse != nil {
+			m.Reverse = reverse
+		}
+	}
+}
+
+// WithDictionary makes the model use dict, e.g. one read by dictionary.Open for chains kept
+// in a database. The chains refer to the words by their index, they must have been built
+// with the same dictionary.
+func WithDictionary(dict *dictionary.Dictionary) Option {
+	return func(m *Markov) {
+		m.Dict = dict
+	}
+}
+
+// WithSmoothing sets the probability of unseen suffixes, e.g. WithSmoothing(AddK(1))
+func WithSmoothing(s Smoothing) Option {
+	return func(m *Markov) {
+		m.Smoothing = s
+	}
+}
+
+// WithLogger makes the model log training, saving and pruning to l
+func WithLogger(l *slog.Logger) Option {
+	return func(m *Markov) {
+		m.Logger = l
+	}
+}
+
+// WithProgress calls f periodically while the model is trained, e.g. to show a progress bar
+func WithProgress(f ProgressFunc) Option {
+	return func(m *Markov) {
+		m.OnProgress = f
+	}
+}
+
+// WithMetrics makes the model count the sentences it generates and the tokens it is
+// trained with in metrics
+func WithMetrics(metrics Metrics) Option {
+	return func(m *Markov) {
+		m.Metrics = metrics
+	}
+}
+
+// WithStarts selects the sentences whose first words become start prefixes
+func WithStarts(mode StartMode) Option {
+	return func(m *Markov) {
+		m.Starts = mode
+	}
+}
+
+// WithTagger tags the sentences of the training text, e.g. with the parts of speech of
+// their words, see Tagger
+func WithTagger(t Tagger) Option {
+	return func(m *Markov) {
+		m.Tagger = t
+	}
+}
+
+// WithSyllableCounter counts the syllables of words with c, e.g. for another language than
+// English, see GenOptions.Syllables
+func WithSyllableCounter(c SyllableCounter) Option {
+	return func(m *Markov) {
+		m.SyllableCounter = c
+	}
+}
+
+// WithFoldCase lower cases all words and restores their most frequent spelling in
+// generation
+func WithFoldCase() Option {
+	return func(m *Markov) {
+		m.FoldCase = true
+	}
+}
@@ -0,0 +1,29 @@
+package garkov
+
+import "testing"
+
+func TestNewDeprecatedArguments(t *testing.T) {
+	tests := []struct {
+		m     *Markov
+		depth int
+		mode  Mode
+	}{
+		{New("options"), DefaultDepth, WordLevel},
+		{New("options", WithDepth(3), WithMode(CharLevel)), 3, CharLevel},
+		{New("options", 3), 3, WordLevel},
+		{New("options", 4, CharLevel), 4, CharLevel},
+	}
+
+	for i, tt := range tests {
+		if tt.m.Depth != tt.depth || tt.m.Mode != tt.mode {
+			t.Errorf("%d: depth %v and mode %v, want %v and %v", i, tt.m.Depth, tt.m.Mode, tt.depth, tt.mode)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("New accepted a string as an option")
+		}
+	}()
+	New("options", "depth")
+}
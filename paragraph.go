@@ -0,0 +1,81 @@
+package garkov
+
+import (
+	"unicode/utf8"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// cohesionMinLength is the number of characters a word needs to be carried over to the
+// next sentence of a paragraph. Shorter words are mostly articles and prepositions.
+const cohesionMinLength int = 4
+
+// Paragraph creates a paragraph of several sentences. Consecutive sentences start with a
+// word of the previous one whenever possible, so that they feel related.
+func (m *Markov) Paragraph(sentences int) string {
+	return m.ParagraphWithOptions(sentences, GenOptions{}, true)
+}
+
+// ParagraphWithOptions creates a paragraph of several sentences. If cohesive is true, each
+// sentence starts with a word of the previous one, if the model has a start prefix containing
+// one. Otherwise, and for the first sentence, the start is random. opts.StartWith seeds the
+// first sentence. With a CharLimit, the paragraph ends before the first sentence that does
+// not fit.
+func (m *Markov) ParagraphWithOptions(sentences int, opts GenOptions, cohesive bool) string {
+
+	var seed []Token
+	if opts.StartWith != "" {
+		tokens, err := m.tokenize(opts.StartWith)
+		if err != nil {
+			return ""
+		}
+		seed = tokens
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var paragraph, sentence []dictionary.Word
+	for i := 0; i < sentences; i++ {
+		var start []dictionary.Word
+		if i == 0 && seed != nil {
+			start = m.seedStart(seed)
+		} else if cohesive && sentence != nil {
+			start = m.carryOver(sentence)
+		}
+		if start == nil {
+			start = m.randomStart(opts)
+		}
+		if start == nil {
+			break
+		}
+
+		sentence = m.generate(start, opts)
+		if !fits(m.toString(append(paragraph, sentence...)), opts) {
+			break
+		}
+		paragraph = append(paragraph, sentence...)
+	}
+
+	if len(paragraph) == 0 {
+		return ""
+	}
+	return m.toString(paragraph)
+}
+
+// carryOver returns a start prefix containing one of the words of the sentence, preferring
+// the words at its end, or nil if there is none. The words of the sentence's own start
+// prefix are skipped, they would only repeat it.
+func (m *Markov) carryOver(sentence []dictionary.Word) []dictionary.Word {
+	for i := len(sentence) - 1; i >= m.Depth; i-- {
+		w := sentence[i]
+		if w.Type != dictionary.WORD || utf8.RuneCountInString(w.Word) < cohesionMinLength {
+			continue
+		}
+
+		if start := m.startMatching([]Token{{Word: w.Word, Type: w.Type}}, false); start != nil {
+			return start
+		}
+	}
+	return nil
+}
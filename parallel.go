@@ -0,0 +1,122 @@
+package garkov
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// BuildParallel reads all text from r and updates the markov model with it, like
+// BuildContext. The paragraphs are tokenized by workers goroutines, workers <= 0 selects
+// one per CPU. The chain is still updated in the order of the text, so the model is the
+// same as the one built by BuildContext. A Tokenizer set on the model must be safe for
+// concurrent use.
+func (m *Markov) BuildParallel(ctx context.Context, r io.Reader, workers int) error {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	_, err := m.build(ctx, r, 1, workers, nil)
+	return err
+}
+
+// paragraph is a piece of text waiting to be tokenized by a worker of feedParallel
+type paragraph struct {
+	text   string
+	tokens chan []Token // receives the tokens of the text
+}
+
+// feedParallel tokenizes the paragraphs of the scanner with several workers and appends
+// them to the stream s in their original order. The progress is reported to prog.
+func (m *Markov) feedParallel(ctx context.Context, s *stream, scanner *bufio.Scanner, workers int, prog *progress) (BuildStats, error) {
+	var stats BuildStats
+
+	// every worker gets a tokenizer of its own, the default tokenizers are not shared
+	tokenizers := make([]Tokenizer, workers)
+	for i := range tokenizers {
+		t, err := m.tokenizer()
+		if err != nil {
+			return stats, err
+		}
+		tokenizers[i] = t
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	jobs := make(chan *paragraph)
+	ordered := make(chan *paragraph, 2*workers)
+	scanErr := make(chan error, 1)
+
+	var wg sync.WaitGroup
+	for _, t := range tokenizers {
+		wg.Add(1)
+		go func(t Tokenizer) {
+			defer wg.Done()
+			for p := range jobs {
+				p.tokens <- m.tag(t.Tokenize(p.text))
+			}
+		}(t)
+	}
+
+	// the reader hands each paragraph to the chain update first, which keeps their order,
+	// and then to the workers
+	go func() {
+		defer close(ordered)
+		defer close(jobs)
+
+		for scanner.Scan() {
+			text := scanner.Text()
+			if strings.TrimSpace(text) == "" {
+				continue
+			}
+
+			p := &paragraph{text: text, tokens: make(chan []Token, 1)}
+			select {
+			case ordered <- p:
+			case <-ctx.Done():
+				scanErr <- nil
+				return
+			}
+			select {
+			case jobs <- p:
+			case <-ctx.Done():
+				scanErr <- nil
+				return
+			}
+		}
+		scanErr <- scanner.Err()
+	}()
+
+	// stop the reader and the workers when done, or on the first error
+	defer func() {
+		cancel()
+		for range ordered {
+		}
+		wg.Wait()
+	}()
+
+	for p := range ordered {
+		var tokens []Token
+		select {
+		case tokens = <-p.tokens:
+		case <-ctx.Done():
+			return stats, ctx.Err()
+		}
+
+		m.startParagraph(s)
+		f, err := m.feedTokens(ctx, s, tokens)
+		stats.Sentences = stats.Sentences + f.Sentences
+		stats.Tokens = stats.Tokens + f.Tokens
+		if err != nil {
+			return stats, err
+		}
+		prog.report(m, stats.Tokens, false)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return stats, err
+	}
+	return stats, <-scanErr
+}
@@ -0,0 +1,50 @@
+package garkov
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestBuildParallel(t *testing.T) {
+	text := benchText(500)
+
+	m := New("serial")
+	if err := m.BuildReader(strings.NewReader(text)); err != nil {
+		t.Fatal(err)
+	}
+	p := New("parallel")
+	if err := p.BuildParallel(context.Background(), strings.NewReader(text), 4); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Dict.Size != m.Dict.Size || p.Chain.Len() != m.Chain.Len() || len(p.Start) != len(m.Start) {
+		t.Errorf("parallel model has %d words, %d chains and %d starts, want %d, %d and %d",
+			p.Dict.Size, p.Chain.Len(), len(p.Start), m.Dict.Size, m.Chain.Len(), len(m.Start))
+	}
+}
+
+func BenchmarkBuildParallel(b *testing.B) {
+	text := benchText(2000)
+
+	workerCounts := []int{1}
+	if runtime.NumCPU() > 1 {
+		workerCounts = append(workerCounts, runtime.NumCPU())
+	}
+
+	for _, workers := range workerCounts {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			b.SetBytes(int64(len(text)))
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				m := New("bench")
+				if err := m.BuildParallel(context.Background(), strings.NewReader(text), workers); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,511 @@
+package garkov
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+const (
+	// formatMagic identifies files written by Save
+	formatMagic string = "GARKOV"
+	// formatVersion is the version of the file format written by Save
+	formatVersion byte = 5
+)
+
+// Version is the version of the library, which is saved with the models
+const Version string = "0.9.0"
+
+// migrations upgrade a model decoded from a file of the format version of their index to
+// the next version. The legacy format without a version is migrated by decodeLegacy.
+var migrations = []func(mdl *binaryModel){
+	1: func(mdl *binaryModel) {
+		// version 1 stored integer counts
+		mdl.SuffixWeights = make([]float64, len(mdl.SuffixCounts))
+		for i, c := range mdl.SuffixCounts {
+			mdl.SuffixWeights[i] = float64(c)
+		}
+		mdl.SuffixCounts = nil
+	},
+	2: func(mdl *binaryModel) {
+		// version 3 added the library version, which is unknown
+	},
+	3: func(mdl *binaryModel) {
+		// version 4 added the checksum
+	},
+	4: func(mdl *binaryModel) {
+		// version 5 added the start counts, before a prefix was repeated for each sentence
+		// it began, which model merges
+	},
+}
+
+// binaryModel is the persisted form of a markov model. All chains and prefixes are
+// stored as flat arrays of word indices, which gob encodes as compact varints.
+type binaryModel struct {
+	Name      string
+	Depth     int
+	Mode      Mode
+	Backoff   bool
+	Backward  bool
+	Smoothing Smoothing
+	FoldCase  bool
+	Novelty   int
+	MaxWords  int
+	Language  string
+	Library   string // the version of the library that wrote the model, since version 3
+
+	Words  []string // the word vector
+	Types  []int    // word types, by word index
+	Counts []int    // word counts, by word index
+
+	Forms  map[string]map[string]int // spellings of case-folded words
+	Tags   map[string]map[string]int // tags of words, e.g. their parts of speech
+	Ngrams map[uint64]int            // hashes of the runs of Novelty+1 words
+
+	Start       []int     // start prefixes, Depth indices each
+	StartCounts []float64 // the count of each start prefix, since version 5
+
+	PrefixLen     []int     // length of the prefix of each chain
+	Prefixes      []int     // the prefixes of all chains
+	SuffixLen     []int     // number of suffixes of each chain
+	Suffixes      []int     // word indices of the suffixes of all chains
+	SuffixCounts  []int     // counts of the suffixes of all chains, version 1 only
+	SuffixWeights []float64 // counts of the suffixes of all chains, since version 2
+
+	Reverse flatChains // the chain of the reversed text
+}
+
+// flatChains are chains stored as flat arrays of word indices
+type flatChains struct {
+	PrefixLen     []int     // length of the prefix of each chain
+	Prefixes      []int     // the prefixes of all chains
+	SuffixLen     []int     // number of suffixes of each chain
+	Suffixes      []int     // word indices of the suffixes of all chains
+	SuffixWeights []float64 // counts of the suffixes of all chains
+}
+
+// legacyModel is the format written before the version header was introduced
+type legacyModel struct {
+	Name     string
+	Depth    int
+	Mode     Mode
+	Backoff  bool
+	Language string
+	Chain    map[string]legacyChain
+	Start    [][]int
+	Dict     *dictionary.Dictionary
+}
+
+type legacyChain struct {
+	Prefix []int
+	Words  map[string]legacyCount
+}
+
+type legacyCount struct {
+	Idx   int
+	Count int
+}
+
+// Save writes the complete model to a file. The model is written to a temporary file
+// first, which replaces the file when it is complete. The file is compressed if its
+// extension selects a compression, see CompressionFor. The dictionary is written in the
+// order of the word indices, Load restores every word under the index the chains refer to.
+func (m *Markov) Save(path string) error {
+	return m.SaveCompressed(path, CompressionFor(path))
+}
+
+// SaveCompressed writes the complete model to a file like Save, compressed with c. Load
+// detects the compression.
+func (m *Markov) SaveCompressed(path string, c Compression) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	// the permissions of a file created by os.Create
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	cw, err := compressor(w, c)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if err := m.encode(cw); err != nil {
+		f.Close()
+		return err
+	}
+	if err := cw.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+	m.log(slog.LevelInfo, "saved", "file", path, "compression", c)
+	return nil
+}
+
+// encode writes the format header and the model to w
+func (m *Markov) encode(w io.Writer) error {
+
+	mdl := m.binaryModel()
+
+	chains, err := flatten(m.Chain)
+	if err != nil {
+		return err
+	}
+	mdl.PrefixLen = chains.PrefixLen
+	mdl.Prefixes = chains.Prefixes
+	mdl.SuffixLen = chains.SuffixLen
+	mdl.Suffixes = chains.Suffixes
+	mdl.SuffixWeights = chains.SuffixWeights
+
+	if mdl.Reverse, err = flatten(m.Reverse); err != nil {
+		return err
+	}
+
+	if _, err := w.Write(append([]byte(formatMagic), formatVersion)); err != nil {
+		return err
+	}
+
+	// the checksum of the model follows it
+	cw := checksumWriter{w: w}
+	if err := gob.NewEncoder(&cw).Encode(&mdl); err != nil {
+		return err
+	}
+	_, err = w.Write(cw.sum())
+	return err
+}
+
+// binaryModel returns the settings, the dictionary and the start prefixes of the model in
+// their persisted form
+func (m *Markov) binaryModel() binaryModel {
+	mdl := binaryModel{
+		Name:      m.Name,
+		Depth:     m.Depth,
+		Mode:      m.Mode,
+		Backoff:   m.Backoff,
+		Backward:  m.Backward,
+		Smoothing: m.Smoothing,
+		FoldCase:  m.FoldCase,
+		Novelty:   m.Novelty,
+		MaxWords:  m.MaxWords,
+		Language:  m.Language,
+		Library:   Version,
+		Words:     m.Dict.V,
+		Forms:     m.Dict.Forms,
+		Tags:      m.Dict.Tags,
+		Ngrams:    m.ngrams,
+		Types:     make([]int, m.Dict.Len()),
+		Counts:    make([]int, m.Dict.Len()),
+		Start:     make([]int, 0, len(m.Start)*m.Depth),
+	}
+
+	m.Dict.Range(func(word dictionary.Word) bool {
+		mdl.Types[word.Idx] = word.Type
+		mdl.Counts[word.Idx] = word.Count
+		return true
+	})
+
+	for _, prefix := range m.Start {
+		mdl.Start = append(mdl.Start, prefix...)
+	}
+	mdl.StartCounts = m.StartCounts
+	return mdl
+}
+
+// Load reads a model that was written by Save, compressed or not.
+func Load(path string) (*Markov, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r, done, err := decompressor(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w: %v", path, ErrCorruptModel, err)
+	}
+	defer done()
+
+	m, err := decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+
+	return m, nil
+}
+
+// Migrate reads a model file of any format version and writes it to dst in the current
+// format, compressed as selected by the extension of dst. src and dst may be the same file.
+func Migrate(src, dst string) error {
+	m, err := Load(src)
+	if err != nil {
+		return err
+	}
+
+	// files written by SaveMapped stay mapped files
+	if _, ok := m.Chain.(*Compiled); ok {
+		return m.SaveMapped(dst)
+	}
+	return m.Save(dst)
+}
+
+// decode reads a model from r, in the current or the legacy format
+func decode(r *bufio.Reader) (*Markov, error) {
+
+	// files written by SaveMapped are read into memory and used like a mapping
+	if header, err := r.Peek(len(mappedMagic)); err == nil && string(header) == mappedMagic {
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return decodeMapped(data, true)
+	}
+
+	header, err := r.Peek(len(formatMagic) + 1)
+	if err != nil || string(header[:len(formatMagic)]) != formatMagic {
+		// files without a header were written in the legacy format
+		return decodeLegacy(r)
+	}
+
+	version := header[len(formatMagic)]
+	if version < 1 || version > formatVersion {
+		return nil, fmt.Errorf("%w %v, version %v of the library reads versions up to %v",
+			ErrIncompatibleVersion, version, Version, formatVersion)
+	}
+	r.Discard(len(header))
+
+	var mdl binaryModel
+	cr := checksumReader{r: r}
+	if err := gob.NewDecoder(&cr).Decode(&mdl); err != nil {
+		// the data of a file with a checksum was intact when it was written
+		if version >= 4 {
+			return nil, fmt.Errorf("%w: %v", ErrChecksum, err)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrCorruptModel, err)
+	}
+	if version >= 4 {
+		if err := cr.verify(); err != nil {
+			return nil, err
+		}
+	}
+
+	for v := version; v < formatVersion; v++ {
+		migrations[v](&mdl)
+	}
+
+	m, err := mdl.model()
+	if err != nil {
+		return nil, err
+	}
+
+	// the chains
+	chains := flatChains{
+		PrefixLen:     mdl.PrefixLen,
+		Prefixes:      mdl.Prefixes,
+		SuffixLen:     mdl.SuffixLen,
+		Suffixes:      mdl.Suffixes,
+		SuffixWeights: mdl.SuffixWeights,
+	}
+	if m.Chain, err = chains.expand(m.Dict); err != nil {
+		return nil, err
+	}
+	if err := addStarts(m.Chain, m.Start, m.StartCounts); err != nil {
+		return nil, err
+	}
+	if m.Reverse, err = mdl.Reverse.expand(m.Dict); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// model creates a model with the settings, the dictionary and the start prefixes of mdl
+func (mdl *binaryModel) model() (*Markov, error) {
+	m := New(mdl.Name, WithDepth(mdl.Depth), WithMode(mdl.Mode))
+	m.Backoff = mdl.Backoff
+	m.Backward = mdl.Backward
+	m.Smoothing = mdl.Smoothing
+	m.FoldCase = mdl.FoldCase
+	m.Novelty = mdl.Novelty
+	m.MaxWords = mdl.MaxWords
+	m.ngrams = mdl.Ngrams
+	m.Language = mdl.Language
+
+	// the dictionary
+	if len(mdl.Types) != len(mdl.Words) || len(mdl.Counts) != len(mdl.Words) {
+		return nil, fmt.Errorf("%w: invalid dictionary", ErrCorruptModel)
+	}
+	dict := &dictionary.Dictionary{
+		Name:  mdl.Name,
+		Size:  len(mdl.Words),
+		Words: make(dictionary.WordMap, len(mdl.Words)),
+		V:     mdl.Words,
+		Forms: mdl.Forms,
+		Tags:  mdl.Tags,
+	}
+	for i, w := range mdl.Words {
+		dict.Words[w] = dictionary.Word{Word: w, Idx: i, Type: mdl.Types[i], Count: mdl.Counts[i]}
+	}
+	m.Dict = dict
+
+	// the start prefixes
+	if err := checkDepth(m.Depth); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptModel, err)
+	}
+	if len(mdl.Start)%mdl.Depth != 0 || !validIndex(mdl.Start, dict) ||
+		(mdl.StartCounts != nil && len(mdl.StartCounts) != len(mdl.Start)/mdl.Depth) {
+		return nil, fmt.Errorf("%w: invalid start prefixes", ErrCorruptModel)
+	}
+	start := make([][]int, 0, len(mdl.Start)/mdl.Depth)
+	for i := 0; i < len(mdl.Start); i = i + mdl.Depth {
+		start = append(start, mdl.Start[i:i+mdl.Depth])
+	}
+	m.setStarts(start, mdl.StartCounts)
+
+	return m, nil
+}
+
+// flatten stores chains as flat arrays
+func flatten(chains Storage) (flatChains, error) {
+	var f flatChains
+	err := chains.Range(func(prefix []int, suffixes []WordCount) bool {
+		f.PrefixLen = append(f.PrefixLen, len(prefix))
+		f.Prefixes = append(f.Prefixes, prefix...)
+		f.SuffixLen = append(f.SuffixLen, len(suffixes))
+		for _, suffix := range suffixes {
+			f.Suffixes = append(f.Suffixes, suffix.Idx)
+			f.SuffixWeights = append(f.SuffixWeights, suffix.Count)
+		}
+		return true
+	})
+	return f, err
+}
+
+// expand restores the chains stored by flatten
+func (f flatChains) expand(dict *dictionary.Dictionary) (*Chains, error) {
+	if len(f.SuffixLen) != len(f.PrefixLen) || len(f.SuffixWeights) != len(f.Suffixes) ||
+		!validIndex(f.Prefixes, dict) || !validIndex(f.Suffixes, dict) {
+		return nil, fmt.Errorf("%w: invalid chains", ErrCorruptModel)
+	}
+
+	chains := NewChains()
+	p, s := 0, 0
+	for i := range f.PrefixLen {
+		if p+f.PrefixLen[i] > len(f.Prefixes) || s+f.SuffixLen[i] > len(f.Suffixes) {
+			return nil, fmt.Errorf("%w: invalid chains", ErrCorruptModel)
+		}
+
+		suffixes := make([]WordCount, 0, f.SuffixLen[i])
+		for j := s; j < s+f.SuffixLen[i]; j++ {
+			suffixes = append(suffixes, WordCount{Idx: f.Suffixes[j], Count: f.SuffixWeights[j]})
+		}
+		chains.PutChain(f.Prefixes[p:p+f.PrefixLen[i]], suffixes)
+
+		p = p + f.PrefixLen[i]
+		s = s + f.SuffixLen[i]
+	}
+
+	return chains, nil
+}
+
+// decodeLegacy reads a model written without a format header
+func decodeLegacy(r io.Reader) (*Markov, error) {
+
+	var mdl legacyModel
+	if err := gob.NewDecoder(r).Decode(&mdl); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptModel, err)
+	}
+
+	// any file without a header is read as the legacy format, its data is checked like
+	// that of the current format
+	if err := checkDepth(mdl.Depth); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptModel, err)
+	}
+	if !validDictionary(mdl.Dict) {
+		return nil, fmt.Errorf("%w: invalid dictionary", ErrCorruptModel)
+	}
+
+	m := New(mdl.Name, WithDepth(mdl.Depth), WithMode(mdl.Mode))
+	m.Backoff = mdl.Backoff
+	m.Language = mdl.Language
+	m.Dict = mdl.Dict
+
+	// the chain is keyed again from the prefix indices, which also migrates models
+	// written with keys made of the concatenated prefix words
+	for _, c := range mdl.Chain {
+		if len(c.Prefix) < 1 || len(c.Prefix) > mdl.Depth || !validIndex(c.Prefix, mdl.Dict) {
+			return nil, fmt.Errorf("%w: invalid chains", ErrCorruptModel)
+		}
+		suffixes := make([]WordCount, 0, len(c.Words))
+		for _, suffix := range c.Words {
+			if !validIndex([]int{suffix.Idx}, mdl.Dict) {
+				return nil, fmt.Errorf("%w: invalid chains", ErrCorruptModel)
+			}
+			suffixes = append(suffixes, WordCount{Idx: suffix.Idx, Count: float64(suffix.Count)})
+		}
+		m.Chain.PutChain(c.Prefix, suffixes)
+	}
+
+	// the legacy format repeats a start prefix for each sentence it began
+	for _, prefix := range mdl.Start {
+		if len(prefix) != mdl.Depth || !validIndex(prefix, mdl.Dict) {
+			return nil, fmt.Errorf("%w: invalid start prefixes", ErrCorruptModel)
+		}
+	}
+	m.setStarts(mdl.Start, nil)
+	if err := addStarts(m.Chain, m.Start, m.StartCounts); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// validIndex checks that all indices refer to words of the dictionary
+func validIndex(idx []int, dict *dictionary.Dictionary) bool {
+	for _, i := range idx {
+		if i < 0 || i >= len(dict.V) {
+			return false
+		}
+	}
+	return true
+}
+
+// validDictionary checks that every word of the word vector is in the map of the dictionary,
+// under its index
+func validDictionary(dict *dictionary.Dictionary) bool {
+	if dict == nil || len(dict.Words) != len(dict.V) {
+		return false
+	}
+	for i, w := range dict.V {
+		if word, found := dict.Words[w]; !found || word.Idx != i {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,184 @@
+package garkov
+
+import (
+	"encoding/gob"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// benchText returns a text of n sentences whose words follow a Zipf distribution over a
+// vocabulary of 5000 words, like the words of natural text
+func benchText(n int) string {
+	r := rand.New(rand.NewSource(1))
+	zipf := rand.NewZipf(r, 1.1, 1, 4999)
+	ends := []string{".", ".", ".", "!", "?"}
+
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		words := 5 + r.Intn(15)
+		for j := 0; j < words; j++ {
+			if j > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString("w")
+			b.WriteString(strconv.FormatUint(zipf.Uint64(), 10))
+		}
+		b.WriteString(ends[r.Intn(len(ends))])
+		b.WriteString(" ")
+		if i%10 == 9 {
+			b.WriteString("\n\n")
+		}
+	}
+	return b.String()
+}
+
+var (
+	benchOnce  sync.Once
+	benchModel *Markov
+)
+
+// newBenchModel returns a model of 10000 sentences, built once for all benchmarks
+func newBenchModel(b *testing.B) *Markov {
+	benchOnce.Do(func() {
+		benchModel = New("bench")
+		if err := benchModel.BuildReader(strings.NewReader(benchText(10000))); err != nil {
+			b.Fatal(err)
+		}
+	})
+	return benchModel
+}
+
+func TestSaveLoad(t *testing.T) {
+	m := New("saved")
+	if err := m.BuildReader(strings.NewReader(benchText(100))); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "saved.gk")
+	if err := m.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Dict.Size != m.Dict.Size || loaded.Chain.Len() != m.Chain.Len() {
+		t.Errorf("loaded %d words and %d chains, want %d and %d",
+			loaded.Dict.Size, loaded.Chain.Len(), m.Dict.Size, m.Chain.Len())
+	}
+	if err := loaded.Validate(); err != nil {
+		t.Errorf("invalid model after Load: %v", err)
+	}
+}
+
+// legacyFile writes a model in the format without a header and returns its path
+func legacyFile(t *testing.T, mdl legacyModel) string {
+	path := filepath.Join(t.TempDir(), "legacy.gk")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := gob.NewEncoder(f).Encode(&mdl); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadLegacy(t *testing.T) {
+	// a model of "a b c." with the words ".", "a", "b" and "c"
+	legacy := func() legacyModel {
+		dict := dictionary.New("legacy")
+		for _, w := range []string{"a", "b", "c"} {
+			dict.Add(w)
+		}
+		return legacyModel{
+			Name:  "legacy",
+			Depth: 2,
+			Chain: map[string]legacyChain{
+				"1:2": {Prefix: []int{1, 2}, Words: map[string]legacyCount{"c": {Idx: 3, Count: 1}}},
+				"2:3": {Prefix: []int{2, 3}, Words: map[string]legacyCount{".": {Idx: 0, Count: 1}}},
+			},
+			Start: [][]int{{1, 2}},
+			Dict:  dict,
+		}
+	}
+
+	m, err := Load(legacyFile(t, legacy()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Validate(); err != nil {
+		t.Errorf("invalid model after Load: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		corrupt func(mdl *legacyModel)
+	}{
+		{"depth 0", func(mdl *legacyModel) { mdl.Depth = 0 }},
+		{"no dictionary", func(mdl *legacyModel) { mdl.Dict = nil }},
+		{"words out of order", func(mdl *legacyModel) { mdl.Dict.V[1], mdl.Dict.V[2] = mdl.Dict.V[2], mdl.Dict.V[1] }},
+		{"unknown prefix word", func(mdl *legacyModel) { mdl.Chain["1:2"].Prefix[1] = 7 }},
+		{"long prefix", func(mdl *legacyModel) { mdl.Chain["1:2:3"] = legacyChain{Prefix: []int{1, 2, 3}} }},
+		{"unknown suffix", func(mdl *legacyModel) { mdl.Chain["1:2"].Words["c"] = legacyCount{Idx: -1, Count: 1} }},
+		{"short start", func(mdl *legacyModel) { mdl.Start = [][]int{{1}} }},
+		{"unknown start word", func(mdl *legacyModel) { mdl.Start = [][]int{{1, 9}} }},
+	}
+
+	for _, tt := range tests {
+		mdl := legacy()
+		tt.corrupt(&mdl)
+		if _, err := Load(legacyFile(t, mdl)); !errors.Is(err, ErrCorruptModel) {
+			t.Errorf("%s: got %v, want ErrCorruptModel", tt.name, err)
+		}
+	}
+}
+
+func BenchmarkLoad(b *testing.B) {
+	m := newBenchModel(b)
+
+	b.Run("gob", func(b *testing.B) {
+		path := filepath.Join(b.TempDir(), "bench.gk")
+		if err := m.Save(path); err != nil {
+			b.Fatal(err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.SetBytes(info.Size())
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			if _, err := Load(path); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("json", func(b *testing.B) {
+		data, err := m.MarshalJSON()
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			if err := New("bench").UnmarshalJSON(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
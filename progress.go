@@ -0,0 +1,86 @@
+package garkov
+
+import (
+	"bytes"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// ProgressInterval is the minimum time between two calls of the ProgressFunc of a model
+// while it is trained
+const ProgressInterval time.Duration = 500 * time.Millisecond
+
+// Progress is the state of training reported to a ProgressFunc
+type Progress struct {
+	Lines  int // lines of text read
+	Tokens int // words and punctuation marks added to the model
+	Chains int // prefixes in the chain of the model
+}
+
+// ProgressFunc receives the progress of training, see WithProgress. It is called at most
+// every ProgressInterval while a text is read and once when it is complete.
+type ProgressFunc func(p Progress)
+
+// progress tracks a build for the ProgressFunc of the model. BuildDir shares one between
+// the files it reads.
+type progress struct {
+	f      ProgressFunc
+	lines  int64     // the lines read, updated atomically as the text may be read by a goroutine of its own
+	tokens int       // the tokens of the texts completed before
+	next   time.Time // the earliest time of the next report
+}
+
+// newProgress returns the progress of a new build
+func (m *Markov) newProgress() *progress {
+	return &progress{f: m.OnProgress}
+}
+
+// reader returns a reader of r that counts the lines read
+func (p *progress) reader(r io.Reader) io.Reader {
+	if p.f == nil {
+		return r
+	}
+	return &lineCounter{r: r, lines: &p.lines}
+}
+
+// report calls the ProgressFunc with the tokens added by the current text, unless the
+// last call was less than ProgressInterval ago and final is not set
+func (p *progress) report(m *Markov, tokens int, final bool) {
+	if p.f == nil {
+		return
+	}
+
+	now := time.Now()
+	if !final && now.Before(p.next) {
+		return
+	}
+	p.next = now.Add(ProgressInterval)
+
+	p.f(Progress{
+		Lines:  int(atomic.LoadInt64(&p.lines)),
+		Tokens: p.tokens + tokens,
+		Chains: m.Chain.Len(),
+	})
+}
+
+// lineCounter counts the lines read from r. The last line counts even without a line break.
+type lineCounter struct {
+	r       io.Reader
+	lines   *int64
+	partial bool // the data read so far ends within a line
+}
+
+// Read reads from r and counts the line breaks
+func (l *lineCounter) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	if n > 0 {
+		atomic.AddInt64(l.lines, int64(bytes.Count(p[:n], []byte{'\n'})))
+		l.partial = p[n-1] != '\n'
+	}
+	if err == io.EOF && l.partial {
+		atomic.AddInt64(l.lines, 1)
+		l.partial = false
+	}
+	return n, err
+}
@@ -0,0 +1,116 @@
+// Package prometheus exports the metrics of markov models to Prometheus: the sentences
+// generated and the time they took, the tokens trained, and the size of the dictionary and
+// of the chains of each model.
+//
+//	c := prometheus.NewCollector("garkov")
+//	c.Add(m)
+//	promclient.MustRegister(c)
+package prometheus
+
+import (
+	"sync"
+	"time"
+
+	promclient "github.com/prometheus/client_golang/prometheus"
+
+	"github.com/mickuehl/garkov"
+)
+
+// Collector is a prometheus.Collector of the metrics of markov models, and the
+// garkov.Metrics the models report to
+type Collector struct {
+	generated *promclient.CounterVec
+	latency   *promclient.HistogramVec
+	tokens    *promclient.CounterVec
+	words     *promclient.Desc
+	chains    *promclient.Desc
+
+	mu     sync.RWMutex
+	models map[string]*garkov.Markov // the models whose size is collected
+}
+
+var _ garkov.Metrics = (*Collector)(nil)
+var _ promclient.Collector = (*Collector)(nil)
+
+// NewCollector creates a collector of metrics whose names start with namespace
+func NewCollector(namespace string) *Collector {
+	labels := []string{"model"}
+
+	return &Collector{
+		generated: promclient.NewCounterVec(promclient.CounterOpts{
+			Namespace: namespace,
+			Name:      "sentences_generated_total",
+			Help:      "Number of sentences generated.",
+		}, labels),
+		latency: promclient.NewHistogramVec(promclient.HistogramOpts{
+			Namespace: namespace,
+			Name:      "generation_duration_seconds",
+			Help:      "Time it took to generate a sentence.",
+			Buckets:   promclient.ExponentialBuckets(0.00001, 4, 10),
+		}, labels),
+		tokens: promclient.NewCounterVec(promclient.CounterOpts{
+			Namespace: namespace,
+			Name:      "training_tokens_total",
+			Help:      "Number of words and punctuation marks the model was trained with.",
+		}, labels),
+		words: promclient.NewDesc(promclient.BuildFQName(namespace, "", "dictionary_words"),
+			"Number of words in the dictionary.", labels, nil),
+		chains: promclient.NewDesc(promclient.BuildFQName(namespace, "", "chains"),
+			"Number of prefixes in the chain.", labels, nil),
+		models: make(map[string]*garkov.Markov),
+	}
+}
+
+// Add makes the model report to the collector and collects its size. It sets the Metrics
+// of the model, so it must be called before the model is used.
+func (c *Collector) Add(m *garkov.Markov) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	m.Metrics = c
+	c.models[m.Name] = m
+}
+
+// Remove stops collecting the size of the model with the name. Its counters are kept.
+func (c *Collector) Remove(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.models, name)
+}
+
+// Generated counts a sentence and the time it took to generate it
+func (c *Collector) Generated(model string, latency time.Duration) {
+	c.generated.WithLabelValues(model).Inc()
+	c.latency.WithLabelValues(model).Observe(latency.Seconds())
+}
+
+// Trained counts the tokens of a piece of text added to the model
+func (c *Collector) Trained(model string, tokens int) {
+	c.tokens.WithLabelValues(model).Add(float64(tokens))
+}
+
+// Describe sends the descriptors of the metrics
+func (c *Collector) Describe(ch chan<- *promclient.Desc) {
+	c.generated.Describe(ch)
+	c.latency.Describe(ch)
+	c.tokens.Describe(ch)
+	ch <- c.words
+	ch <- c.chains
+}
+
+// Collect sends the counters and the current size of the models
+func (c *Collector) Collect(ch chan<- promclient.Metric) {
+	c.generated.Collect(ch)
+	c.latency.Collect(ch)
+	c.tokens.Collect(ch)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for name, m := range c.models {
+		words, chains := m.Size()
+		ch <- promclient.MustNewConstMetric(c.words, promclient.GaugeValue, float64(words), name)
+		ch <- promclient.MustNewConstMetric(c.chains, promclient.GaugeValue, float64(chains), name)
+	}
+}
@@ -0,0 +1,273 @@
+package garkov
+
+import (
+	"log/slog"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// Prune removes all suffixes that followed their prefix less than minCount times, and the
+// chains and start prefixes that are left without suffixes. It returns the number of removed
+// suffixes.
+func (m *Markov) Prune(minCount int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	removed, err := m.Chain.Prune(minCount)
+	if err != nil {
+		return removed, err
+	}
+	if _, err := m.Reverse.Prune(minCount); err != nil {
+		return removed, err
+	}
+
+	// keep only start prefixes that can be continued
+	var start [][]int
+	var counts []float64
+	for i, prefix := range m.Start {
+		if _, found := m.Chain.GetChain(prefix); found {
+			start = append(start, prefix)
+			counts = append(counts, m.startCount(i))
+		}
+	}
+	m.setStarts(start, counts)
+
+	m.log(slog.LevelInfo, "pruned", "min_count", minCount, "removed", removed)
+	return removed, nil
+}
+
+// Compact removes the words no chain and no start prefix refers to, e.g. after Prune or
+// Forget, and renumbers the other words without gaps. The chains, the start prefixes and the
+// text passed to Feed are renumbered with them, so saved models get smaller. The runs
+// remembered for GenOptions.Novel can not be renumbered and are forgotten. It returns the
+// number of removed words. It must not be called while the model is trained. The models of
+// a MultiModel share their dictionary and return ErrSharedDictionary, use
+// MultiModel.Compact for them.
+func (m *Markov) Compact() (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.shared {
+		return 0, ErrSharedDictionary
+	}
+	return compact(m.Dict, []*Markov{m})
+}
+
+// compact removes the words of dict none of the models refers to, and renumbers the models.
+// The chains in memory are renumbered in copies that replace them once all are renumbered, so
+// neither the models nor the dictionary change if renumbering fails. Other storages are
+// renumbered in place. The caller holds the lock of the dictionary.
+func compact(dict *dictionary.Dictionary, models []*Markov) (int, error) {
+	for _, m := range models {
+		if _, ok := m.Chain.(*Compiled); ok {
+			return 0, ErrReadOnly
+		}
+	}
+
+	chains := make([]chainList, len(models))
+	reverses := make([]chainList, len(models))
+	for i, m := range models {
+		var err error
+		if chains[i], err = readChains(m.Chain); err != nil {
+			return 0, err
+		}
+		if reverses[i], err = readChains(m.Reverse); err != nil {
+			return 0, err
+		}
+	}
+
+	// the words in use, and the end tokens which generation needs
+	used := make([]bool, dict.Len())
+	for i, m := range models {
+		chains[i].mark(used)
+		reverses[i].mark(used)
+		for _, prefix := range m.Start {
+			markIndices(used, prefix)
+		}
+		m.stream.mark(used)
+		if end := dict.IndexOf(m.endToken()); end >= 0 {
+			used[end] = true
+		}
+	}
+
+	// the new index of each word, like dictionary.Compact numbers them
+	index := make([]int, len(used))
+	removed := 0
+	for i, u := range used {
+		if !u {
+			index[i] = -1
+			removed = removed + 1
+			continue
+		}
+		index[i] = i - removed
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+
+	renumbered := make([]Storage, len(models))
+	renumberedReverse := make([]Storage, len(models))
+	for i, m := range models {
+		var err error
+		if renumbered[i], err = renumberStorage(m.Chain, chains[i], index); err != nil {
+			return removed, err
+		}
+		if renumberedReverse[i], err = renumberStorage(m.Reverse, reverses[i], index); err != nil {
+			return removed, err
+		}
+	}
+
+	dict.Compact(func(w dictionary.Word) bool {
+		return used[w.Idx]
+	})
+	for i, m := range models {
+		m.Chain = renumbered[i]
+		m.Reverse = renumberedReverse[i]
+
+		start := make([][]int, len(m.Start))
+		for j, prefix := range m.Start {
+			start[j] = renumberIndices(prefix, index)
+		}
+		m.setStarts(start, m.StartCounts)
+		m.stream.renumber(index)
+		m.ngrams = nil
+
+		m.log(slog.LevelInfo, "compacted", "removed", removed, "words", dict.Len())
+	}
+	return removed, nil
+}
+
+// renumberStorage renumbers the chains of c, read into l, with index. Chains in memory are
+// written to a new storage, which is returned, other storages are renumbered in place.
+func renumberStorage(c Storage, l chainList, index []int) (Storage, error) {
+	if chains, ok := c.(*Chains); ok {
+		renumbered := NewShardedChains(len(chains.shards))
+		return renumbered, l.write(renumbered, index)
+	}
+	return c, l.renumber(c, index)
+}
+
+// chainList holds the chains and start prefixes of a storage while they are renumbered
+type chainList struct {
+	prefixes [][]int
+	suffixes [][]WordCount
+	starts   [][]int
+	counts   []float64 // the counts of the start prefixes
+}
+
+// readChains reads all chains and start prefixes of c
+func readChains(c Storage) (chainList, error) {
+	var l chainList
+	err := c.Range(func(prefix []int, suffixes []WordCount) bool {
+		l.prefixes = append(l.prefixes, prefix)
+		l.suffixes = append(l.suffixes, suffixes)
+		return true
+	})
+	if err != nil {
+		return l, err
+	}
+
+	err = c.IterStarts(func(prefix []int, count float64) bool {
+		l.starts = append(l.starts, prefix)
+		l.counts = append(l.counts, count)
+		return true
+	})
+	return l, err
+}
+
+// mark marks the words the chains refer to as used
+func (l chainList) mark(used []bool) {
+	for i, prefix := range l.prefixes {
+		markIndices(used, prefix)
+		for _, suffix := range l.suffixes[i] {
+			markIndices(used, []int{suffix.Idx})
+		}
+	}
+	for _, prefix := range l.starts {
+		markIndices(used, prefix)
+	}
+}
+
+// renumber replaces the chains and start prefixes of c with those of the list, renumbered
+// with index. All old chains are removed before the new ones are written, as the new prefix
+// of a chain may be the old prefix of another.
+func (l chainList) renumber(c Storage, index []int) error {
+	for _, prefix := range l.prefixes {
+		if err := c.PutChain(prefix, nil); err != nil {
+			return err
+		}
+	}
+	for i, prefix := range l.starts {
+		if err := c.UpdateStart(prefix, -l.counts[i]); err != nil {
+			return err
+		}
+	}
+	return l.write(c, index)
+}
+
+// write writes the chains and start prefixes of the list to c, renumbered with index
+func (l chainList) write(c Storage, index []int) error {
+	for i, prefix := range l.prefixes {
+		suffixes := make([]WordCount, len(l.suffixes[i]))
+		for j, suffix := range l.suffixes[i] {
+			suffixes[j] = WordCount{Idx: index[suffix.Idx], Count: suffix.Count}
+		}
+		if err := c.PutChain(renumberIndices(prefix, index), suffixes); err != nil {
+			return err
+		}
+	}
+	for i, prefix := range l.starts {
+		if err := c.UpdateStart(renumberIndices(prefix, index), l.counts[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mark marks the words of the text not yet added to the chains as used
+func (s *stream) mark(used []bool) {
+	for _, w := range s.window {
+		markIndices(used, []int{w.Idx})
+	}
+	markIndices(used, s.start)
+	markIndices(used, s.run)
+	markIndices(used, s.pending)
+	markIndices(used, s.pendingStarts)
+}
+
+// renumber renumbers the words of the stream with index
+func (s *stream) renumber(index []int) {
+	for i, w := range s.window {
+		if w.Idx >= 0 {
+			s.window[i].Idx = index[w.Idx]
+		}
+	}
+	s.start = renumberIndices(s.start, index)
+	s.run = renumberIndices(s.run, index)
+	s.pending = renumberIndices(s.pending, index)
+	s.pendingStarts = renumberIndices(s.pendingStarts, index)
+}
+
+// markIndices marks the word indices as used
+func markIndices(used []bool, indices []int) {
+	for _, idx := range indices {
+		if idx >= 0 && idx < len(used) {
+			used[idx] = true
+		}
+	}
+}
+
+// renumberIndices returns the word indices renumbered with index
+func renumberIndices(indices []int, index []int) []int {
+	if indices == nil {
+		return nil
+	}
+	renumbered := make([]int, len(indices))
+	for i, idx := range indices {
+		renumbered[i] = idx
+		if idx >= 0 && idx < len(index) {
+			renumbered[i] = index[idx]
+		}
+	}
+	return renumbered
+}
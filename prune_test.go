@@ -0,0 +1,66 @@
+package garkov
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCompact(t *testing.T) {
+	m := New("compact")
+	if err := m.BuildReader(strings.NewReader("The cat sat on the mat. The dog ate a bone.")); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Forget(strings.NewReader("The dog ate a bone.")); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := m.Compact()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed == 0 {
+		t.Error("no words removed")
+	}
+	if m.Dict.Exists("bone") {
+		t.Error("forgotten word 'bone' still in the dictionary")
+	}
+	if err := m.Validate(); err != nil {
+		t.Errorf("invalid model after Compact: %v", err)
+	}
+}
+
+func TestCompactMultiModel(t *testing.T) {
+	mm := NewMultiModel("multi")
+	a := mm.Model("a")
+	b := mm.Model("b")
+	if err := a.BuildReader(strings.NewReader("Apples are red and sweet. Pears are green.")); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.BuildReader(strings.NewReader("Dogs bark at night. Cats sleep all day.")); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Forget(strings.NewReader("Pears are green.")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := a.Compact(); !errors.Is(err, ErrSharedDictionary) {
+		t.Fatalf("Compact of a shared model: got %v, want ErrSharedDictionary", err)
+	}
+
+	removed, err := mm.Compact()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed == 0 {
+		t.Error("no words removed")
+	}
+	for name, m := range map[string]*Markov{"a": a, "b": b} {
+		if err := m.Validate(); err != nil {
+			t.Errorf("invalid model %s after Compact: %v", name, err)
+		}
+	}
+	if s := b.Sentence(0, 0); s != "Dogs bark at night." && s != "Cats sleep all day." {
+		t.Errorf("b generated %q after Compact", s)
+	}
+}
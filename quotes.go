@@ -0,0 +1,65 @@
+package garkov
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// elisions are the words starting with an apostrophe that is not an opening quote, in lower
+// case. The contractions the word tokenizer splits off, like 's or 'll, are among them.
+var elisions = map[string]bool{
+	"'s": true, "'re": true, "'ve": true, "'d": true, "'m": true, "'ll": true,
+	"'t": true, "'tis": true, "'twas": true, "'em": true, "'cause": true, "'til": true,
+	"'bout": true, "'round": true, "'n": true, "'n'": true, "'ol": true, "'y": true, "'ye": true,
+}
+
+// unquote removes the single quotes around quoted text from the words of a sentence, like
+// the double quotes that are filtered out, and keeps the apostrophes of elisions like 'tis,
+// of decades like '90s and of possessives like dogs'. quoted is the state of the text, true
+// within a quote. It carries over from sentence to sentence, a quote that is never closed
+// ends with the text.
+func unquote(words []string, quoted *bool) []string {
+	result := make([]string, 0, len(words))
+
+	for _, w := range words {
+		var previous string
+		if len(result) > 0 {
+			previous = result[len(result)-1]
+		}
+
+		switch {
+		case w == "'":
+			switch {
+			case *quoted:
+				// the closing quote
+				*quoted = false
+			case previous == "'n":
+				// rock 'n' roll
+				result[len(result)-1] = "'n'"
+			case strings.HasSuffix(strings.ToLower(previous), "s") && isWord(previous):
+				// the possessive of a plural
+				result = append(result, w)
+			default:
+				// an opening quote separated from the quoted word
+				*quoted = true
+			}
+
+		case len(w) > 1 && w[0] == '\'' && isWord(w[1:]) && !elisions[strings.ToLower(w)]:
+			// an opening quote the word tokenizer left attached to the word
+			*quoted = true
+			result = append(result, w[1:])
+
+		default:
+			result = append(result, w)
+		}
+	}
+
+	return result
+}
+
+// isWord is true if the token starts with a letter
+func isWord(w string) bool {
+	r, _ := utf8.DecodeRuneInString(w)
+	return unicode.IsLetter(r)
+}
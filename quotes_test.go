@@ -0,0 +1,40 @@
+package garkov
+
+import (
+	"strings"
+	"testing"
+)
+
+// words returns the words of the tokens
+func words(tokens []Token) []string {
+	w := make([]string, len(tokens))
+	for i, t := range tokens {
+		w[i] = t.Word
+	}
+	return w
+}
+
+func TestTokenizeQuotes(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"contraction", "I don't know.", "I don't know ."},
+		{"nested quotes", `She said 'nested "quotes" are fun' today.`, "She said nested quotes are fun today ."},
+		{"unterminated quote", "It's the 'end of it. Then it goes on.", "It's the end of it . Then it goes on ."},
+		{"possessive plural", "The boys' dog barks.", "The boys' dog barks ."},
+		{"quoted word", "He said 'hi' to me.", "He said hi to me ."},
+		{"elision", "'Tis the season.", "'Tis the season ."},
+	}
+
+	tokenizer, err := NewTreebankTokenizer("en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		if got := strings.Join(words(tokenizer.Tokenize(tt.text)), " "); got != tt.want {
+			t.Errorf("%s: Tokenize(%q) = %q, want %q", tt.name, tt.text, got, tt.want)
+		}
+	}
+}
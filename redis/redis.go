@@ -0,0 +1,438 @@
+// Package redis keeps the chains of a markov model in Redis, so that several processes can
+// share one model and update it concurrently. Each chain is a hash that maps the word indices
+// of the suffixes to their counts, the start prefixes and their counts are one more hash.
+// The chains refer to the words by their index, so all processes must use the same
+// dictionary, e.g. by loading the same model.
+//
+//	client := goredis.NewClient(&goredis.Options{Addr: "localhost:6379"})
+//	err := m.UseStorage(redis.New(client, "garkov:model:"), nil)
+package redis
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/mickuehl/garkov"
+)
+
+// DefaultBatchSize is the number of updates sent in one pipeline if Storage.BatchSize is not set
+const DefaultBatchSize int = 1000
+
+// countEpsilon is the count below which a suffix is considered removed
+const countEpsilon float64 = 1e-9
+
+// scanCount is the number of keys requested by each SCAN
+const scanCount int64 = 1000
+
+// forgetScript decreases the count of a suffix and removes it when nothing is left
+var forgetScript = `
+local count = redis.call('HINCRBYFLOAT', KEYS[1], ARGV[1], ARGV[2])
+if tonumber(count) <= tonumber(ARGV[3]) then
+	redis.call('HDEL', KEYS[1], ARGV[1])
+end
+return count`
+
+// pruneScript removes the suffixes of a chain counted less than ARGV[1] times
+var pruneScript = `
+local removed = 0
+local counts = redis.call('HGETALL', KEYS[1])
+for i = 1, #counts, 2 do
+	if tonumber(counts[i + 1]) < tonumber(ARGV[1]) then
+		redis.call('HDEL', KEYS[1], counts[i])
+		removed = removed + 1
+	end
+end
+return removed`
+
+// Storage is a garkov.Storage in Redis. Updates are sent in a pipeline after BatchSize
+// updates, before the chains are read, and by Flush. Updates of several processes add up.
+type Storage struct {
+	BatchSize int          // number of updates sent in one pipeline, 0 selects DefaultBatchSize
+	Logger    *slog.Logger // receives the errors GetChain and Len can not return, nil discards them
+
+	client  goredis.UniversalClient
+	prefix  string            // prepended to the keys of the chains
+	pipe    goredis.Pipeliner // the pending updates, or nil
+	pending int               // the number of updates in pipe
+	ctx     context.Context   // the context of the commands
+	mu      sync.Mutex
+}
+
+var _ garkov.Storage = (*Storage)(nil)
+
+// New creates a storage of the chains whose keys start with prefix. The prefix of one model
+// must not start with the prefix of another one.
+func New(client goredis.UniversalClient, prefix string) *Storage {
+	return &Storage{
+		client: client,
+		prefix: prefix,
+		ctx:    context.Background(),
+	}
+}
+
+// Flush sends the pending updates
+func (s *Storage) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.flush()
+}
+
+// GetChain returns the suffixes of the chain of a prefix, ordered by their word index. A
+// chain that can not be read is not found, the error goes to the Logger.
+func (s *Storage) GetChain(prefix []int) ([]garkov.WordCount, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.flush(); err != nil {
+		s.logError("sending the updates failed", err)
+		return nil, false
+	}
+
+	counts, err := s.client.HGetAll(s.ctx, s.key(prefix)).Result()
+	if err != nil {
+		s.logError("reading a chain failed", err)
+		return nil, false
+	}
+	if len(counts) == 0 {
+		return nil, false
+	}
+
+	suffixes, err := parseCounts(counts)
+	if err != nil {
+		s.logError("reading a chain failed", err)
+		return nil, false
+	}
+	return suffixes, true
+}
+
+// Update counts the suffix of the prefix weight times, or removes that many counts if
+// weight is negative
+func (s *Storage) Update(prefix []int, suffix int, weight float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, field := s.key(prefix), strconv.Itoa(suffix)
+	if weight >= 0 {
+		s.pipeline().HIncrByFloat(s.ctx, key, field, weight)
+	} else {
+		s.pipeline().Eval(s.ctx, forgetScript, []string{key}, field, weight, countEpsilon)
+	}
+	return s.written(1)
+}
+
+// PutChain replaces the chain of a prefix, or removes it if there are no suffixes. The
+// pending updates are sent first, then the chain is replaced in a transaction, so other
+// processes never see it emptied.
+func (s *Storage) PutChain(prefix []int, suffixes []garkov.WordCount) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.flush(); err != nil {
+		return err
+	}
+
+	key := s.key(prefix)
+	_, err := s.client.TxPipelined(s.ctx, func(pipe goredis.Pipeliner) error {
+		pipe.Del(s.ctx, key)
+		for _, suffix := range suffixes {
+			pipe.HIncrByFloat(s.ctx, key, strconv.Itoa(suffix.Idx), suffix.Count)
+		}
+		return nil
+	})
+	return err
+}
+
+// UpdateStart counts a start prefix weight times, or removes that many counts if weight
+// is negative
+func (s *Storage) UpdateStart(prefix []int, weight float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	field := string(garkov.EncodePrefix(prefix))
+	if weight >= 0 {
+		s.pipeline().HIncrByFloat(s.ctx, s.startsKey(), field, weight)
+	} else {
+		s.pipeline().Eval(s.ctx, forgetScript, []string{s.startsKey()}, field, weight, countEpsilon)
+	}
+	return s.written(1)
+}
+
+// IterStarts calls f for every start prefix and its count until f returns false
+func (s *Storage) IterStarts(f func(prefix []int, count float64) bool) error {
+	if err := s.Flush(); err != nil {
+		return err
+	}
+
+	counts, err := s.client.HGetAll(s.ctx, s.startsKey()).Result()
+	if err != nil {
+		return err
+	}
+	for field, value := range counts {
+		prefix, err := garkov.DecodePrefix([]byte(field))
+		if err != nil {
+			return err
+		}
+		count, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+
+		if !f(prefix, count) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Snapshot returns an in-memory copy of the chains and the start prefixes. Updates of other
+// processes while the copy is made may be missing from it.
+func (s *Storage) Snapshot() (garkov.Storage, error) {
+	snapshot := garkov.NewChains()
+	err := s.Range(func(prefix []int, suffixes []garkov.WordCount) bool {
+		snapshot.PutChain(prefix, suffixes)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = s.IterStarts(func(prefix []int, count float64) bool {
+		snapshot.UpdateStart(prefix, count)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// Len returns the number of chains, 0 if Redis can not be read
+func (s *Storage) Len() int {
+	n := 0
+	err := s.scan(func(keys []string) error {
+		n = n + len(keys)
+		return nil
+	})
+	if err != nil {
+		s.logError("counting the chains failed", err)
+		return 0
+	}
+	return n
+}
+
+// Range calls f for every chain until f returns false. The chains are in no particular
+// order, and a chain updated during Range may be missed or seen twice.
+func (s *Storage) Range(f func(prefix []int, suffixes []garkov.WordCount) bool) error {
+	err := s.scan(func(keys []string) error {
+		pipe := s.client.Pipeline()
+		cmds := make([]*goredis.MapStringStringCmd, len(keys))
+		for i, key := range keys {
+			cmds[i] = pipe.HGetAll(s.ctx, key)
+		}
+		if _, err := pipe.Exec(s.ctx); err != nil {
+			return err
+		}
+
+		for i, cmd := range cmds {
+			if len(cmd.Val()) == 0 {
+				continue
+			}
+
+			prefix, err := garkov.DecodePrefix([]byte(strings.TrimPrefix(keys[i], s.chainPrefix())))
+			if err != nil {
+				return err
+			}
+			suffixes, err := parseCounts(cmd.Val())
+			if err != nil {
+				return err
+			}
+
+			if !f(prefix, suffixes) {
+				return errStop
+			}
+		}
+		return nil
+	})
+	if err == errStop {
+		return nil
+	}
+	return err
+}
+
+// Prune removes the suffixes counted less than minCount times and the start prefixes
+// without a chain. It returns the number of removed suffixes.
+func (s *Storage) Prune(minCount int) (int, error) {
+	removed := 0
+	err := s.scan(func(keys []string) error {
+		pipe := s.client.Pipeline()
+		cmds := make([]*goredis.Cmd, len(keys))
+		for i, key := range keys {
+			cmds[i] = pipe.Eval(s.ctx, pruneScript, []string{key}, minCount)
+		}
+		if _, err := pipe.Exec(s.ctx); err != nil {
+			return err
+		}
+
+		for _, cmd := range cmds {
+			n, err := cmd.Int()
+			if err != nil {
+				return err
+			}
+			removed = removed + n
+		}
+		return nil
+	})
+	if err != nil {
+		return removed, err
+	}
+
+	// the start prefixes whose chain is gone
+	starts, err := s.client.HKeys(s.ctx, s.startsKey()).Result()
+	if err != nil || len(starts) == 0 {
+		return removed, err
+	}
+
+	pipe := s.client.Pipeline()
+	exists := make([]*goredis.IntCmd, len(starts))
+	for i, field := range starts {
+		exists[i] = pipe.Exists(s.ctx, s.chainPrefix()+field)
+	}
+	if _, err := pipe.Exec(s.ctx); err != nil {
+		return removed, err
+	}
+
+	var orphans []string
+	for i, cmd := range exists {
+		if cmd.Val() == 0 {
+			orphans = append(orphans, starts[i])
+		}
+	}
+	if len(orphans) > 0 {
+		err = s.client.HDel(s.ctx, s.startsKey(), orphans...).Err()
+	}
+	return removed, err
+}
+
+// errStop ends a scan early
+var errStop = errors.New("stop")
+
+// scan calls f with the keys of the chains, a batch at a time, after the pending updates
+// were sent
+func (s *Storage) scan(f func(keys []string) error) error {
+	if err := s.Flush(); err != nil {
+		return err
+	}
+
+	var cursor uint64
+	for {
+		keys, next, err := s.client.Scan(s.ctx, cursor, escapePattern(s.chainPrefix())+"*", scanCount).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if err := f(keys); err != nil {
+				return err
+			}
+		}
+
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// pipeline returns the pipeline of the pending updates, and starts one if there is none
+func (s *Storage) pipeline() goredis.Pipeliner {
+	if s.pipe == nil {
+		s.pipe = s.client.Pipeline()
+	}
+	return s.pipe
+}
+
+// written counts n updates and sends the pipeline when the batch is full
+func (s *Storage) written(n int) error {
+	batchSize := s.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	s.pending = s.pending + n
+	if s.pending < batchSize {
+		return nil
+	}
+	return s.flush()
+}
+
+// flush sends the pending updates, if any
+func (s *Storage) flush() error {
+	if s.pipe == nil {
+		return nil
+	}
+
+	_, err := s.pipe.Exec(s.ctx)
+	s.pipe = nil
+	s.pending = 0
+	return err
+}
+
+// key returns the key of the chain of a prefix
+func (s *Storage) key(prefix []int) string {
+	return s.chainPrefix() + string(garkov.EncodePrefix(prefix))
+}
+
+// chainPrefix is the start of the keys of the chains
+func (s *Storage) chainPrefix() string {
+	return s.prefix + "c:"
+}
+
+// startsKey is the key of the hash of the start prefixes
+func (s *Storage) startsKey() string {
+	return s.prefix + "starts"
+}
+
+// parseCounts returns the suffixes of a chain hash, ordered by their word index
+func parseCounts(counts map[string]string) ([]garkov.WordCount, error) {
+	suffixes := make([]garkov.WordCount, 0, len(counts))
+	for field, value := range counts {
+		idx, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, err
+		}
+		count, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, err
+		}
+		suffixes = append(suffixes, garkov.WordCount{Idx: idx, Count: count})
+	}
+	sort.Slice(suffixes, func(i, j int) bool {
+		return suffixes[i].Idx < suffixes[j].Idx
+	})
+	return suffixes, nil
+}
+
+// escapePattern escapes the special characters of a SCAN pattern
+func escapePattern(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]\`, r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// logError writes an error to the logger of the storage, if it has one
+func (s *Storage) logError(msg string, err error) {
+	if s.Logger != nil {
+		s.Logger.Error(msg, "error", err)
+	}
+}
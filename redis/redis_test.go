@@ -0,0 +1,97 @@
+package redis
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/mickuehl/garkov"
+	"github.com/mickuehl/garkov/storagetest"
+)
+
+// client connects to a Redis server, closed when the test ends
+func client(t *testing.T, server *miniredis.Miniredis) *goredis.Client {
+	c := goredis.NewClient(&goredis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestStorage(t *testing.T) {
+	server := miniredis.RunT(t)
+	storagetest.Run(t, func(t *testing.T) garkov.Storage {
+		s := New(client(t, server), "garkov:"+t.Name()+":")
+		s.BatchSize = 2
+		return s
+	})
+}
+
+func TestConcurrentUpdate(t *testing.T) {
+	server := miniredis.RunT(t)
+	storages := []*Storage{
+		New(client(t, server), "garkov:shared:"),
+		New(client(t, server), "garkov:shared:"),
+	}
+
+	var wg sync.WaitGroup
+	for i, s := range storages {
+		wg.Add(1)
+		go func(i int, s *Storage) {
+			defer wg.Done()
+			s.BatchSize = 7
+			for j := 0; j < 100; j++ {
+				if err := s.Update([]int{1, 2}, j%2, 1); err != nil {
+					t.Error(err)
+					return
+				}
+				if err := s.Update([]int{1, 2}, 9, float64(i+1)); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+			if err := s.Flush(); err != nil {
+				t.Error(err)
+			}
+		}(i, s)
+	}
+	wg.Wait()
+
+	want := []garkov.WordCount{{Idx: 0, Count: 100}, {Idx: 1, Count: 100}, {Idx: 9, Count: 300}}
+	for i, s := range storages {
+		suffixes, found := s.GetChain([]int{1, 2})
+		if !found || len(suffixes) != len(want) {
+			t.Fatalf("GetChain of client %d = %v, %v, want %v", i, suffixes, found, want)
+		}
+		for j := range want {
+			if suffixes[j] != want[j] {
+				t.Errorf("GetChain of client %d = %v, want %v", i, suffixes, want)
+				break
+			}
+		}
+	}
+}
+
+func TestGetChainLogsErrors(t *testing.T) {
+	var log bytes.Buffer
+	server := miniredis.RunT(t)
+	s := New(client(t, server), "garkov:")
+	s.Logger = slog.New(slog.NewTextHandler(&log, nil))
+	if err := s.Update([]int{1, 2}, 3, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	if _, found := s.GetChain([]int{1, 2}); found {
+		t.Error("GetChain found a chain without a server")
+	}
+	if !strings.Contains(log.String(), "reading a chain failed") {
+		t.Errorf("GetChain logged %q", log.String())
+	}
+}
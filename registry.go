@@ -0,0 +1,159 @@
+package garkov
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FileExtension is the extension of the model files of a Registry
+const FileExtension string = ".garkov"
+
+// Registry holds a collection of models by their name. It is safe for concurrent use.
+type Registry struct {
+	Logger *slog.Logger // receives the models loaded by LoadDir and Watch and the files that fail, nil discards them
+
+	models map[string]*Markov
+	mu     sync.RWMutex // guards models
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		models: make(map[string]*Markov),
+	}
+}
+
+// Add registers a model under the given name, replacing a model of the same name
+func (r *Registry) Add(name string, m *Markov) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.models[name] = m
+}
+
+// Remove removes the model of the given name
+func (r *Registry) Remove(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.models, name)
+}
+
+// Get returns the model of the given name
+func (r *Registry) Get(name string) (*Markov, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	m, found := r.models[name]
+	return m, found
+}
+
+// Lookup returns the model of the given name. An empty name selects the only model of a
+// registry that holds a single model, otherwise it returns ErrMissingModel. A name without
+// a model returns ErrUnknownModel.
+func (r *Registry) Lookup(name string) (*Markov, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if name == "" {
+		if len(r.models) != 1 {
+			return nil, fmt.Errorf("%w, the registry holds %v models", ErrMissingModel, len(r.models))
+		}
+		for _, m := range r.models {
+			return m, nil
+		}
+	}
+
+	m, found := r.models[name]
+	if !found {
+		return nil, fmt.Errorf("%w '%v'", ErrUnknownModel, name)
+	}
+	return m, nil
+}
+
+// GetOrCreate returns the model of the given name, or registers the model returned by create
+// if there is none
+func (r *Registry) GetOrCreate(name string, create func(name string) *Markov) *Markov {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, found := r.models[name]
+	if !found {
+		m = create(name)
+		r.models[name] = m
+	}
+	return m
+}
+
+// Names returns the sorted names of the models
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.models))
+	for name := range r.models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Stats returns the statistics of all models by their name
+func (r *Registry) Stats() map[string]Stats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := make(map[string]Stats, len(r.models))
+	for name, m := range r.models {
+		stats[name] = m.Stats()
+	}
+	return stats
+}
+
+// LoadDir loads all model files in dir, see FileExtension. Each model is registered under
+// the name of its file without the extension.
+func (r *Registry) LoadDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+FileExtension))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		m, err := Load(file)
+		if err != nil {
+			return err
+		}
+		r.Add(strings.TrimSuffix(filepath.Base(file), FileExtension), m)
+		r.log(slog.LevelInfo, "loaded model", "file", file)
+	}
+
+	return nil
+}
+
+// SaveDir saves all models to dir, each to a file named after the model, see FileExtension
+func (r *Registry) SaveDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	for _, name := range r.Names() {
+		m, found := r.Get(name)
+		if !found {
+			continue
+		}
+		if name != filepath.Base(name) {
+			return fmt.Errorf("invalid file name '%v'", name)
+		}
+		if err := m.Save(filepath.Join(dir, name+FileExtension)); err != nil {
+			return fmt.Errorf("%v: %w", name, err)
+		}
+	}
+
+	return nil
+}
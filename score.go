@@ -0,0 +1,84 @@
+package garkov
+
+import (
+	"math"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// Score returns the log-likelihood of a text under the model, i.e. the sum of the natural
+// logarithms of the probabilities of each word given its prefix. Without smoothing, words that
+// never followed their prefix in the training text make the score -Inf.
+func (m *Markov) Score(text string) float64 {
+	ll, _ := m.logLikelihood(text)
+	return ll
+}
+
+// Perplexity returns the perplexity of a text under the model. Low values indicate text that
+// is similar to the training text. It is +Inf for text that the model can not produce, or that
+// has fewer words than the prefix size.
+func (m *Markov) Perplexity(text string) float64 {
+	ll, n := m.logLikelihood(text)
+	if n == 0 {
+		return math.Inf(1)
+	}
+	return math.Exp(-ll / float64(n))
+}
+
+// logLikelihood returns the log-likelihood of the text and the number of scored transitions
+func (m *Markov) logLikelihood(text string) (float64, int) {
+
+	tokens, err := m.tokenize(text)
+	if err != nil {
+		return math.Inf(-1), 0
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	words := make([]dictionary.Word, len(tokens))
+	for i, t := range tokens {
+		if !t.Control {
+			t.Word = dictionary.Escape(t.Word)
+		}
+		w, found := m.Dict.Get(t.Word)
+		if !found && m.MaxWords > 0 {
+			w, found = m.Dict.Get(dictionary.UNKNOWN_TOKEN)
+		}
+		if !found {
+			w = dictionary.Word{Word: t.Word, Idx: -1}
+		}
+		words[i] = w
+	}
+
+	ll := 0.0
+	n := 0
+	for i := m.Depth; i < len(words); i++ {
+		ll = ll + math.Log(m.probability(words[i-m.Depth:i], words[i]))
+		n = n + 1
+	}
+
+	return ll, n
+}
+
+// probability returns the probability that the suffix follows the prefix
+func (m *Markov) probability(prefix []dictionary.Word, suffix dictionary.Word) float64 {
+	for _, w := range prefix {
+		if w.Idx < 0 {
+			return m.smoothed(0, 0)
+		}
+	}
+
+	suffixes, found := m.suffixesFor(prefix, nil)
+	if !found {
+		return m.smoothed(0, 0)
+	}
+
+	total := 0.0
+	for _, w := range suffixes {
+		total = total + w.Count
+	}
+	count, _ := findSuffix(suffixes, suffix.Idx)
+
+	return m.smoothed(count.Count, total)
+}
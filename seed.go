@@ -0,0 +1,90 @@
+package garkov
+
+import (
+	"strings"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// SentenceFrom creates a sentence that starts with, or continues, a seed word or phrase.
+// If the seed is shorter than the prefix size, a start prefix beginning with or containing
+// the seed is used. The result is empty if the seed does not end with a prefix of the model
+// and no start prefix contains its last word.
+func (m *Markov) SentenceFrom(seed string) string {
+	return m.SentenceWithOptions(GenOptions{StartWith: seed})
+}
+
+// seedStart returns the beginning of a sentence matching the seed tokens, or nil
+func (m *Markov) seedStart(seed []Token) []dictionary.Word {
+
+	// the tokenizer terminates the seed like any other sentence
+	for len(seed) > 0 && seed[len(seed)-1].Type == dictionary.SENTENCE_END {
+		seed = seed[:len(seed)-1]
+	}
+	if len(seed) == 0 {
+		return nil
+	}
+
+	words := make([]dictionary.Word, 0, len(seed))
+	for _, t := range seed {
+		if w, found := m.Dict.Get(m.entry(t)); found {
+			words = append(words, w)
+		}
+	}
+
+	// the end of the seed is a known prefix
+	if len(words) == len(seed) && len(words) >= m.Depth {
+		if _, found := m.suffixesFor(words[len(words)-m.Depth:], nil); found {
+			return words
+		}
+	}
+
+	// a start prefix beginning with the seed, or containing its last word
+	if start := m.startMatching(seed, true); start != nil {
+		return start
+	}
+	return m.startMatching(seed[len(seed)-1:], false)
+}
+
+// startMatching selects a random start prefix that begins with the seed or, if atBeginning
+// is false, contains the seed somewhere. Prefixes that began more sentences are more likely.
+// Words are compared ignoring their case.
+func (m *Markov) startMatching(seed []Token, atBeginning bool) []dictionary.Word {
+	var candidates []int
+
+	for i, prefix := range m.Start {
+		for offset := 0; offset+len(seed) <= len(prefix); offset++ {
+			if m.prefixMatches(prefix[offset:], seed) {
+				candidates = append(candidates, i)
+				break
+			}
+			if atBeginning {
+				break
+			}
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	return m.prefixWords(m.drawStartOf(candidates))
+}
+
+func (m *Markov) prefixMatches(prefix []int, seed []Token) bool {
+	for i := range seed {
+		if !strings.EqualFold(m.Dict.WordAt(prefix[i]), m.entry(seed[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+// prefixWords returns the dictionary words of a prefix
+func (m *Markov) prefixWords(prefix []int) []dictionary.Word {
+	words := make([]dictionary.Word, len(prefix))
+	for i := range prefix {
+		words[i], _ = m.Dict.GetAt(prefix[i])
+	}
+	return words
+}
@@ -0,0 +1,40 @@
+package garkov
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestSentenceFrom(t *testing.T) {
+	text := "The wife of Bath told a tale. A knight rode to Bath. Colour fades in the rain."
+	tests := []struct {
+		name      string
+		normalize bool
+		seed      string
+		want      string
+	}{
+		{"start", false, "The wife", "The wife of Bath told a tale."},
+		{"start of a prefix", false, "A", "A knight rode to Bath."},
+		{"within a start", false, "knight", "A knight rode to Bath."},
+		{"end of a prefix", false, "told a", "Told a tale."},
+		{"case", false, "the WIFE", "The wife of Bath told a tale."},
+		{"normalized", true, "COLOUR fades", "Colour fades in the rain."},
+		{"unknown", false, "dragon", ""},
+		{"within a sentence only", false, "tale", ""},
+	}
+
+	for _, tt := range tests {
+		m := New("seed", WithFoldCase(), WithRandom(rand.NewSource(1)))
+		if tt.normalize {
+			m.Dict.Normalizer = func(w string) string { return strings.Replace(w, "colour", "color", 1) }
+		}
+		if err := m.BuildReader(strings.NewReader(text)); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := m.SentenceFrom(tt.seed); got != tt.want {
+			t.Errorf("%s: SentenceFrom(%q) = %q, want %q", tt.name, tt.seed, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+// Package server serves the sentences of markov models over HTTP with JSON responses.
+//
+// The endpoints are
+//
+//	GET  /sentence?model=name&seed=words&temp=0.8&topk=10&topp=0.9&novel=true&min=4&max=60&chars=280
+//	POST /train?model=name, with the text as the request body
+//	GET  /stats?model=name
+//
+// The model parameter can be omitted if the server has a single model.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/mickuehl/garkov"
+)
+
+// DefaultMaxBody limits the size of the text of a POST /train request if Server.MaxBody is not set
+const DefaultMaxBody int64 = 10 * 1024 * 1024
+
+// Server is a http.Handler serving the models of a registry
+type Server struct {
+	Registry *garkov.Registry
+	NewModel func(name string) *garkov.Markov // creates the models POST /train adds to, nil rejects unknown models
+	MaxBody  int64                            // maximum size of the text of a POST /train request, 0 selects DefaultMaxBody
+
+	mux *http.ServeMux
+}
+
+// SentenceResponse is the response of GET /sentence
+type SentenceResponse struct {
+	Model    string `json:"model"`
+	Sentence string `json:"sentence"`
+}
+
+// ErrorResponse is the response of a failed request
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// New creates a server for the models of a registry. A nil registry is replaced by an empty one.
+func New(registry *garkov.Registry) *Server {
+	if registry == nil {
+		registry = garkov.NewRegistry()
+	}
+
+	s := Server{
+		Registry: registry,
+		mux:      http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("/sentence", s.sentence)
+	s.mux.HandleFunc("/train", s.train)
+	s.mux.HandleFunc("/stats", s.stats)
+
+	return &s
+}
+
+// ServeHTTP dispatches a request to the endpoints
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// model returns the model named by the model parameter of the request
+func (s *Server) model(r *http.Request) (*garkov.Markov, error) {
+	return s.Registry.Lookup(r.URL.Query().Get("model"))
+}
+
+func (s *Server) sentence(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %v not allowed", r.Method))
+		return
+	}
+
+	m, err := s.model(r)
+	if err != nil {
+		writeError(w, lookupStatus(err), err)
+		return
+	}
+
+	q := r.URL.Query()
+	opts := garkov.GenOptions{StartWith: q.Get("seed")}
+	if opts.Temperature, err = floatParam(q.Get("temp")); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if opts.TopK, err = intParam(q.Get("topk")); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if opts.TopP, err = floatParam(q.Get("topp")); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if v := q.Get("novel"); v != "" {
+		if opts.Novel, err = strconv.ParseBool(v); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid boolean '%v'", v))
+			return
+		}
+	}
+	if opts.MinWords, err = intParam(q.Get("min")); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if opts.MaxTokens, err = intParam(q.Get("max")); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if opts.CharLimit, err = intParam(q.Get("chars")); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	sentence, err := m.Generate(opts)
+	if err != nil {
+		writeError(w, generateStatus(err), err)
+		return
+	}
+
+	name := q.Get("model")
+	if name == "" {
+		name = m.Name
+	}
+
+	writeJSON(w, http.StatusOK, SentenceResponse{Model: name, Sentence: sentence})
+}
+
+// lookupStatus returns the status of an error of Registry.Lookup: a request without a model
+// is a bad request, a model that is not in the registry is not found
+func lookupStatus(err error) int {
+	if errors.Is(err, garkov.ErrMissingModel) {
+		return http.StatusBadRequest
+	}
+	return http.StatusNotFound
+}
+
+// generateStatus returns the status of a generation error: a seed the model does not know
+// and contradicting options are bad requests, a model without sentences is not found
+func generateStatus(err error) int {
+	switch {
+	case errors.Is(err, garkov.ErrUnknownPrefix), errors.Is(err, garkov.ErrInvalidOptions):
+		return http.StatusBadRequest
+	case errors.Is(err, garkov.ErrEmptyModel):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (s *Server) train(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %v not allowed", r.Method))
+		return
+	}
+
+	m, err := s.model(r)
+	if err != nil {
+		name := r.URL.Query().Get("model")
+		if s.NewModel == nil || name == "" {
+			writeError(w, lookupStatus(err), err)
+			return
+		}
+		m = s.Registry.GetOrCreate(name, s.NewModel)
+	}
+
+	maxBody := s.MaxBody
+	if maxBody <= 0 {
+		maxBody = DefaultMaxBody
+	}
+
+	if err := m.BuildContext(r.Context(), http.MaxBytesReader(w, r.Body, maxBody)); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, m.Stats())
+}
+
+func (s *Server) stats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %v not allowed", r.Method))
+		return
+	}
+
+	// the statistics of all models
+	if r.URL.Query().Get("model") == "" {
+		writeJSON(w, http.StatusOK, s.Registry.Stats())
+		return
+	}
+
+	m, err := s.model(r)
+	if err != nil {
+		writeError(w, lookupStatus(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, m.Stats())
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, ErrorResponse{Error: err.Error()})
+}
+
+// intParam parses an optional integer parameter
+func intParam(v string) (int, error) {
+	if v == "" {
+		return 0, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number '%v'", v)
+	}
+	return i, nil
+}
+
+// floatParam parses an optional decimal parameter
+func floatParam(v string) (float64, error) {
+	if v == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid number '%v'", v)
+	}
+	return f, nil
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mickuehl/garkov"
+)
+
+func TestSentence(t *testing.T) {
+	m := garkov.New("cats")
+	if err := m.BuildReader(strings.NewReader("The cat sat on the mat.")); err != nil {
+		t.Fatal(err)
+	}
+	registry := garkov.NewRegistry()
+	registry.Add("cats", m)
+	registry.Add("empty", garkov.New("empty"))
+	s := New(registry)
+
+	tests := []struct {
+		query  string
+		status int
+	}{
+		{"model=cats", http.StatusOK},
+		{"model=cats&seed=The+cat", http.StatusOK},
+		{"model=cats&seed=dragon", http.StatusBadRequest},
+		{"model=cats&temp=hot", http.StatusBadRequest},
+		{"model=empty", http.StatusNotFound},
+		{"model=dogs", http.StatusNotFound},
+		{"", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sentence?"+tt.query, nil))
+		if rec.Code != tt.status {
+			t.Errorf("GET /sentence?%v: status %v, want %v: %v", tt.query, rec.Code, tt.status, rec.Body)
+			continue
+		}
+		if rec.Code != http.StatusOK {
+			continue
+		}
+
+		var resp SentenceResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Sentence != "The cat sat on the mat." {
+			t.Errorf("GET /sentence?%v: sentence %q", tt.query, resp.Sentence)
+		}
+	}
+}
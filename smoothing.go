@@ -0,0 +1,47 @@
+package garkov
+
+import (
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// Smoothing assigns a probability to words that never followed a prefix in the training text
+type Smoothing struct {
+	K float64 // pseudo count added to the count of every word of the dictionary, 0 disables smoothing
+}
+
+// AddK returns additive smoothing with the pseudo count k. AddK(1) is Laplace smoothing.
+func AddK(k float64) Smoothing {
+	return Smoothing{K: k}
+}
+
+// unseenWord returns a random word of the dictionary that is not one of the suffixes, ordered
+// by their word index, and for which allow is true, if it is not nil
+func (m *Markov) unseenWord(suffixes []WordCount, allow func(idx int) bool) dictionary.Word {
+	if m.Dict.Len() == 0 {
+		return dictionary.Word{}
+	}
+
+	var word dictionary.Word
+	for i := 0; i < 100; i++ {
+		word, _ = m.Dict.GetAt(m.intn(m.Dict.Len()))
+		if _, found := findSuffix(suffixes, word.Idx); !found && (allow == nil || allow(word.Idx)) {
+			return word
+		}
+	}
+
+	return dictionary.Word{}
+}
+
+// smoothed returns the probability of a suffix seen count times after a prefix with the
+// given total count of suffixes
+func (m *Markov) smoothed(count, total float64) float64 {
+	k := m.Smoothing.K
+	if k <= 0 {
+		if total == 0 {
+			return 0
+		}
+		return count / total
+	}
+
+	return (count + k) / (total + k*float64(m.Dict.Len()))
+}
@@ -0,0 +1,455 @@
+// Package sqlite keeps the chains of a markov model in an SQLite database, for models that
+// are larger than the memory. The dictionary and the start prefixes stay in memory.
+//
+//	chains, err := sqlite.Open("model.db")
+//	if err != nil {
+//		...
+//	}
+//	defer chains.Close()
+//
+//	m := garkov.New("model", garkov.WithDepth(2))
+//	err = m.UseStorage(chains, nil)
+//
+// A model with a backward chain needs a second database for m.Reverse. The chains refer to
+// the words by their index, so a database reopened in another run needs the dictionary it
+// was built with: m.Close writes it, dictionary.Open and garkov.WithDictionary restore it.
+package sqlite
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	_ "github.com/mattn/go-sqlite3" // the sqlite3 driver
+
+	"github.com/mickuehl/garkov"
+)
+
+// DefaultBatchSize is the number of updates written in one transaction if Storage.BatchSize is not set
+const DefaultBatchSize int = 10000
+
+// countEpsilon is the count below which a suffix is considered removed
+const countEpsilon float64 = 1e-9
+
+const (
+	schemaSQL = `CREATE TABLE IF NOT EXISTS chains (
+		prefix BLOB NOT NULL,
+		suffix INTEGER NOT NULL,
+		count REAL NOT NULL,
+		PRIMARY KEY (prefix, suffix)
+	) WITHOUT ROWID`
+	startsSchemaSQL = `CREATE TABLE IF NOT EXISTS starts (
+		prefix BLOB NOT NULL PRIMARY KEY,
+		count REAL NOT NULL
+	) WITHOUT ROWID`
+	suffixesSQL = `SELECT suffix, count FROM chains WHERE prefix = ? ORDER BY suffix`
+	rangeSQL    = `SELECT prefix, suffix, count FROM chains ORDER BY prefix, suffix`
+	lenSQL      = `SELECT COUNT(DISTINCT prefix) FROM chains`
+	addSQL      = `INSERT INTO chains (prefix, suffix, count) VALUES (?, ?, ?)
+		ON CONFLICT (prefix, suffix) DO UPDATE SET count = count + excluded.count`
+	subtractSQL = `UPDATE chains SET count = count - ? WHERE prefix = ? AND suffix = ?`
+	cleanSQL    = `DELETE FROM chains WHERE prefix = ? AND suffix = ? AND count <= ?`
+	deleteSQL   = `DELETE FROM chains WHERE prefix = ?`
+	pruneSQL    = `DELETE FROM chains WHERE count < ?`
+
+	startsSQL   = `SELECT prefix, count FROM starts ORDER BY prefix`
+	addStartSQL = `INSERT INTO starts (prefix, count) VALUES (?, ?)
+		ON CONFLICT (prefix) DO UPDATE SET count = count + excluded.count`
+	subtractStartSQL = `UPDATE starts SET count = count - ? WHERE prefix = ?`
+	cleanStartSQL    = `DELETE FROM starts WHERE prefix = ? AND count <= ?`
+	pruneStartsSQL   = `DELETE FROM starts WHERE prefix NOT IN (SELECT prefix FROM chains)`
+)
+
+// querier runs queries on the database or in a transaction
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Storage is a garkov.Storage in an SQLite database. Updates are collected in a transaction
+// that is committed after BatchSize updates, before the chains are read, and by Flush and Close.
+type Storage struct {
+	BatchSize int          // number of updates written in one transaction, 0 selects DefaultBatchSize
+	Logger    *slog.Logger // receives the errors GetChain and Len can not return, nil discards them
+
+	db       *sql.DB
+	suffixes *sql.Stmt            // reads the suffixes of a prefix
+	tx       *sql.Tx              // the open transaction, or nil
+	stmts    map[string]*sql.Stmt // the statements prepared in tx
+	pending  int                  // the number of updates in tx
+	mu       sync.Mutex
+}
+
+var _ garkov.Storage = (*Storage)(nil)
+
+// Open opens the database at path, or creates it if it does not exist
+func Open(path string) (*Storage, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+
+	// the storage serializes its statements anyway, and a single connection sees its
+	// own transaction
+	db.SetMaxOpenConns(1)
+
+	for _, schema := range []string{schemaSQL, startsSchemaSQL} {
+		if _, err := db.Exec(schema); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("%v: %v", path, err)
+		}
+	}
+	suffixes, err := db.Prepare(suffixesSQL)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+
+	return &Storage{db: db, suffixes: suffixes}, nil
+}
+
+// Close commits the pending updates and closes the database
+func (s *Storage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.commit()
+	s.suffixes.Close()
+	if closeErr := s.db.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Flush commits the pending updates
+func (s *Storage) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.commit()
+}
+
+// GetChain returns the suffixes of the chain of a prefix, ordered by their word index. A
+// chain that can not be read is not found, the error goes to the Logger.
+func (s *Storage) GetChain(prefix []int) ([]garkov.WordCount, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.commit(); err != nil {
+		s.logError("commit failed", err)
+		return nil, false
+	}
+
+	rows, err := s.suffixes.Query(garkov.EncodePrefix(prefix))
+	if err != nil {
+		s.logError("reading a chain failed", err)
+		return nil, false
+	}
+	defer rows.Close()
+
+	var suffixes []garkov.WordCount
+	for rows.Next() {
+		var suffix garkov.WordCount
+		if err := rows.Scan(&suffix.Idx, &suffix.Count); err != nil {
+			s.logError("reading a chain failed", err)
+			return nil, false
+		}
+		suffixes = append(suffixes, suffix)
+	}
+	if err := rows.Err(); err != nil {
+		s.logError("reading a chain failed", err)
+		return nil, false
+	}
+	if len(suffixes) == 0 {
+		return nil, false
+	}
+	return suffixes, true
+}
+
+// Update counts the suffix of the prefix weight times, or removes that many counts if
+// weight is negative
+func (s *Storage) Update(prefix []int, suffix int, weight float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := garkov.EncodePrefix(prefix)
+	if weight >= 0 {
+		if _, err := s.exec(addSQL, k, suffix, weight); err != nil {
+			return err
+		}
+		return s.written(1)
+	}
+
+	if _, err := s.exec(subtractSQL, -weight, k, suffix); err != nil {
+		return err
+	}
+	if _, err := s.exec(cleanSQL, k, suffix, countEpsilon); err != nil {
+		return err
+	}
+	return s.written(1)
+}
+
+// PutChain replaces the chain of a prefix, or removes it if there are no suffixes
+func (s *Storage) PutChain(prefix []int, suffixes []garkov.WordCount) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := garkov.EncodePrefix(prefix)
+	if _, err := s.exec(deleteSQL, k); err != nil {
+		return err
+	}
+	for _, suffix := range suffixes {
+		if _, err := s.exec(addSQL, k, suffix.Idx, suffix.Count); err != nil {
+			return err
+		}
+	}
+	return s.written(len(suffixes) + 1)
+}
+
+// Len returns the number of chains, 0 if the database can not be read
+func (s *Storage) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.commit(); err != nil {
+		s.logError("commit failed", err)
+		return 0
+	}
+
+	n := 0
+	if err := s.db.QueryRow(lenSQL).Scan(&n); err != nil {
+		s.logError("counting the chains failed", err)
+		return 0
+	}
+	return n
+}
+
+// Range calls f for every chain until f returns false. The chains are ordered by the key
+// of their prefix.
+func (s *Storage) Range(f func(prefix []int, suffixes []garkov.WordCount) bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.commit(); err != nil {
+		return err
+	}
+	return rangeChains(s.db, f)
+}
+
+// UpdateStart counts a start prefix weight times, or removes that many counts if weight
+// is negative
+func (s *Storage) UpdateStart(prefix []int, weight float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := garkov.EncodePrefix(prefix)
+	if weight >= 0 {
+		if _, err := s.exec(addStartSQL, k, weight); err != nil {
+			return err
+		}
+		return s.written(1)
+	}
+
+	if _, err := s.exec(subtractStartSQL, -weight, k); err != nil {
+		return err
+	}
+	if _, err := s.exec(cleanStartSQL, k, countEpsilon); err != nil {
+		return err
+	}
+	return s.written(1)
+}
+
+// IterStarts calls f for every start prefix and its count until f returns false
+func (s *Storage) IterStarts(f func(prefix []int, count float64) bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.commit(); err != nil {
+		return err
+	}
+	return rangeStarts(s.db, f)
+}
+
+// Snapshot returns an in-memory copy of the chains and the start prefixes, read in one
+// transaction
+func (s *Storage) Snapshot() (garkov.Storage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.commit(); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	snapshot := garkov.NewChains()
+	err = rangeChains(tx, func(prefix []int, suffixes []garkov.WordCount) bool {
+		snapshot.PutChain(prefix, suffixes)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = rangeStarts(tx, func(prefix []int, count float64) bool {
+		snapshot.UpdateStart(prefix, count)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// Prune removes the suffixes counted less than minCount times and the start prefixes
+// without a chain. It returns the number of removed suffixes.
+func (s *Storage) Prune(minCount int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.exec(pruneSQL, float64(minCount))
+	if err != nil {
+		return 0, err
+	}
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if _, err := s.exec(pruneStartsSQL); err != nil {
+		return 0, err
+	}
+	return int(removed), s.commit()
+}
+
+// exec runs a statement in the open transaction, and begins one if there is none
+func (s *Storage) exec(query string, args ...interface{}) (sql.Result, error) {
+	if s.tx == nil {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return nil, err
+		}
+		s.tx = tx
+		s.stmts = make(map[string]*sql.Stmt)
+	}
+
+	stmt, found := s.stmts[query]
+	if !found {
+		var err error
+		if stmt, err = s.tx.Prepare(query); err != nil {
+			return nil, err
+		}
+		s.stmts[query] = stmt
+	}
+	return stmt.Exec(args...)
+}
+
+// written counts n updates and commits the transaction when the batch is full
+func (s *Storage) written(n int) error {
+	batchSize := s.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	s.pending = s.pending + n
+	if s.pending < batchSize {
+		return nil
+	}
+	return s.commit()
+}
+
+// commit commits the open transaction, if any
+func (s *Storage) commit() error {
+	if s.tx == nil {
+		return nil
+	}
+
+	err := s.tx.Commit()
+	s.tx = nil
+	s.stmts = nil
+	s.pending = 0
+	return err
+}
+
+// logError writes an error to the logger of the storage, if it has one
+func (s *Storage) logError(msg string, err error) {
+	if s.Logger != nil {
+		s.Logger.Error(msg, "error", err)
+	}
+}
+
+// rangeChains calls f for every chain read by q until f returns false
+func rangeChains(q querier, f func(prefix []int, suffixes []garkov.WordCount) bool) error {
+	rows, err := q.Query(rangeSQL)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var current []byte
+	var suffixes []garkov.WordCount
+
+	// call f with the collected chain
+	emit := func() (bool, error) {
+		if len(suffixes) == 0 {
+			return true, nil
+		}
+		prefix, err := garkov.DecodePrefix(current)
+		if err != nil {
+			return false, err
+		}
+		return f(prefix, suffixes), nil
+	}
+
+	for rows.Next() {
+		var k []byte
+		var suffix garkov.WordCount
+		if err := rows.Scan(&k, &suffix.Idx, &suffix.Count); err != nil {
+			return err
+		}
+
+		if !bytes.Equal(k, current) {
+			if more, err := emit(); !more || err != nil {
+				return err
+			}
+			current = k
+			suffixes = nil
+		}
+		suffixes = append(suffixes, suffix)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = emit()
+	return err
+}
+
+// rangeStarts calls f for every start prefix read by q until f returns false
+func rangeStarts(q querier, f func(prefix []int, count float64) bool) error {
+	rows, err := q.Query(startsSQL)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var k []byte
+		var count float64
+		if err := rows.Scan(&k, &count); err != nil {
+			return err
+		}
+
+		prefix, err := garkov.DecodePrefix(k)
+		if err != nil {
+			return err
+		}
+		if !f(prefix, count) {
+			return nil
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"bytes"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mickuehl/garkov"
+	"github.com/mickuehl/garkov/storagetest"
+)
+
+// open opens a database in the temporary directory of the test, closed when it ends
+func open(t *testing.T) *Storage {
+	s, err := Open(filepath.Join(t.TempDir(), "chains.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) garkov.Storage {
+		s := open(t)
+		s.BatchSize = 2
+		return s
+	})
+}
+
+func TestGetChainLogsErrors(t *testing.T) {
+	var log bytes.Buffer
+	s := open(t)
+	s.Logger = slog.New(slog.NewTextHandler(&log, nil))
+	if err := s.Update([]int{1, 2}, 3, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, found := s.GetChain([]int{1, 2}); found {
+		t.Error("GetChain found a chain in a closed database")
+	}
+	if !strings.Contains(log.String(), "reading a chain failed") {
+		t.Errorf("GetChain logged %q", log.String())
+	}
+}
@@ -0,0 +1,174 @@
+package garkov
+
+// startIndex finds the start prefixes of a model and draws them in proportion to their
+// counts. The counts are kept in a Fenwick tree, so updates and draws take logarithmic
+// time however many distinct sentence openers the model knows.
+type startIndex struct {
+	positions map[string]int // the index of each prefix in Markov.Start
+	tree      []float64      // tree[i] sums the i&-i counts up to the i-th, tree[0] is unused
+}
+
+// push appends the count of a new prefix
+func (t *startIndex) push(count float64) {
+	i := len(t.tree)
+	t.tree = append(t.tree, count+t.sum(i-1)-t.sum(i-i&-i))
+}
+
+// pop removes the count of the last prefix
+func (t *startIndex) pop() {
+	t.tree = t.tree[:len(t.tree)-1]
+}
+
+// add adds delta to the count of the prefix at index i
+func (t *startIndex) add(i int, delta float64) {
+	for i = i + 1; i < len(t.tree); i = i + i&-i {
+		t.tree[i] = t.tree[i] + delta
+	}
+}
+
+// sum returns the sum of the counts of the first n prefixes
+func (t *startIndex) sum(n int) float64 {
+	total := 0.0
+	for ; n > 0; n = n - n&-n {
+		total = total + t.tree[n]
+	}
+	return total
+}
+
+// search returns the index of the prefix whose counts, added to those of the prefixes
+// before it, first exceed x
+func (t *startIndex) search(x float64) int {
+	n := len(t.tree) - 1
+	step := 1
+	for step*2 <= n {
+		step = step * 2
+	}
+
+	pos := 0
+	for ; step > 0; step = step / 2 {
+		if next := pos + step; next <= n && t.tree[next] <= x {
+			pos = next
+			x = x - t.tree[next]
+		}
+	}
+
+	// rounding may leave x beyond the last count
+	if pos >= n {
+		pos = n - 1
+	}
+	return pos
+}
+
+// setStarts replaces the start prefixes of the model. Repeated prefixes are merged, adding
+// up their counts, and prefixes without a count count once. Files written before the
+// counts were stored repeat a prefix for each sentence it started.
+func (m *Markov) setStarts(prefixes [][]int, counts []float64) {
+	m.Start = make([][]int, 0, len(prefixes))
+	m.StartCounts = make([]float64, 0, len(prefixes))
+	m.starts = startIndex{positions: make(map[string]int, len(prefixes))}
+
+	for i, prefix := range prefixes {
+		count := 1.0
+		if i < len(counts) {
+			count = counts[i]
+		}
+
+		key := prefixKey(prefix)
+		if j, found := m.starts.positions[key]; found {
+			m.StartCounts[j] = m.StartCounts[j] + count
+			continue
+		}
+		m.starts.positions[key] = len(m.Start)
+		m.Start = append(m.Start, prefix)
+		m.StartCounts = append(m.StartCounts, count)
+	}
+
+	m.starts.tree = make([]float64, 1, len(m.Start)+1)
+	for _, count := range m.StartCounts {
+		m.starts.push(count)
+	}
+}
+
+// indexed is true if the index of the start prefixes is current, which it is not if Start
+// or StartCounts were replaced directly
+func (m *Markov) indexed() bool {
+	return len(m.StartCounts) == len(m.Start) && len(m.starts.tree) == len(m.Start)+1
+}
+
+// updateStart adds weight to the count of a start prefix. A new prefix is added, and a
+// prefix is removed once its count drops to zero.
+func (m *Markov) updateStart(prefix []int, weight float64) {
+	if !m.indexed() {
+		m.setStarts(m.Start, m.StartCounts)
+	}
+
+	key := prefixKey(prefix)
+	i, found := m.starts.positions[key]
+	if !found {
+		if weight > countEpsilon {
+			m.starts.positions[key] = len(m.Start)
+			m.Start = append(m.Start, prefix)
+			m.StartCounts = append(m.StartCounts, weight)
+			m.starts.push(weight)
+		}
+		return
+	}
+
+	m.StartCounts[i] = m.StartCounts[i] + weight
+	m.starts.add(i, weight)
+	if m.StartCounts[i] > countEpsilon {
+		return
+	}
+
+	// the last prefix takes the place of the removed one
+	last := len(m.Start) - 1
+	m.starts.add(i, m.StartCounts[last]-m.StartCounts[i])
+	delete(m.starts.positions, key)
+	if i != last {
+		m.Start[i] = m.Start[last]
+		m.StartCounts[i] = m.StartCounts[last]
+		m.starts.positions[prefixKey(m.Start[i])] = i
+	}
+	m.Start = m.Start[:last]
+	m.StartCounts = m.StartCounts[:last]
+	m.starts.pop()
+}
+
+// drawStart returns one of the start prefixes, drawn in proportion to their counts. The
+// model must have start prefixes.
+func (m *Markov) drawStart() []int {
+	if !m.indexed() {
+		all := make([]int, len(m.Start))
+		for i := range all {
+			all[i] = i
+		}
+		return m.drawStartOf(all)
+	}
+	return m.Start[m.starts.search(m.float64()*m.starts.sum(len(m.Start)))]
+}
+
+// drawStartOf returns one of the start prefixes at the indices, drawn in proportion to
+// their counts. There must be at least one index.
+func (m *Markov) drawStartOf(indices []int) []int {
+	total := 0.0
+	for _, i := range indices {
+		total = total + m.startCount(i)
+	}
+
+	pos := m.float64() * total
+	for _, i := range indices {
+		if pos < m.startCount(i) {
+			return m.Start[i]
+		}
+		pos = pos - m.startCount(i)
+	}
+	return m.Start[indices[len(indices)-1]]
+}
+
+// startCount returns the count of the start prefix at index i, 1 if it has none
+func (m *Markov) startCount(i int) float64 {
+	if i < len(m.StartCounts) {
+		return m.StartCounts[i]
+	}
+	return 1
+}
@@ -0,0 +1,118 @@
+package garkov
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// ChainState is the position in the sentences of the training text at which the prefix of
+// a chain occurred. A prefix that occurred at several positions has several states.
+type ChainState int
+
+const (
+	// StartState marks a prefix that began a sentence
+	StartState ChainState = 1 << iota
+	// MiddleState marks a prefix followed by a word that did not end the sentence
+	MiddleState
+	// EndState marks a prefix followed by the STOP word that ended the sentence
+	EndState
+)
+
+// endingTokens is the number of tokens before the token limit from which generation only
+// continues with words after which the sentence can end, if there are any
+const endingTokens int = 3
+
+// String returns the names of the states, e.g. "start|middle"
+func (s ChainState) String() string {
+	var names []string
+	if s&StartState != 0 {
+		names = append(names, "start")
+	}
+	if s&MiddleState != 0 {
+		names = append(names, "middle")
+	}
+	if s&EndState != 0 {
+		names = append(names, "end")
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "|")
+}
+
+// MarshalText encodes the states by their names, e.g. in JSON
+func (s ChainState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// State returns the states of the chain of a prefix of Depth words, 0 if the model has no
+// chain of the prefix
+func (m *Markov) State(prefix []string) (ChainState, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(prefix) != m.Depth {
+		return 0, fmt.Errorf("%w: a prefix of %v words in a model of depth %v", ErrDepthMismatch, len(prefix), m.Depth)
+	}
+	idx, err := m.prefixIndex(prefix)
+	if err != nil {
+		return 0, err
+	}
+	return m.chainState(idx), nil
+}
+
+// chainState returns the states of the chain of a prefix. It is a start if the prefix is a
+// start prefix, and a middle or an end depending on the types of its suffixes.
+func (m *Markov) chainState(prefix []int) ChainState {
+	var state ChainState
+	if m.isStartPrefix(prefix) {
+		state = state | StartState
+	}
+
+	suffixes, _ := m.Chain.GetChain(prefix)
+	for _, suffix := range suffixes {
+		if word, _ := m.Dict.GetAt(suffix.Idx); word.Type == dictionary.STOP {
+			state = state | EndState
+		} else {
+			state = state | MiddleState
+		}
+	}
+	return state
+}
+
+// isStartPrefix is true if the prefix is one of the start prefixes
+func (m *Markov) isStartPrefix(prefix []int) bool {
+	key := prefixKey(prefix)
+	if m.indexed() {
+		_, found := m.starts.positions[key]
+		return found
+	}
+	for _, start := range m.Start {
+		if prefixKey(start) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// allowEnding narrows allow to the STOP words and the words that shift the prefix into a
+// chain in EndState, so that the sentence can end after them
+func (m *Markov) allowEnding(allow func(idx int) bool, prefix []dictionary.Word) func(idx int) bool {
+	next := make([]int, m.Depth)
+	for i, w := range prefix[1:] {
+		next[i] = w.Idx
+	}
+
+	return func(idx int) bool {
+		if allow != nil && !allow(idx) {
+			return false
+		}
+		if word, _ := m.Dict.GetAt(idx); word.Type == dictionary.STOP {
+			return true
+		}
+		next[m.Depth-1] = idx
+		return m.chainState(next)&EndState != 0
+	}
+}
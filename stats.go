@@ -0,0 +1,191 @@
+package garkov
+
+import (
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// DefaultTopWords is the number of most frequent words in Stats
+const DefaultTopWords int = 10
+
+// Stats summarizes the size of a markov model
+type Stats struct {
+	Name        string      `json:"name"`
+	Depth       int         `json:"depth"`
+	Words       int         `json:"words"`       // size of the vocabulary
+	Chains      int         `json:"chains"`      // number of prefixes with suffixes
+	Transitions float64     `json:"transitions"` // sum of the counts of all suffixes
+	Starts      int         `json:"starts"`      // number of distinct start prefixes
+	TopWords    []WordStats `json:"top_words"`   // the DefaultTopWords most frequent words
+}
+
+// WordStats summarizes a word of the dictionary
+type WordStats struct {
+	Word       string `json:"word"`
+	Type       int    `json:"type"`
+	Count      int    `json:"count"`      // number of occurrences in the training text
+	Successors int    `json:"successors"` // number of distinct words following the word
+	Rank       int    `json:"rank"`       // position in the list of words ordered by count, starting at 1
+}
+
+// PrefixStats summarizes the chain of a prefix
+type PrefixStats struct {
+	Prefix   []string   `json:"prefix"`
+	Suffixes int        `json:"suffixes"` // number of distinct words following the prefix
+	Count    float64    `json:"count"`    // sum of the counts of the suffixes
+	End      float64    `json:"end"`      // probability that the sentence ends after the prefix, i.e. that a STOP word follows it
+	State    ChainState `json:"state"`    // where the prefix occurred in the sentences of the training text
+}
+
+// Stats returns the size of the model
+func (m *Markov) Stats() Stats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats := Stats{
+		Name:     m.Name,
+		Depth:    m.Depth,
+		Words:    m.Dict.Len(),
+		Chains:   m.Chain.Len(),
+		Starts:   len(m.Start),
+		TopWords: m.topWords(DefaultTopWords),
+	}
+
+	m.Chain.Range(func(prefix []int, suffixes []WordCount) bool {
+		for _, suffix := range suffixes {
+			stats.Transitions = stats.Transitions + suffix.Count
+		}
+		return true
+	})
+
+	return stats
+}
+
+// Size returns the number of words in the dictionary and of prefixes in the chain. Unlike
+// Stats it does not read the chains.
+func (m *Markov) Size() (words, chains int) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.Dict.Len(), m.Chain.Len()
+}
+
+// TopWords returns the statistics of the n most frequent words, punctuation included, none
+// if n is not positive
+func (m *Markov) TopWords(n int) []WordStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.topWords(n)
+}
+
+// WordStats returns the statistics of a word, and false if it is not in the dictionary
+func (m *Markov) WordStats(w string) (WordStats, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if !m.Dict.Exists(w) {
+		return WordStats{}, false
+	}
+
+	for i, word := range m.Dict.Ranked() {
+		if word.Word == w {
+			return WordStats{
+				Word:       word.Word,
+				Type:       word.Type,
+				Count:      word.Count,
+				Successors: m.successors(map[int]bool{word.Idx: true})[word.Idx],
+				Rank:       i + 1,
+			}, true
+		}
+	}
+	return WordStats{}, false
+}
+
+// PrefixStats returns the statistics of the chain of a prefix, of Depth words or fewer if
+// the model backs off, and false if the model has no chain of the prefix
+func (m *Markov) PrefixStats(prefix []string) (PrefixStats, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	idx, err := m.prefixIndex(prefix)
+	if err != nil {
+		return PrefixStats{}, false
+	}
+	suffixes, found := m.Chain.GetChain(idx)
+	if !found {
+		return PrefixStats{}, false
+	}
+
+	stats := PrefixStats{Prefix: prefix, Suffixes: len(suffixes), State: m.chainState(idx)}
+	ends := 0.0
+	for _, suffix := range suffixes {
+		stats.Count = stats.Count + suffix.Count
+		if word, _ := m.Dict.GetAt(suffix.Idx); word.Type == dictionary.STOP {
+			ends = ends + suffix.Count
+		}
+	}
+	if stats.Count > 0 {
+		stats.End = ends / stats.Count
+	}
+	return stats, true
+}
+
+// topWords returns the statistics of the n most frequent words, ranked by their count. The
+// caller holds the lock of the model.
+func (m *Markov) topWords(n int) []WordStats {
+	ranked := m.Dict.Ranked()
+	if n < 0 {
+		n = 0
+	}
+	if n < len(ranked) {
+		ranked = ranked[:n]
+	}
+
+	words := make(map[int]bool, len(ranked))
+	for _, word := range ranked {
+		words[word.Idx] = true
+	}
+	successors := m.successors(words)
+
+	top := make([]WordStats, len(ranked))
+	for i, word := range ranked {
+		top[i] = WordStats{
+			Word:       word.Word,
+			Type:       word.Type,
+			Count:      word.Count,
+			Successors: successors[word.Idx],
+			Rank:       i + 1,
+		}
+	}
+	return top
+}
+
+// successors counts the distinct words following each of the words, given by their index.
+// The suffixes of all chains whose prefix ends with the word are counted.
+func (m *Markov) successors(words map[int]bool) map[int]int {
+	seen := make(map[int]map[int]bool, len(words))
+	m.Chain.Range(func(prefix []int, suffixes []WordCount) bool {
+		// the lower order chains of backoff repeat the suffixes
+		if len(prefix) != m.Depth {
+			return true
+		}
+
+		last := prefix[len(prefix)-1]
+		if !words[last] {
+			return true
+		}
+		if seen[last] == nil {
+			seen[last] = make(map[int]bool)
+		}
+		for _, suffix := range suffixes {
+			seen[last][suffix.Idx] = true
+		}
+		return true
+	})
+
+	counts := make(map[int]int, len(seen))
+	for idx, suffixes := range seen {
+		counts[idx] = len(suffixes)
+	}
+	return counts
+}
@@ -0,0 +1,32 @@
+package garkov
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTopWords(t *testing.T) {
+	m := New("top")
+	if err := m.BuildReader(strings.NewReader("The cat sat. The cat ran. The dog sat.")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		n    int
+		want int
+	}{
+		{-1, 0},
+		{0, 0},
+		{2, 2},
+		{1000, m.Dict.Len()},
+	}
+	for _, tt := range tests {
+		if top := m.TopWords(tt.n); len(top) != tt.want {
+			t.Errorf("TopWords(%d) returned %d words, want %d", tt.n, len(top), tt.want)
+		}
+	}
+
+	if top := m.TopWords(1); top[0].Word != "." || top[0].Rank != 1 {
+		t.Errorf("TopWords(1) = %+v, want the period", top[0])
+	}
+}
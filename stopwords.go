@@ -0,0 +1,36 @@
+package garkov
+
+import (
+	"strings"
+)
+
+// DefaultStopwords are common English words that carry little meaning of their own
+var DefaultStopwords = []string{
+	"a", "an", "and", "are", "as", "at", "be", "but", "by", "for", "from", "had", "has", "have",
+	"he", "her", "his", "i", "if", "in", "into", "is", "it", "its", "of", "on", "or", "she",
+	"so", "that", "the", "their", "then", "there", "they", "this", "to", "was", "we", "were",
+	"which", "while", "with", "you",
+}
+
+// SetStopwords replaces the stopwords of the model, see GenOptions.StopwordWeight and
+// GenOptions.SkipStopwordStarts. Words are compared ignoring their case. The stopwords are
+// not persisted with the model.
+func (m *Markov) SetStopwords(words ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(words) == 0 {
+		m.stopwords = nil
+		return
+	}
+
+	m.stopwords = make(map[string]bool, len(words))
+	for _, w := range words {
+		m.stopwords[strings.ToLower(w)] = true
+	}
+}
+
+// isStopword is true if the word is one of the stopwords of the model
+func (m *Markov) isStopword(w string) bool {
+	return m.stopwords[strings.ToLower(w)]
+}
@@ -0,0 +1,85 @@
+package garkov
+
+import (
+	"sort"
+)
+
+// Storage keeps the chains of a model: the prefixes of word indices mapped to the counts of
+// the words following them, and the counts of the prefixes that start a sentence. Chains,
+// the default, keeps them in memory. The packages sqlite, bolt and redis keep them on disk
+// or share them between processes. The methods of a Storage can be called from multiple
+// goroutines. A storage that fails to read a chain reports it as missing.
+type Storage interface {
+	// GetChain returns the suffixes of the chain of a prefix, ordered by their word index
+	GetChain(prefix []int) ([]WordCount, bool)
+	// PutChain replaces the chain of a prefix, or removes it if there are no suffixes
+	PutChain(prefix []int, suffixes []WordCount) error
+	// Update counts the suffix of the prefix weight times, or removes that many counts if
+	// weight is negative. Suffixes without counts and chains without suffixes are removed.
+	Update(prefix []int, suffix int, weight float64) error
+	// UpdateStart counts a start prefix weight times, or removes that many counts if
+	// weight is negative
+	UpdateStart(prefix []int, weight float64) error
+	// IterStarts calls f for every start prefix and its count until f returns false
+	IterStarts(f func(prefix []int, count float64) bool) error
+	// Len returns the number of chains
+	Len() int
+	// Range calls f for every chain until f returns false. f may keep the slices, but must
+	// not call other methods of the storage.
+	Range(f func(prefix []int, suffixes []WordCount) bool) error
+	// Prune removes the suffixes counted less than minCount times, the chains left without
+	// suffixes and the start prefixes without a chain. It returns the number of removed
+	// suffixes.
+	Prune(minCount int) (int, error)
+	// Snapshot returns a copy of the storage that later updates do not change
+	Snapshot() (Storage, error)
+}
+
+// UseStorage replaces the chains of the model with chain, and the chain of the reversed text
+// with reverse unless it is nil. The start prefixes of the model are replaced by those of chain.
+func (m *Markov) UseStorage(chain, reverse Storage) error {
+	type start struct {
+		prefix []int
+		count  float64
+	}
+	var starts []start
+	err := chain.IterStarts(func(prefix []int, count float64) bool {
+		starts = append(starts, start{prefix: prefix, count: count})
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	// a stable order keeps seeded generation reproducible
+	sort.Slice(starts, func(i, j int) bool {
+		return prefixKey(starts[i].prefix) < prefixKey(starts[j].prefix)
+	})
+	prefixes := make([][]int, len(starts))
+	counts := make([]float64, len(starts))
+	for i, s := range starts {
+		prefixes[i] = s.prefix
+		counts[i] = s.count
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Chain = chain
+	if reverse != nil {
+		m.Reverse = reverse
+	}
+	m.setStarts(prefixes, counts)
+	return nil
+}
+
+// addStarts counts the start prefixes in the chains, the prefix at each index as often as
+// the count of the same index
+func addStarts(chains Storage, start [][]int, counts []float64) error {
+	for i, prefix := range start {
+		if err := chains.UpdateStart(prefix, counts[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
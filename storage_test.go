@@ -0,0 +1,14 @@
+package garkov_test
+
+import (
+	"testing"
+
+	"github.com/mickuehl/garkov"
+	"github.com/mickuehl/garkov/storagetest"
+)
+
+func TestChainsStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) garkov.Storage {
+		return garkov.NewChains()
+	})
+}
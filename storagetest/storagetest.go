@@ -0,0 +1,185 @@
+// Package storagetest checks that an implementation of garkov.Storage behaves like the
+// in-memory chains, for the tests of the storage backends.
+//
+//	func TestStorage(t *testing.T) {
+//		storagetest.Run(t, func(t *testing.T) garkov.Storage {
+//			...
+//		})
+//	}
+package storagetest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mickuehl/garkov"
+)
+
+// Run runs the checks of the Storage interface on the empty storages returned by open
+func Run(t *testing.T, open func(t *testing.T) garkov.Storage) {
+	t.Run("Update", func(t *testing.T) { testUpdate(t, open(t)) })
+	t.Run("PutChain", func(t *testing.T) { testPutChain(t, open(t)) })
+	t.Run("Starts", func(t *testing.T) { testStarts(t, open(t)) })
+	t.Run("Snapshot", func(t *testing.T) { testSnapshot(t, open(t)) })
+	t.Run("Prune", func(t *testing.T) { testPrune(t, open(t)) })
+}
+
+// update counts the suffixes of the prefix, which alternate with their weights
+func update(t *testing.T, s garkov.Storage, prefix []int, suffixes ...float64) {
+	t.Helper()
+	for i := 0; i < len(suffixes); i = i + 2 {
+		if err := s.Update(prefix, int(suffixes[i]), suffixes[i+1]); err != nil {
+			t.Fatalf("Update(%v, %v, %v): %v", prefix, suffixes[i], suffixes[i+1], err)
+		}
+	}
+}
+
+// checkChain checks the suffixes of the chain of a prefix, nil checks that there is no chain
+func checkChain(t *testing.T, s garkov.Storage, prefix []int, want []garkov.WordCount) {
+	t.Helper()
+	got, found := s.GetChain(prefix)
+	if found != (want != nil) || (found && !reflect.DeepEqual(got, want)) {
+		t.Errorf("GetChain(%v) = %v, %v, want %v", prefix, got, found, want)
+	}
+}
+
+// checkLen checks the number of chains of the storage
+func checkLen(t *testing.T, s garkov.Storage, want int) {
+	t.Helper()
+	if n := s.Len(); n != want {
+		t.Errorf("Len() = %v, want %v", n, want)
+	}
+}
+
+// starts returns the start prefixes of the storage and their counts, keyed by the prefix
+func starts(t *testing.T, s garkov.Storage) map[[2]int]float64 {
+	t.Helper()
+	counts := make(map[[2]int]float64)
+	err := s.IterStarts(func(prefix []int, count float64) bool {
+		counts[[2]int{prefix[0], prefix[1]}] = count
+		return true
+	})
+	if err != nil {
+		t.Fatalf("IterStarts: %v", err)
+	}
+	return counts
+}
+
+func testUpdate(t *testing.T, s garkov.Storage) {
+	update(t, s, []int{1, 2}, 4, 1, 3, 2)
+	update(t, s, []int{2, 3}, 0, 1)
+	checkChain(t, s, []int{1, 2}, []garkov.WordCount{{Idx: 3, Count: 2}, {Idx: 4, Count: 1}})
+	checkChain(t, s, []int{2, 3}, []garkov.WordCount{{Idx: 0, Count: 1}})
+	checkChain(t, s, []int{9, 9}, nil)
+	checkLen(t, s, 2)
+
+	// removed counts remove the suffixes without counts and the chains without suffixes
+	update(t, s, []int{1, 2}, 3, 1, 4, -1)
+	update(t, s, []int{2, 3}, 0, -1)
+	checkChain(t, s, []int{1, 2}, []garkov.WordCount{{Idx: 3, Count: 3}})
+	checkChain(t, s, []int{2, 3}, nil)
+	checkLen(t, s, 1)
+
+	chains := make(map[[2]int][]garkov.WordCount)
+	err := s.Range(func(prefix []int, suffixes []garkov.WordCount) bool {
+		chains[[2]int{prefix[0], prefix[1]}] = suffixes
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Range: %v", err)
+	}
+	want := map[[2]int][]garkov.WordCount{{1, 2}: {{Idx: 3, Count: 3}}}
+	if !reflect.DeepEqual(chains, want) {
+		t.Errorf("Range visited %v, want %v", chains, want)
+	}
+}
+
+func testPutChain(t *testing.T, s garkov.Storage) {
+	update(t, s, []int{5, 6}, 7, 1)
+	if err := s.PutChain([]int{5, 6}, []garkov.WordCount{{Idx: 1, Count: 1}, {Idx: 2, Count: 3}}); err != nil {
+		t.Fatalf("PutChain: %v", err)
+	}
+	checkChain(t, s, []int{5, 6}, []garkov.WordCount{{Idx: 1, Count: 1}, {Idx: 2, Count: 3}})
+
+	if err := s.PutChain([]int{5, 6}, nil); err != nil {
+		t.Fatalf("PutChain: %v", err)
+	}
+	checkChain(t, s, []int{5, 6}, nil)
+	checkLen(t, s, 0)
+}
+
+func testStarts(t *testing.T, s garkov.Storage) {
+	for _, start := range []struct {
+		prefix []int
+		weight float64
+	}{
+		{[]int{1, 2}, 2},
+		{[]int{7, 8}, 1},
+		{[]int{1, 2}, -1},
+		{[]int{3, 4}, 1},
+		{[]int{3, 4}, -1},
+	} {
+		if err := s.UpdateStart(start.prefix, start.weight); err != nil {
+			t.Fatalf("UpdateStart(%v, %v): %v", start.prefix, start.weight, err)
+		}
+	}
+
+	want := map[[2]int]float64{{1, 2}: 1, {7, 8}: 1}
+	if got := starts(t, s); !reflect.DeepEqual(got, want) {
+		t.Errorf("IterStarts visited %v, want %v", got, want)
+	}
+}
+
+func testSnapshot(t *testing.T, s garkov.Storage) {
+	update(t, s, []int{1, 2}, 3, 2)
+	if err := s.UpdateStart([]int{1, 2}, 1); err != nil {
+		t.Fatalf("UpdateStart: %v", err)
+	}
+
+	snapshot, err := s.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+
+	// later updates change the storage, but not the snapshot
+	update(t, s, []int{1, 2}, 5, 1)
+	update(t, s, []int{2, 3}, 0, 1)
+	if err := s.UpdateStart([]int{2, 3}, 1); err != nil {
+		t.Fatalf("UpdateStart: %v", err)
+	}
+
+	checkChain(t, snapshot, []int{1, 2}, []garkov.WordCount{{Idx: 3, Count: 2}})
+	checkChain(t, snapshot, []int{2, 3}, nil)
+	checkLen(t, snapshot, 1)
+	if got, want := starts(t, snapshot), map[[2]int]float64{{1, 2}: 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("IterStarts of the snapshot visited %v, want %v", got, want)
+	}
+
+	checkChain(t, s, []int{1, 2}, []garkov.WordCount{{Idx: 3, Count: 2}, {Idx: 5, Count: 1}})
+	checkLen(t, s, 2)
+}
+
+func testPrune(t *testing.T, s garkov.Storage) {
+	update(t, s, []int{1, 2}, 3, 2, 5, 1)
+	update(t, s, []int{7, 8}, 0, 1)
+	for _, prefix := range [][]int{{1, 2}, {7, 8}} {
+		if err := s.UpdateStart(prefix, 1); err != nil {
+			t.Fatalf("UpdateStart: %v", err)
+		}
+	}
+
+	removed, err := s.Prune(2)
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("Prune removed %v suffixes, want 2", removed)
+	}
+
+	checkChain(t, s, []int{1, 2}, []garkov.WordCount{{Idx: 3, Count: 2}})
+	checkChain(t, s, []int{7, 8}, nil)
+	checkLen(t, s, 1)
+	if got, want := starts(t, s), map[[2]int]float64{{1, 2}: 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("IterStarts after Prune visited %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,87 @@
+package garkov
+
+import (
+	"context"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// Stream generates a sentence and sends its tokens, one at a time as they are sampled, on
+// the returned channel. The channel is closed when the sentence is complete or ctx is done.
+// Join the tokens with the model's Detokenizer to get the sentence.
+func (m *Markov) Stream(ctx context.Context) <-chan string {
+	return m.StreamWithOptions(ctx, GenOptions{})
+}
+
+// StreamWithOptions generates a sentence like SentenceWithOptions and sends its tokens on
+// the returned channel, see Stream.
+func (m *Markov) StreamWithOptions(ctx context.Context, opts GenOptions) <-chan string {
+	tokens := make(chan string)
+
+	go func() {
+		defer close(tokens)
+
+		var seed []Token
+		if opts.StartWith != "" {
+			t, err := m.tokenize(opts.StartWith)
+			if err != nil {
+				return
+			}
+			seed = t
+		}
+
+		// the model is locked for each word only, a slow receiver does not block updates
+		m.mu.RLock()
+		var sentence []dictionary.Word
+		if opts.StartWith != "" {
+			sentence = m.seedStart(seed)
+		} else {
+			sentence = m.randomStart(opts)
+		}
+		m.mu.RUnlock()
+
+		for _, w := range sentence {
+			if !m.send(ctx, tokens, w) {
+				return
+			}
+		}
+		if sentence == nil {
+			return
+		}
+
+		n := 0
+		for {
+			m.mu.RLock()
+			word, done := m.nextWord(sentence, n, opts)
+			m.mu.RUnlock()
+
+			if word.Word != "" {
+				sentence = append(sentence, word)
+				if !m.send(ctx, tokens, word) {
+					return
+				}
+			}
+			if done {
+				return
+			}
+			n = n + 1
+		}
+	}()
+
+	return tokens
+}
+
+// send sends a word on the channel, in its most frequent surface form if the model folds
+// the case of words or normalizes them. The result is false if ctx is done.
+func (m *Markov) send(ctx context.Context, tokens chan<- string, w dictionary.Word) bool {
+	m.mu.RLock()
+	token := m.Dict.Form(w.Word)
+	m.mu.RUnlock()
+
+	select {
+	case tokens <- token:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
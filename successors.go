@@ -0,0 +1,75 @@
+package garkov
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// WordProb is a word that follows a prefix and its probability
+type WordProb struct {
+	Word  string  `json:"word"` // the most frequent spelling of the word, see dictionary.Dictionary.Form
+	Type  int     `json:"type"`
+	Count float64 `json:"count"` // the number of times the word followed the prefix
+	Prob  float64 `json:"prob"`  // the share of the count in the counts of all words following the prefix
+}
+
+// Successors returns the words following a prefix in the chain, ordered by their
+// probability. The prefix has Depth words, or fewer with Backoff, which are folded and
+// normalized like the words of the text the model is trained with. The probabilities are
+// those of the counts, without smoothing. It returns ErrDepthMismatch for a prefix of
+// another length and ErrUnknownPrefix if the model has no chain of the prefix.
+func (m *Markov) Successors(prefix []string) ([]WordProb, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(prefix) == 0 || len(prefix) > m.Depth || (len(prefix) < m.Depth && !m.Backoff) {
+		return nil, fmt.Errorf("%w: a prefix of %v words in a model of depth %v", ErrDepthMismatch, len(prefix), m.Depth)
+	}
+	idx, err := m.prefixIndex(prefix)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownPrefix, err)
+	}
+	suffixes, found := m.Chain.GetChain(idx)
+	if !found {
+		return nil, fmt.Errorf("%w '%v'", ErrUnknownPrefix, strings.Join(prefix, " "))
+	}
+	return m.wordProbs(suffixes), nil
+}
+
+// prefixIndex returns the word indices of a prefix of words of a text, which are folded,
+// normalized and escaped like the words the model is trained with
+func (m *Markov) prefixIndex(prefix []string) ([]int, error) {
+	entries := make([]string, len(prefix))
+	for i, w := range prefix {
+		entries[i] = m.entry(Token{Word: w})
+	}
+	return wordsToIndex(entries, m.Dict)
+}
+
+// wordProbs returns the probabilities of the suffixes, the most probable first. Suffixes of
+// equal counts are ordered by their index.
+func (m *Markov) wordProbs(suffixes []WordCount) []WordProb {
+	total := 0.0
+	for _, s := range suffixes {
+		total = total + s.Count
+	}
+
+	sorted := append([]WordCount(nil), suffixes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Idx < sorted[j].Idx
+	})
+
+	probs := make([]WordProb, len(sorted))
+	for i, s := range sorted {
+		word, _ := m.Dict.GetAt(s.Idx)
+		probs[i] = WordProb{Word: m.Dict.Form(word.Word), Type: word.Type, Count: s.Count}
+		if total > 0 {
+			probs[i].Prob = s.Count / total
+		}
+	}
+	return probs
+}
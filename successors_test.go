@@ -0,0 +1,50 @@
+package garkov
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSuccessors(t *testing.T) {
+	text := "The wife of Bath. The wife of Bath. The wife sang."
+	tests := []struct {
+		name   string
+		opts   []Option
+		prefix []string
+		want   string
+		err    error
+	}{
+		{"exact", nil, []string{"The", "wife"}, "of", nil},
+		{"case folded", []Option{WithFoldCase()}, []string{"The", "Wife"}, "of", nil},
+		{"case folded lower", []Option{WithFoldCase()}, []string{"the", "wife"}, "of", nil},
+		{"unknown", nil, []string{"The", "husband"}, "", ErrUnknownPrefix},
+		{"depth", nil, []string{"The"}, "", ErrDepthMismatch},
+	}
+
+	for _, tt := range tests {
+		m := New("successors", tt.opts...)
+		if err := m.BuildReader(strings.NewReader(text)); err != nil {
+			t.Fatal(err)
+		}
+
+		probs, err := m.Successors(tt.prefix)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: Successors(%q) error %v, want %v", tt.name, tt.prefix, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if len(probs) != 2 || probs[0].Word != tt.want || probs[0].Prob < 0.66 || probs[0].Prob > 0.67 {
+			t.Errorf("%s: Successors(%q) = %+v, want %q with 2/3", tt.name, tt.prefix, probs, tt.want)
+		}
+
+		if _, found := m.PrefixStats(tt.prefix); !found {
+			t.Errorf("%s: PrefixStats(%q) found no chain", tt.name, tt.prefix)
+		}
+		if state, err := m.State(tt.prefix); err != nil || state == 0 {
+			t.Errorf("%s: State(%q) = %v, %v", tt.name, tt.prefix, state, err)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package garkov
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// meterAttempts is the number of words tried to find a sentence with the syllables of
+// GenOptions.Syllables before generation gives up
+const meterAttempts int = 10000
+
+// SyllableCounter counts the syllables of a word. Punctuation has none.
+type SyllableCounter func(word string) int
+
+// CountSyllables estimates the syllables of an English word by its groups of vowels, e.g. 1
+// for "night" and 2 for "table". A final silent e does not count. Words without letters
+// have no syllables.
+func CountSyllables(word string) int {
+	word = strings.ToLower(word)
+
+	n := 0
+	vowel := false
+	letters := 0
+	for _, r := range word {
+		if !unicode.IsLetter(r) {
+			vowel = false
+			continue
+		}
+		letters = letters + 1
+		v := strings.ContainsRune("aeiouyàáâäèéêëìíîïòóôöùúûü", r)
+		if v && !vowel {
+			n = n + 1
+		}
+		vowel = v
+	}
+	if letters == 0 {
+		return 0
+	}
+
+	// the e of "cake" is silent, the one of "table" is not
+	if n > 1 && strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") && !strings.HasSuffix(word, "ee") {
+		n = n - 1
+	}
+	if n == 0 {
+		n = 1
+	}
+	return n
+}
+
+// Verse generates a line per number of syllables, e.g. 5, 7 and 5 for a haiku. Each line is
+// a sentence generated with opts and the syllables of its line, see GenOptions.Syllables.
+func (m *Markov) Verse(opts GenOptions, syllables ...int) ([]string, error) {
+	lines := make([]string, 0, len(syllables))
+	for _, n := range syllables {
+		opts.Syllables = n
+		line, err := m.Generate(opts)
+		if err != nil {
+			return lines, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// meter searches the sentences with a number of syllables
+type meter struct {
+	m         *Markov
+	opts      GenOptions
+	allow     func(idx int) bool
+	count     SyllableCounter
+	counts    map[int]int // the syllables of the words counted so far, by their index
+	maxTokens int
+	attempts  int // the words left to try
+}
+
+// generateMeter creates a sentence with opts.Syllables syllables. Starting with the start
+// prefixes that do not have too many syllables, drawn in proportion to their counts, the
+// suffixes are tried in a random order weighted by their counts, and the next one is tried
+// if the sentence can not end with the syllables left.
+func (m *Markov) generateMeter(seed []Token, opts GenOptions) (string, error) {
+	begin := time.Now()
+
+	v := meter{m: m, opts: opts, count: m.SyllableCounter, counts: make(map[int]int), maxTokens: opts.MaxTokens, attempts: meterAttempts}
+	if v.count == nil {
+		v.count = CountSyllables
+	}
+	if v.maxTokens <= 0 {
+		v.maxTokens = DefaultMaxTokens
+	}
+
+	var starts [][]dictionary.Word
+	if opts.StartWith != "" {
+		start := m.seedStart(seed)
+		if start == nil {
+			return "", fmt.Errorf("%w '%v'", ErrUnknownPrefix, opts.StartWith)
+		}
+		starts = append(starts, start)
+	} else {
+		filter := m.wordFilter(opts)
+		var candidates []int
+		var weights []float64
+		for i, prefix := range m.Start {
+			if m.allowedPrefix(prefix, filter) {
+				candidates = append(candidates, i)
+				weights = append(weights, weight(m.startCount(i), opts.Temperature))
+			}
+		}
+		for _, i := range m.weightedOrder(weights) {
+			starts = append(starts, m.prefixWords(m.Start[candidates[i]]))
+		}
+	}
+
+	for _, start := range starts {
+		if v.attempts <= 0 {
+			break
+		}
+
+		syllables := 0
+		for _, w := range start {
+			syllables = syllables + v.syllables(w)
+		}
+		if syllables > opts.Syllables {
+			continue
+		}
+
+		v.allow = m.allowFunc(start, opts)
+		if sentence, ok := v.walk(start, syllables); ok {
+			if m.Metrics != nil {
+				m.Metrics.Generated(m.Name, time.Since(begin))
+			}
+			return m.text(sentence, opts), nil
+		}
+	}
+	return "", ErrNoMeter
+}
+
+// syllables returns the syllables of a word
+func (v *meter) syllables(w dictionary.Word) int {
+	n, found := v.counts[w.Idx]
+	if !found {
+		n = v.count(v.m.Dict.Form(w.Word))
+		v.counts[w.Idx] = n
+	}
+	return n
+}
+
+// walk continues the sentence, which has the syllables so far, until it ends with exactly
+// the syllables of the options and passes their other constraints
+func (v *meter) walk(sentence []dictionary.Word, syllables int) ([]dictionary.Word, bool) {
+	if len(sentence)+1 >= v.maxTokens {
+		return nil, false
+	}
+
+	suffixes, found := v.m.suffixesFor(sentence[len(sentence)-v.m.Depth:], v.allow)
+	if !found {
+		return nil, false
+	}
+
+	var candidates []dictionary.Word
+	var weights []float64
+	for _, s := range suffixes {
+		if v.allow != nil && !v.allow(s.Idx) {
+			continue
+		}
+		word, _ := v.m.Dict.GetAt(s.Idx)
+		if word.Type == dictionary.STOP && syllables != v.opts.Syllables {
+			continue
+		}
+		if syllables+v.syllables(word) > v.opts.Syllables {
+			continue
+		}
+		candidates = append(candidates, word)
+		weights = append(weights, weight(s.Count, v.opts.Temperature))
+	}
+
+	for _, i := range v.m.weightedOrder(weights) {
+		if v.attempts <= 0 {
+			break
+		}
+		v.attempts = v.attempts - 1
+
+		word := candidates[i]
+		next := append(sentence, word)
+		if word.Type == dictionary.STOP {
+			if v.complete(next) {
+				return next, true
+			}
+			continue
+		}
+
+		// the words of the sentence so far decide which suffixes are novel
+		if v.opts.Novel {
+			v.allow = v.m.allowFunc(next, v.opts)
+		}
+		if s, ok := v.walk(next, syllables+v.syllables(word)); ok {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// complete is true if the sentence passes the constraints of the options on a complete
+// sentence
+func (v *meter) complete(sentence []dictionary.Word) bool {
+	if len(sentence)-v.m.Depth-1 < v.opts.MinWords {
+		return false
+	}
+	if v.opts.LastWord != nil {
+		if last := lastWord(sentence); last == "" || !v.opts.LastWord(last) {
+			return false
+		}
+	}
+	text := v.m.text(sentence, v.opts)
+	return fits(text, v.opts) && longEnough(text, v.opts)
+}
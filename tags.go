@@ -0,0 +1,45 @@
+package garkov
+
+import (
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// Tagger tags the tokens of a sentence, e.g. with their parts of speech or the named
+// entities they belong to, and returns a tag for each token, "" for none. The dictionary of
+// the model counts the tags of each word, see dictionary.Dictionary.Tag. BuildParallel calls
+// the tagger from several goroutines at once.
+type Tagger func(sentence []Token) []string
+
+// TagWeight returns the factor by which the weight of a suffix with the tag is multiplied
+// after the word prev with the tag prevTag, e.g. 3 for a noun after "the". The words are
+// spelled as the dictionary stores them, and words without a tag have the tag "". Factors
+// of 0 or less leave the weight unchanged.
+type TagWeight func(prev, prevTag, tag string) float64
+
+// tag tags the tokens with the Tagger of the model, a sentence at a time. Tags the tokenizer
+// set are kept where the tagger returns none.
+func (m *Markov) tag(tokens []Token) []Token {
+	if m.Tagger == nil {
+		return tokens
+	}
+
+	start := 0
+	for i, t := range tokens {
+		typ := t.Type
+		if typ == 0 {
+			typ = dictionary.TokenType(t.Word)
+		}
+		if typ != dictionary.SENTENCE_END && i < len(tokens)-1 {
+			continue
+		}
+
+		sentence := tokens[start : i+1]
+		for j, tag := range m.Tagger(sentence) {
+			if j < len(sentence) && tag != "" {
+				sentence[j].Tag = tag
+			}
+		}
+		start = i + 1
+	}
+	return tokens
+}
@@ -0,0 +1,376 @@
+package garkov
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jdkato/prose/tokenize"
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// CHAR_END_TOKEN terminates the lines of a character level model
+const CHAR_END_TOKEN string = "\n"
+
+// DefaultStopTokens are the tokens that end a sentence, unless configured otherwise
+var DefaultStopTokens = []string{".", "!", "?"}
+
+// DefaultAbbreviations are the English abbreviations whose period does not end a sentence,
+// unless configured otherwise
+var DefaultAbbreviations = []string{
+	"Mr.", "Mrs.", "Ms.", "Dr.", "Prof.", "Sr.", "Jr.", "St.", "Mt.",
+	"Gen.", "Col.", "Capt.", "Lt.", "Sgt.", "Rev.", "Hon.", "Gov.", "Sen.", "Rep.",
+	"Inc.", "Ltd.", "Co.", "Corp.", "Bros.", "Dept.", "Univ.",
+	"Fig.", "Vol.", "No.", "Nos.", "pp.", "ch.", "ed.", "cf.", "ca.", "approx.",
+	"vs.", "etc.", "e.g.", "i.e.", "a.m.", "p.m.",
+	"Jan.", "Feb.", "Mar.", "Apr.", "Jun.", "Jul.", "Aug.", "Sep.", "Sept.", "Oct.", "Nov.", "Dec.",
+}
+
+// Token is a single word or punctuation mark of a text
+type Token struct {
+	Word string // the text of the token
+	Type int    // the word type, e.g. dictionary.WORD. 0 lets the dictionary decide.
+
+	// Tag is the tag of the token, e.g. its part of speech, "" for none. See Tagger.
+	Tag string
+
+	// Control marks a control token that a tokenizer substitutes for a piece of the text,
+	// e.g. dictionary.URL_TOKEN. A token of the text that merely spells a control token is
+	// kept apart from it in the dictionary.
+	Control bool
+}
+
+// Tokenizer splits a text into tokens. Every sentence is terminated by a token of type dictionary.SENTENCE_END.
+type Tokenizer interface {
+	Tokenize(text string) []Token
+}
+
+// TreebankTokenizer is the default tokenizer. It splits a text into sentences first and then
+// tokenizes each sentence with the Penn Treebank conventions.
+type TreebankTokenizer struct {
+	StopTokens        []string // tokens that end a sentence, nil selects DefaultStopTokens
+	Abbreviations     []string // words whose period does not end a sentence, nil selects DefaultAbbreviations
+	LineBreaks        bool     // every line break ends a sentence
+	SplitContractions bool     // split contractions like the Penn Treebank, e.g. "do" "n't", instead of keeping "don't"
+	Placeholders      bool     // replace numbers, URLs, email addresses, @mentions and hashtags by placeholders like dictionary.URL_TOKEN
+
+	words     tokenize.ProseTokenizer
+	sentences tokenize.ProseTokenizer
+}
+
+// NewTreebankTokenizer creates the default tokenizer for a language.
+func NewTreebankTokenizer(language string) (*TreebankTokenizer, error) {
+	sentenizer, err := tokenize.NewPragmaticSegmenter(language)
+	if err != nil {
+		return nil, err
+	}
+
+	t := TreebankTokenizer{
+		words:     tokenize.NewTreebankWordTokenizer(),
+		sentences: sentenizer,
+	}
+
+	return &t, nil
+}
+
+// Tokenize splits the text into complete sentences fist, regardless of the individual lines
+// unless LineBreaks is set, and then each sentence into words.
+func (t *TreebankTokenizer) Tokenize(text string) []Token {
+	var tokens []Token
+
+	paragraphs := []string{text}
+	if t.LineBreaks {
+		paragraphs = strings.Split(text, "\n")
+	}
+
+	for _, paragraph := range paragraphs {
+		// a quote may span the sentences of a paragraph
+		quoted := false
+
+		// URLs and the like are kept from the sentence and word tokenizers
+		paragraph, spans, marker := protectSpans(paragraph)
+
+		for _, sentence := range t.split(paragraph) {
+			if len(sentence) == 0 {
+				continue
+			}
+
+			words := unquote(t.words.Tokenize(sentence), &quoted)
+			if !t.SplitContractions {
+				words = joinContractions(words)
+			}
+			words, control := restoreSpans(words, spans, marker, t.Placeholders)
+
+			last := 0
+			for i, w := range words {
+				if filter(w) {
+					continue
+				}
+				if control[i] {
+					last = dictionary.WORD
+					tokens = append(tokens, Token{Word: w, Type: dictionary.WORD, Control: true})
+					continue
+				}
+
+				for _, part := range splitPunct(w) {
+					for _, token := range t.splitStop(part) {
+						last = token.Type
+						tokens = append(tokens, token)
+					}
+				}
+			}
+
+			// check if the sentence ends with a STOP token and add one if not
+			if last != dictionary.SENTENCE_END {
+				tokens = append(tokens, Token{Word: t.EndToken(), Type: dictionary.SENTENCE_END})
+			}
+		}
+	}
+
+	return tokens
+}
+
+// EndToken returns the token that terminates sentences the text did not terminate, the
+// period unless it is not one of the stop tokens
+func (t *TreebankTokenizer) EndToken() string {
+	return endToken(t.stops())
+}
+
+// stops returns the tokens that end a sentence
+func (t *TreebankTokenizer) stops() []string {
+	if t.StopTokens == nil {
+		return DefaultStopTokens
+	}
+	return t.StopTokens
+}
+
+// endToken returns the period if it is one of the stops, the first stop otherwise
+func endToken(stops []string) string {
+	if len(stops) == 0 || isStop(stops, dictionary.SENTENCE_END_TOKEN) {
+		return dictionary.SENTENCE_END_TOKEN
+	}
+	return stops[0]
+}
+
+// isStop is true if w is one of the stops
+func isStop(stops []string, w string) bool {
+	for _, stop := range stops {
+		if w == stop {
+			return true
+		}
+	}
+	return false
+}
+
+// split splits a text into sentences. The segmenter's sentences that end with an
+// abbreviation, or with a punctuation mark that is not a stop token, are joined with the
+// next one.
+func (t *TreebankTokenizer) split(text string) []string {
+	abbreviations := t.Abbreviations
+	if abbreviations == nil {
+		abbreviations = DefaultAbbreviations
+	}
+	stops := t.stops()
+
+	var sentences []string
+	joined := ""
+	for _, sentence := range t.sentences.Tokenize(text) {
+		if joined != "" {
+			sentence = joined + " " + sentence
+		}
+
+		if endsWithAbbreviation(sentence, abbreviations) || !endsWithStop(sentence, stops) {
+			joined = sentence
+			continue
+		}
+		joined = ""
+		sentences = append(sentences, sentence)
+	}
+
+	if joined != "" {
+		sentences = append(sentences, joined)
+	}
+
+	return sentences
+}
+
+// endsWithAbbreviation is true if the last word of the sentence is one of the abbreviations
+func endsWithAbbreviation(sentence string, abbreviations []string) bool {
+	sentence = strings.TrimSpace(sentence)
+	last := sentence[strings.LastIndexAny(sentence, " \t\n")+1:]
+
+	for _, a := range abbreviations {
+		if last == a {
+			return true
+		}
+	}
+	return false
+}
+
+// isAbbreviation is true if the word is one of the abbreviations of the tokenizer
+func (t *TreebankTokenizer) isAbbreviation(w string) bool {
+	abbreviations := t.Abbreviations
+	if abbreviations == nil {
+		abbreviations = DefaultAbbreviations
+	}
+	for _, a := range abbreviations {
+		if w == a {
+			return true
+		}
+	}
+	return false
+}
+
+// endsWithStop is false if the sentence ends with a punctuation mark the segmenter splits
+// at, e.g. a period, that is not one of the stops
+func endsWithStop(sentence string, stops []string) bool {
+	sentence = strings.TrimRightFunc(sentence, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune("\"')]’”»", r)
+	})
+
+	for _, stop := range stops {
+		if strings.HasSuffix(sentence, stop) {
+			return true
+		}
+	}
+	return !strings.HasSuffix(sentence, ".") && !strings.HasSuffix(sentence, "!") && !strings.HasSuffix(sentence, "?")
+}
+
+// splitStop classifies a word and separates a trailing stop token from it. Periods are
+// left alone, the word tokenizer already splits them off except for abbreviations and the
+// periods within a sentence if the period is not a stop token.
+func (t *TreebankTokenizer) splitStop(w string) []Token {
+	for _, stop := range t.stops() {
+		if w == stop {
+			return []Token{{Word: w, Type: dictionary.SENTENCE_END}}
+		}
+		if stop != "." && strings.HasSuffix(w, stop) {
+			return []Token{
+				{Word: strings.TrimSuffix(w, stop), Type: dictionary.WORD},
+				{Word: stop, Type: dictionary.SENTENCE_END},
+			}
+		}
+	}
+
+	// the word tokenizer leaves the period of a sentence the segmenter did not end there
+	if w != "." && strings.HasSuffix(w, ".") && !strings.HasSuffix(w, "..") &&
+		!isStop(t.stops(), ".") && !t.isAbbreviation(w) {
+		return []Token{
+			{Word: strings.TrimSuffix(w, "."), Type: dictionary.WORD},
+			{Word: ".", Type: dictionary.MARK},
+		}
+	}
+
+	// punctuation that is not configured to end a sentence
+	typ := dictionary.TokenType(w)
+	if typ == dictionary.STOP {
+		typ = dictionary.MARK
+	}
+
+	return []Token{{Word: w, Type: typ}}
+}
+
+// splitPunct separates non-ASCII punctuation like «», „“, ¿ or ¡ from the beginning and the
+// end of a word, and splits words at dashes. The word tokenizer only knows ASCII punctuation.
+func splitPunct(w string) []string {
+	var parts []string
+
+	// leading punctuation
+	for len(w) > 0 {
+		r, size := utf8.DecodeRuneInString(w)
+		if !isUnicodePunct(r) || size == len(w) {
+			break
+		}
+		parts = append(parts, w[:size])
+		w = w[size:]
+	}
+
+	// trailing punctuation, collected in reverse order
+	var trailing []string
+	for len(w) > 0 {
+		r, size := utf8.DecodeLastRuneInString(w)
+		if !isUnicodePunct(r) || size == len(w) {
+			break
+		}
+		trailing = append(trailing, w[len(w)-size:])
+		w = w[:len(w)-size]
+	}
+
+	// dashes within the word
+	start := 0
+	for i, r := range w {
+		if isDash(r) {
+			if i > start {
+				parts = append(parts, w[start:i])
+			}
+			parts = append(parts, string(r))
+			start = i + utf8.RuneLen(r)
+		}
+	}
+	if start < len(w) {
+		parts = append(parts, w[start:])
+	}
+
+	for i := len(trailing) - 1; i >= 0; i-- {
+		parts = append(parts, trailing[i])
+	}
+
+	return parts
+}
+
+// isUnicodePunct is true for punctuation outside of ASCII
+func isUnicodePunct(r rune) bool {
+	return r >= utf8.RuneSelf && unicode.IsPunct(r)
+}
+
+// isDash is true for dashes, but not for the hyphens within compound words
+func isDash(r rune) bool {
+	return r >= utf8.RuneSelf && r != '‐' && unicode.Is(unicode.Pd, r)
+}
+
+// CharTokenizer splits a text into characters. Every non-empty line of the text is a sentence.
+type CharTokenizer struct{}
+
+// Tokenize returns the characters of each line, followed by a CHAR_END_TOKEN.
+func (t *CharTokenizer) Tokenize(text string) []Token {
+	var tokens []Token
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		for _, r := range line {
+			tokens = append(tokens, Token{Word: string(r), Type: dictionary.WORD})
+		}
+		tokens = append(tokens, Token{Word: CHAR_END_TOKEN, Type: dictionary.SENTENCE_END})
+	}
+
+	return tokens
+}
+
+// EndToken returns the token that terminates each line
+func (t *CharTokenizer) EndToken() string {
+	return CHAR_END_TOKEN
+}
+
+func filter(w string) bool {
+	if len(w) > 2 {
+		return false
+	}
+
+	if w == "\"" {
+		return true
+	}
+
+	if w == "``" {
+		return true
+	}
+
+	if w == "''" {
+		return true
+	}
+
+	return false
+}
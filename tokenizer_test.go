@@ -0,0 +1,115 @@
+package garkov
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+const stopText = "I like cats. I like dogs. They run fast!"
+
+// sentenceEnds returns the words of the tokens that end a sentence
+func sentenceEnds(tokens []Token) []string {
+	var ends []string
+	for _, t := range tokens {
+		if t.Type == dictionary.SENTENCE_END {
+			ends = append(ends, t.Word)
+		}
+	}
+	return ends
+}
+
+func TestTokenizeStopTokens(t *testing.T) {
+	tests := []struct {
+		name  string
+		stops []string
+		ends  []string
+	}{
+		{"default", nil, []string{".", ".", "!"}},
+		{"without period", []string{"!", "?"}, []string{"!"}},
+		{"period only", []string{"."}, []string{".", ".", "."}},
+	}
+
+	for _, tt := range tests {
+		tokenizer, err := NewTreebankTokenizer("en")
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokenizer.StopTokens = tt.stops
+
+		ends := sentenceEnds(tokenizer.Tokenize(stopText))
+		if strings.Join(ends, " ") != strings.Join(tt.ends, " ") {
+			t.Errorf("%s: sentence ends %q, want %q", tt.name, ends, tt.ends)
+		}
+	}
+}
+
+func TestTokenizeStopTokensEndToken(t *testing.T) {
+	tokenizer, err := NewTreebankTokenizer("en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenizer.StopTokens = []string{"!", "?"}
+
+	tokens := tokenizer.Tokenize("I like cats. I like dogs")
+	if ends := sentenceEnds(tokens); len(ends) != 1 || ends[0] != "!" {
+		t.Errorf("sentence ends %q, want [!]", ends)
+	}
+	for _, token := range tokens {
+		if token.Word == "." && token.Type != dictionary.MARK {
+			t.Errorf("period has type %d, want MARK", token.Type)
+		}
+	}
+}
+
+func TestGenerateStopTokensWithoutPeriod(t *testing.T) {
+	m := New("stops", WithStopTokens("!", "?"), WithRandom(rand.NewSource(1)))
+	if err := m.BuildReader(strings.NewReader(stopText)); err != nil {
+		t.Fatal(err)
+	}
+
+	if w, _ := m.Dict.Get("."); w.Type != dictionary.MARK {
+		t.Errorf("period has type %d in the dictionary, want MARK", w.Type)
+	}
+
+	for i := 0; i < 50; i++ {
+		s := m.Sentence(0, 0)
+		if s == "" {
+			continue
+		}
+		if strings.HasSuffix(s, ".") {
+			t.Fatalf("sentence %q ends with a period", s)
+		}
+	}
+}
+
+func TestMultiModelStopTokensWithoutPeriod(t *testing.T) {
+	mm := NewMultiModel("chat", WithRandom(rand.NewSource(1)))
+	exclaims, states := mm.Model("exclaims"), mm.Model("states")
+	exclaims.StopTokens = []string{"!", "?"}
+
+	// the model without the period trains first, the shared period must keep its type
+	for _, m := range []*Markov{exclaims, states} {
+		if err := m.BuildReader(strings.NewReader(stopText)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if w, _ := mm.Dict.Get("."); w.Type != dictionary.SENTENCE_END {
+		t.Errorf("period has type %d in the shared dictionary, want SENTENCE_END", w.Type)
+	}
+
+	periods := 0
+	for i := 0; i < 50; i++ {
+		if s := exclaims.Sentence(0, 0); strings.HasSuffix(s, ".") {
+			t.Fatalf("sentence %q of the model without the period ends with a period", s)
+		}
+		if s := states.Sentence(0, 0); strings.HasSuffix(s, ".") {
+			periods++
+		}
+	}
+	if periods == 0 {
+		t.Error("no sentence of the model with the period ends with a period")
+	}
+}
@@ -1,25 +1,87 @@
 package garkov
 
 import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+
 	"github.com/mickuehl/garkov/dictionary"
 )
 
-func wordsToPrefixString(prefix []dictionary.Word) string {
-	k := ""
-	for i := range prefix {
-		k = k + prefix[i].Word
+// maxKeyDepth is the prefix size up to which the keys of lookups fit on the stack
+const maxKeyDepth int = 8
+
+// prefixKey encodes the word indices of a prefix into the key of the chain map. The
+// indices are packed as varints, which are self-delimiting, so different prefixes never
+// share a key.
+func prefixKey(prefix []int) string {
+	return string(appendPrefixKey(make([]byte, 0, 3*len(prefix)), prefix))
+}
+
+// appendPrefixKey appends the key of a prefix to buf
+func appendPrefixKey(buf []byte, prefix []int) []byte {
+	for _, idx := range prefix {
+		buf = binary.AppendVarint(buf, int64(idx))
 	}
+	return buf
+}
 
-	return k
+// EncodePrefix returns the key of a prefix of word indices, for storages that keep the
+// chains under byte keys
+func EncodePrefix(prefix []int) []byte {
+	return appendPrefixKey(make([]byte, 0, 3*len(prefix)), prefix)
 }
 
-func indexToPrefixString(prefix []int, dict *dictionary.Dictionary) string {
-	k := ""
+// DecodePrefix returns the word indices of a key made by EncodePrefix
+func DecodePrefix(key []byte) ([]int, error) {
+	var prefix []int
+	for len(key) > 0 {
+		idx, n := binary.Varint(key)
+		if n <= 0 {
+			return nil, fmt.Errorf("%w: invalid prefix key", ErrCorruptModel)
+		}
+		prefix = append(prefix, int(idx))
+		key = key[n:]
+	}
+	return prefix, nil
+}
+
+// findSuffixes returns the suffixes of the chain of a prefix, ordered by their word index
+func findSuffixes(chains Storage, prefix []dictionary.Word) ([]WordCount, bool) {
+	return chains.GetChain(wordsToIndexArray(prefix))
+}
+
+// findSuffix returns the suffix with the word index idx of suffixes ordered by their index
+func findSuffix(suffixes []WordCount, idx int) (WordCount, bool) {
+	i := sort.Search(len(suffixes), func(i int) bool {
+		return suffixes[i].Idx >= idx
+	})
+	if i < len(suffixes) && suffixes[i].Idx == idx {
+		return suffixes[i], true
+	}
+	return WordCount{}, false
+}
+
+func indexToWords(prefix []int, dict *dictionary.Dictionary) []string {
+	words := make([]string, len(prefix))
 	for i := range prefix {
-		k = k + dict.V[prefix[i]]
+		words[i] = dict.WordAt(prefix[i])
+	}
+
+	return words
+}
+
+func wordsToIndex(words []string, dict *dictionary.Dictionary) ([]int, error) {
+	idx := make([]int, len(words))
+	for i := range words {
+		word, found := dict.Get(words[i])
+		if !found {
+			return nil, fmt.Errorf("unknown word '%v'", words[i])
+		}
+		idx[i] = word.Idx
 	}
 
-	return k
+	return idx, nil
 }
 
 func wordsToIndexArray(prefix []dictionary.Word) []int {
@@ -32,15 +94,22 @@ func wordsToIndexArray(prefix []dictionary.Word) []int {
 	return idx
 }
 
-func wordsToSentence(sentence []dictionary.Word) string {
-	k := ""
-	for i := range sentence {
-		if sentence[i].Type < dictionary.STOP {
-			k = k + " " + sentence[i].Word
-		} else {
-			k = k + sentence[i].Word
-		}
+func wordsToTokens(words []dictionary.Word) []Token {
+	tokens := make([]Token, len(words))
+	for i := range words {
+		tokens[i] = Token{Word: words[i].Word, Type: words[i].Type}
 	}
 
-	return k
+	return tokens
+}
+
+// ngramHash returns the FNV-1a hash of a run of word indices
+func ngramHash(idx []int) uint64 {
+	h := uint64(14695981039346656037)
+	for _, i := range idx {
+		for j := uint(0); j < 64; j = j + 8 {
+			h = (h ^ uint64(byte(uint64(i)>>j))) * 1099511628211
+		}
+	}
+	return h
 }
@@ -0,0 +1,99 @@
+package garkov
+
+import (
+	"fmt"
+	"math"
+)
+
+// Validate checks the invariants of the model: the dictionary maps its words to their
+// index, all prefixes and suffixes of the chains refer to words of the dictionary, all
+// counts are positive, and every start prefix has a chain. It returns the first violation
+// it finds, nil if there is none.
+func (m *Markov) Validate() error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if err := checkDepth(m.Depth); err != nil {
+		return err
+	}
+
+	// the dictionary
+	for i, w := range m.Dict.V {
+		if word, found := m.Dict.Words[w]; !found || word.Idx != i {
+			return fmt.Errorf("word %v '%v' is not in the dictionary under its index", i, w)
+		}
+	}
+	if len(m.Dict.Words) != m.Dict.Len() {
+		return fmt.Errorf("the dictionary has %v words but %v indices", len(m.Dict.Words), m.Dict.Len())
+	}
+
+	if err := m.validateChains("chain", m.Chain); err != nil {
+		return err
+	}
+	if err := m.validateChains("reverse chain", m.Reverse); err != nil {
+		return err
+	}
+
+	// the start prefixes
+	if len(m.StartCounts) != len(m.Start) {
+		return fmt.Errorf("%v start counts for %v start prefixes", len(m.StartCounts), len(m.Start))
+	}
+	seen := make(map[string]bool, len(m.Start))
+	for i, prefix := range m.Start {
+		if len(prefix) != m.Depth || !validIndex(prefix, m.Dict) {
+			return fmt.Errorf("invalid start prefix %v", prefix)
+		}
+		if count := m.StartCounts[i]; !(count > 0) || math.IsInf(count, 0) {
+			return fmt.Errorf("start prefix %v has the invalid count %v", prefix, count)
+		}
+		if seen[prefixKey(prefix)] {
+			return fmt.Errorf("duplicate start prefix %v", prefix)
+		}
+		seen[prefixKey(prefix)] = true
+		if _, found := m.Chain.GetChain(prefix); !found {
+			return fmt.Errorf("start prefix %v has no chain", prefix)
+		}
+	}
+
+	var err error
+	iterErr := m.Chain.IterStarts(func(prefix []int, count float64) bool {
+		if len(prefix) != m.Depth || !validIndex(prefix, m.Dict) || !(count > 0) || math.IsInf(count, 0) {
+			err = fmt.Errorf("invalid start prefix %v with count %v", prefix, count)
+		}
+		return err == nil
+	})
+	if iterErr != nil {
+		return iterErr
+	}
+	return err
+}
+
+// validateChains checks that the prefixes and suffixes of the chains refer to words of the
+// dictionary and that the counts are positive
+func (m *Markov) validateChains(name string, chains Storage) error {
+	var err error
+	rangeErr := chains.Range(func(prefix []int, suffixes []WordCount) bool {
+		switch {
+		case len(prefix) == 0 || len(prefix) > m.Depth || !validIndex(prefix, m.Dict):
+			err = fmt.Errorf("%v: invalid prefix %v", name, prefix)
+		case len(suffixes) == 0:
+			err = fmt.Errorf("%v: prefix %v has no suffixes", name, prefix)
+		}
+
+		for _, suffix := range suffixes {
+			if err != nil {
+				break
+			}
+			if suffix.Idx < 0 || suffix.Idx >= m.Dict.Len() {
+				err = fmt.Errorf("%v: prefix %v has the unknown suffix %v", name, prefix, suffix.Idx)
+			} else if !(suffix.Count > 0) || math.IsInf(suffix.Count, 0) {
+				err = fmt.Errorf("%v: prefix %v has the suffix %v with count %v", name, prefix, suffix.Idx, suffix.Count)
+			}
+		}
+		return err == nil
+	})
+	if rangeErr != nil {
+		return rangeErr
+	}
+	return err
+}
@@ -0,0 +1,81 @@
+package garkov
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultWatchInterval is the polling interval of Watch if none is given
+const DefaultWatchInterval time.Duration = 5 * time.Second
+
+// fileState identifies a version of a model file
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watch polls the model files in dir, see FileExtension, and loads every file that is new or
+// changed into the registry, replacing the model of the same name. Models whose file is
+// removed stay in the registry. A file that fails to load is tried again on the next poll,
+// the error is passed to onError if it is not nil. Watch returns when ctx is done.
+func (r *Registry) Watch(ctx context.Context, dir string, interval time.Duration, onError func(error)) error {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	loaded := make(map[string]fileState)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.poll(dir, loaded, onError)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll loads the files of dir that changed since they were loaded
+func (r *Registry) poll(dir string, loaded map[string]fileState, onError func(error)) {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+FileExtension))
+	if err != nil {
+		if onError != nil {
+			onError(err)
+		}
+		return
+	}
+
+	for _, file := range files {
+		fi, err := os.Stat(file)
+		if err != nil {
+			// removed since the directory was read
+			continue
+		}
+
+		state := fileState{modTime: fi.ModTime(), size: fi.Size()}
+		if last, found := loaded[file]; found && last == state {
+			continue
+		}
+
+		m, err := Load(file)
+		if err != nil {
+			r.log(slog.LevelWarn, "failed to load model", "file", file, "error", err)
+			if onError != nil {
+				onError(err)
+			}
+			continue
+		}
+
+		r.Add(strings.TrimSuffix(filepath.Base(file), FileExtension), m)
+		r.log(slog.LevelInfo, "loaded model", "file", file)
+		loaded[file] = state
+	}
+}
@@ -0,0 +1,64 @@
+package garkov
+
+import (
+	"strings"
+
+	"github.com/mickuehl/garkov/dictionary"
+)
+
+// WordFilter decides if a word may be generated. Generation samples only from the suffixes
+// the filter accepts, backs off to shorter prefixes if it rejects all of them, and ends the
+// sentence if there is no way to continue it.
+type WordFilter func(word string) bool
+
+// BannedWords returns a WordFilter that rejects the given words, regardless of their case
+func BannedWords(words ...string) WordFilter {
+	banned := make(map[string]bool, len(words))
+	for _, w := range words {
+		banned[strings.ToLower(w)] = true
+	}
+
+	return func(word string) bool {
+		return !banned[strings.ToLower(word)]
+	}
+}
+
+// ExactWord returns a WordFilter that accepts the word w only, regardless of its case
+func ExactWord(w string) WordFilter {
+	return func(word string) bool {
+		return strings.EqualFold(word, w)
+	}
+}
+
+// WordSuffix returns a WordFilter that accepts the words ending in suffix, regardless of
+// their case, e.g. "ight" for the words that rhyme with night. The suffix itself is not a
+// word that rhymes, it is rejected.
+func WordSuffix(suffix string) WordFilter {
+	suffix = strings.ToLower(suffix)
+	return func(word string) bool {
+		word = strings.ToLower(word)
+		return len(word) > len(suffix) && strings.HasSuffix(word, suffix)
+	}
+}
+
+// Vocabulary returns a WordFilter that accepts the words of the dictionary of m, regardless
+// of their case, and all punctuation. Generating from one model with the vocabulary of
+// another keeps the structure of the first and the words of the second:
+//
+//	a.Generate(GenOptions{WordFilter: Vocabulary(b)})
+//
+// The words are copied, later training of m does not change the filter.
+func Vocabulary(m *Markov) WordFilter {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	words := make(map[string]bool, m.Dict.Len())
+	m.Dict.Range(func(w dictionary.Word) bool {
+		words[strings.ToLower(w.Word)] = true
+		return true
+	})
+
+	return func(word string) bool {
+		return dictionary.TokenType(word) != dictionary.WORD || words[strings.ToLower(word)]
+	}
+}